@@ -0,0 +1,109 @@
+package api
+
+import (
+	"container/list"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/metrics"
+)
+
+// DefaultCacheSize bounds the number of query results held by the cache
+const DefaultCacheSize = 128
+
+// queryCache is a size-bounded LRU cache of query responses with a fixed TTL
+type queryCache struct {
+	ttl     time.Duration
+	maxSize int
+	order   *list.List
+	entries map[string]*list.Element
+	mutex   sync.Mutex
+}
+
+// cacheEntry is a cached response and the time it was stored
+type cacheEntry struct {
+	key      string
+	response APIResponse
+	storedAt time.Time
+}
+
+// newQueryCache creates a cache, or returns nil when ttl disables caching
+func newQueryCache(ttl time.Duration, maxSize int) *queryCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultCacheSize
+	}
+	return &queryCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// cacheKey normalizes query parameters into a cache key
+func cacheKey(params QueryParams) string {
+	params.Entity = strings.TrimSpace(params.Entity)
+	params.Select = strings.TrimSpace(params.Select)
+	params.Filter = strings.TrimSpace(params.Filter)
+	params.OrderBy = strings.TrimSpace(params.OrderBy)
+	params.Expand = strings.TrimSpace(params.Expand)
+	params.Search = strings.TrimSpace(params.Search)
+
+	data, _ := json.Marshal(params)
+	return string(data)
+}
+
+// get returns a copy of a fresh cached response and its age
+func (c *queryCache) get(key string) (*APIResponse, time.Duration, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		metrics.CacheMisses.Inc()
+		return nil, 0, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	age := time.Since(entry.storedAt)
+	if age > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		metrics.CacheMisses.Inc()
+		return nil, 0, false
+	}
+	metrics.CacheHits.Inc()
+
+	c.order.MoveToFront(element)
+	response := entry.response
+	response.Value = append([]map[string]interface{}(nil), entry.response.Value...)
+	return &response, age, true
+}
+
+// put stores a copy of response, evicting the least recently used entry when full
+func (c *queryCache) put(key string, response *APIResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &cacheEntry{key: key, response: *response, storedAt: time.Now()}
+	entry.response.Value = append([]map[string]interface{}(nil), response.Value...)
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		metrics.CacheEvictions.Inc()
+	}
+}
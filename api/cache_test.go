@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueryCacheHit(t *testing.T) {
+	cache := newQueryCache(time.Minute, 0)
+	key := cacheKey(QueryParams{Entity: "Property", Filter: "City eq 'Seattle'"})
+	cache.put(key, &APIResponse{Value: []map[string]interface{}{{"ListingKey": "K1"}}})
+
+	if _, _, ok := cache.get(cacheKey(QueryParams{Entity: "Property", Filter: " City eq 'Seattle' "})); !ok {
+		t.Fatal("a filter differing only in surrounding spaces missed the cache")
+	}
+	cached, _, ok := cache.get(key)
+	if !ok || len(cached.Value) != 1 {
+		t.Fatalf("get = %v, %t, want the stored response", cached, ok)
+	}
+
+	// Callers get a copy, so appending to a cached result cannot change the cache
+	cached.Value = append(cached.Value, map[string]interface{}{"ListingKey": "K2"})
+	if again, _, _ := cache.get(key); len(again.Value) != 1 {
+		t.Errorf("cached response has %d records after a caller changed its copy, want 1", len(again.Value))
+	}
+}
+
+func TestQueryCacheExpiry(t *testing.T) {
+	cache := newQueryCache(20*time.Millisecond, 0)
+	cache.put("key", &APIResponse{})
+	if _, _, ok := cache.get("key"); !ok {
+		t.Fatal("fresh entry missed the cache")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := cache.get("key"); ok {
+		t.Error("entry older than the TTL was served")
+	}
+	if cache.order.Len() != 0 || len(cache.entries) != 0 {
+		t.Errorf("expired entry still held: %d in order, %d in entries", cache.order.Len(), len(cache.entries))
+	}
+}
+
+func TestQueryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newQueryCache(time.Minute, 2)
+	cache.put("a", &APIResponse{})
+	cache.put("b", &APIResponse{})
+	cache.get("a")
+	cache.put("c", &APIResponse{})
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, _, ok := cache.get(key); ok != want {
+			t.Errorf("get(%q) hit = %t, want %t", key, ok, want)
+		}
+	}
+}
+
+func TestNewQueryCacheDisabled(t *testing.T) {
+	if cache := newQueryCache(0, 10); cache != nil {
+		t.Error("zero TTL returned a cache, want caching disabled")
+	}
+}
+
+func TestQueryServedFromCache(t *testing.T) {
+	var requests atomic.Int32
+	client, _ := newTestClientWithOptions(t, ClientOptions{CacheTTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"value":[{"ListingKey":"K1"}]}`)
+	})
+
+	params := QueryParams{Entity: "Property", Filter: "City eq 'Seattle'"}
+	if _, err := client.Query(params); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := client.Query(params)
+			if err != nil || !response.FromCache {
+				t.Errorf("concurrent Query = %v, %v, want a cached response", response, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("stub saw %d requests, want 1", got)
+	}
+}
@@ -1,10 +1,11 @@
 package api
 
 import (
-	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -12,43 +13,272 @@ import (
 	"time"
 
 	"github.com/rennietech/constellation1-mcp-server/auth"
+	"github.com/rennietech/constellation1-mcp-server/logging"
+	"github.com/rennietech/constellation1-mcp-server/metrics"
 )
 
 // Client represents the RESO API client
 type Client struct {
-	baseURL     string
-	oauthClient *auth.OAuthClient
-	httpClient  *http.Client
+	baseURL         string
+	metadataURL     string
+	oauthClient     *auth.OAuthClient
+	httpClient      *http.Client
+	debug           bool
+	cache           *queryCache
+	limiter         *rateLimiter
+	userAgent       string
+	host            string
+	logger          *logging.Logger
+	allowedEntities map[string]bool
+	redactFields    map[string]bool
+	disableCount    bool
+	requestIDHeader string
+	correlation     correlation
+	maintenance     *maintenanceBreaker
+
+	// ctx is cancelled by Close, aborting every request still running
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// ClientOptions holds optional client behavior
+type ClientOptions struct {
+	// Debug logs every request to stderr and records it in APIResponse.Debug
+	Debug bool
+	// Timeout bounds each HTTP request; zero uses DefaultTimeout
+	Timeout time.Duration
+	// CacheTTL keeps successful query results in memory; zero disables the cache
+	CacheTTL time.Duration
+	// CacheSize bounds the number of cached queries; zero uses DefaultCacheSize
+	CacheSize int
+	// RateLimit caps outgoing requests per second; zero disables limiting
+	RateLimit float64
+	// UserAgent is sent with every request; empty uses DefaultUserAgent
+	UserAgent string
+	// Host overrides the Host header; empty derives it from the base URL
+	Host string
+	// Logger receives retry and failure events; nil logs to stderr only
+	Logger *logging.Logger
+	// MetadataPath locates $metadata: an absolute URL, or a reference resolved
+	// against the service root ("$metadata", "../$metadata"). Empty uses
+	// DefaultMetadataURL.
+	MetadataPath string
+	// AllowedEntities restricts queries to these entities; empty allows every
+	// supported entity
+	AllowedEntities []string
+	// RedactFields are masked in every query result (see DefaultRedactFields);
+	// empty disables redaction
+	RedactFields []string
+	// DisableCount stops sending $count=true with queries, for servers that
+	// reject it
+	DisableCount bool
+	// RequestIDHeader carries each call's correlation ID; empty uses
+	// DefaultRequestIDHeader
+	RequestIDHeader string
+	// Transport carries every request, so its connection pool can be shared (see
+	// NewTransport); nil uses http.DefaultTransport
+	Transport http.RoundTripper
+	// MaintenanceCooldown is how long queries fail fast after MaintenanceThreshold
+	// consecutive 503s (see DefaultMaintenanceCooldown); zero disables the breaker
+	MaintenanceCooldown time.Duration
+}
+
+// DefaultTimeout is the HTTP timeout used when ClientOptions.Timeout is unset
+const DefaultTimeout = 60 * time.Second
+
+// MaxGETURLLength is the longest query URL sent as a GET; longer queries are sent
+// as POST <entity>/$query
+const MaxGETURLLength = 6 * 1024
+
+// DefaultUserAgent is the User-Agent used when ClientOptions.UserAgent is unset
+const DefaultUserAgent = "RESO-MCP-Server/1.0"
+
+// DefaultMetadataURL returns the metadata URL used when no metadata path is
+// configured. Constellation1 serves $metadata beside its /odata service root;
+// other service roots use the OData standard <root>/$metadata.
+func DefaultMetadataURL(serviceRoot string) string {
+	return strings.TrimSuffix(serviceRoot, "/odata") + "/$metadata"
+}
+
+// resolveMetadataURL resolves a configured metadata path against the service root.
+// The result must stay on the service root's scheme and host, so the bearer token is
+// never sent to another server.
+func resolveMetadataURL(serviceRoot, metadataPath string) (string, error) {
+	if metadataPath == "" {
+		return DefaultMetadataURL(serviceRoot), nil
+	}
+	ref, err := url.Parse(metadataPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid metadata path %q: %w", metadataPath, err)
+	}
+	root, err := url.Parse(serviceRoot + "/")
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", serviceRoot, err)
+	}
+
+	resolved := root.ResolveReference(ref)
+	if !strings.EqualFold(resolved.Scheme, root.Scheme) || !strings.EqualFold(resolved.Host, root.Host) {
+		return "", fmt.Errorf("metadata path %q does not point at the configured API host %s", metadataPath, root.Host)
+	}
+	return resolved.String(), nil
 }
 
 // NewClient creates a new RESO API client
 func NewClient(baseURL string, oauthClient *auth.OAuthClient) *Client {
+	return NewClientWithOptions(baseURL, oauthClient, ClientOptions{})
+}
+
+// NewClientWithOptions creates a new RESO API client with optional behavior
+func NewClientWithOptions(baseURL string, oauthClient *auth.OAuthClient, opts ClientOptions) *Client {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	// Entity and metadata URLs are joined with "/", so drop any trailing slashes
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	metadataURL, err := resolveMetadataURL(baseURL, strings.TrimSpace(opts.MetadataPath))
+	if err != nil {
+		log.Printf("Warning: %v; using %s", err, DefaultMetadataURL(baseURL))
+		metadataURL = DefaultMetadataURL(baseURL)
+	}
+
+	requestIDHeader := strings.TrimSpace(opts.RequestIDHeader)
+	if requestIDHeader != "" && !headerNamePattern.MatchString(requestIDHeader) {
+		log.Printf("Warning: invalid request ID header %q; using %s", requestIDHeader, DefaultRequestIDHeader)
+		requestIDHeader = ""
+	}
+	if requestIDHeader == "" {
+		requestIDHeader = DefaultRequestIDHeader
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Client{
 		baseURL:     baseURL,
+		metadataURL: metadataURL,
 		oauthClient: oauthClient,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   timeout,
+			Transport: opts.Transport,
 		},
+		debug:           opts.Debug,
+		cache:           newQueryCache(opts.CacheTTL, opts.CacheSize),
+		limiter:         newRateLimiter(opts.RateLimit),
+		userAgent:       userAgent,
+		host:            opts.Host,
+		logger:          opts.Logger,
+		allowedEntities: newEntityPolicy(opts.AllowedEntities),
+		redactFields:    newRedactFields(opts.RedactFields),
+		disableCount:    opts.DisableCount,
+		requestIDHeader: requestIDHeader,
+		maintenance:     newMaintenanceBreaker(opts.MaintenanceCooldown, opts.Logger),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+// BaseURL returns the OData service root the client queries
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// MetadataURL returns the URL GetMetadata fetches
+func (c *Client) MetadataURL() string {
+	return c.metadataURL
+}
+
+// Close cancels every request still running on the client and closes its idle
+// connections; later requests fail immediately
+func (c *Client) Close() {
+	c.cancel()
+	c.httpClient.CloseIdleConnections()
+}
+
+// bind derives a context from ctx that is also cancelled when the client is closed
+func (c *Client) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
 	}
 }
 
 // Query executes a query against the RESO API
 func (c *Client) Query(params QueryParams) (*APIResponse, error) {
+	return c.QueryContext(context.Background(), params)
+}
+
+// QueryContext executes a query against the RESO API, aborting when ctx is done
+func (c *Client) QueryContext(ctx context.Context, params QueryParams) (*APIResponse, error) {
+	metrics.Queries.Inc()
+	ctx, _ = c.withRequestID(ctx)
+	ctx, release, err := queries.Acquire(ctx)
+	if err != nil {
+		metrics.QueryErrors.Inc()
+		return nil, fmt.Errorf("query cancelled while waiting for a free request slot: %w", err)
+	}
+	defer release()
+
+	response, err := c.query(ctx, params)
+	if err != nil {
+		metrics.QueryErrors.Inc()
+	}
+	return response, err
+}
+
+// query runs a query for QueryContext, which counts it
+func (c *Client) query(ctx context.Context, params QueryParams) (*APIResponse, error) {
 	startTime := time.Now()
+	ctx, release := c.bind(ctx)
+	defer release()
 
 	// Validate entity
 	if !IsValidEntity(params.Entity) {
 		return nil, fmt.Errorf("unsupported entity: %s", params.Entity)
 	}
+	if err := c.CheckEntityAllowed(params.Entity); err != nil {
+		return nil, err
+	}
+
+	// A delta link returns the changes since it was issued, so it is never cached
+	if params.DeltaLink != "" {
+		params.NoCache = true
+	}
 
 	// Validate skip limit
 	if params.Skip > 0 {
-		limit := GetEntitySkipLimit(params.Entity)
-		if params.Skip > limit {
-			return nil, fmt.Errorf("skip value %d exceeds limit %d for entity %s", params.Skip, limit, params.Entity)
+		if err := c.checkSkipLimit(ctx, params); err != nil {
+			return nil, err
 		}
 	}
 
+	// Serve repeated queries from the cache
+	var key string
+	if c.cache != nil && !params.NoCache {
+		key = cacheKey(params)
+		if cached, age, ok := c.cache.get(key); ok {
+			cached.FromCache = true
+			cached.CacheAge = age
+			return c.redact(cached), nil
+		}
+	}
+
+	// Fail fast while the API is in a maintenance window; cached results still serve
+	probe, maintenanceErr := c.maintenance.allow()
+	if maintenanceErr != nil {
+		return nil, maintenanceErr
+	}
+	if probe {
+		defer c.maintenance.endProbe()
+	}
+
 	// Build URL
 	apiURL := fmt.Sprintf("%s/%s", c.baseURL, params.Entity)
 
@@ -79,6 +309,10 @@ func (c *Client) Query(params QueryParams) (*APIResponse, error) {
 		queryParams.Set("$expand", params.Expand)
 	}
 
+	if params.Apply != "" {
+		queryParams.Set("$apply", params.Apply)
+	}
+
 	if params.IgnoreNulls {
 		queryParams.Set("$ignorenulls", "true")
 	}
@@ -87,114 +321,395 @@ func (c *Client) Query(params QueryParams) (*APIResponse, error) {
 		queryParams.Set("$ignorecase", "true")
 	}
 
-	// Add query parameters to URL
-	if len(queryParams) > 0 {
-		apiURL += "?" + queryParams.Encode()
+	if search := formatSearch(params.Search); search != "" {
+		queryParams.Set("$search", search)
 	}
 
-	// Get access token
-	token, err := c.oauthClient.GetToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
+	// Ask for the total match count; aggregated $apply results have no use for it
+	countRequested := !c.disableCount && params.Apply == "" && params.DeltaLink == ""
+	if countRequested {
+		queryParams.Set("$count", "true")
 	}
 
-	// Create request
-	req, err := http.NewRequest("GET", apiURL, nil)
+	// Send the query options in the URL, or via POST <entity>/$query when the URL
+	// would exceed MaxGETURLLength. A delta link already carries its query.
+	var apiResp *APIResponse
+	var err error
+	encoded := queryParams.Encode()
+	switch {
+	case params.DeltaLink != "":
+		deltaURL, linkErr := c.resolveDeltaLink(params.DeltaLink, params.Entity)
+		if linkErr != nil {
+			return nil, linkErr
+		}
+		apiResp, err = c.fetchPage(ctx, http.MethodGet, deltaURL, "")
+	case len(apiURL)+1+len(encoded) > MaxGETURLLength:
+		apiResp, err = c.fetchPage(ctx, http.MethodPost, apiURL+"/$query", encoded)
+	default:
+		if encoded != "" {
+			apiURL += "?" + encoded
+		}
+		apiResp, err = c.fetchPage(ctx, http.MethodGet, apiURL, "")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		if params.Search != "" && searchNotSupported(err) {
+			return nil, &SearchNotSupportedError{Cause: err}
+		}
+		return nil, err
 	}
+	reportedCount := apiResp.Count
 
-	// Set headers
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Host", "listings.cdatalabs.com")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("User-Agent", "RESO-MCP-Server/1.0")
+	// Follow @odata.nextLink until exhausted or the record cap is reached
+	if params.FetchAll {
+		maxRecords := params.MaxRecords
+		if maxRecords <= 0 {
+			maxRecords = DefaultMaxRecords
+		}
 
-	// Make request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		apiResp.PagesFetched = 1
+		for apiResp.NextLink != "" && len(apiResp.Value) < maxRecords {
+			nextURL, err := c.resolveNextLink(apiResp.NextLink)
+			if err != nil {
+				return nil, err
+			}
+
+			page, err := c.fetchPage(ctx, http.MethodGet, nextURL, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch page %d: %w", apiResp.PagesFetched+1, err)
+			}
+
+			apiResp.Value = append(apiResp.Value, page.Value...)
+			apiResp.NextLink = page.NextLink
+			if page.DeltaLink != "" {
+				apiResp.DeltaLink = page.DeltaLink
+			}
+			if requests, ok := page.Debug["requests"].([]map[string]interface{}); ok && apiResp.Debug != nil {
+				if existing, ok := apiResp.Debug["requests"].([]map[string]interface{}); ok {
+					apiResp.Debug["requests"] = append(existing, requests...)
+				}
+			}
+			apiResp.PagesFetched++
+		}
+
+		// Cutting the last page short leaves its nextLink pointing past the dropped
+		// records, so drop the link too; callers resume by $skip instead
+		if len(apiResp.Value) > maxRecords {
+			apiResp.Value = apiResp.Value[:maxRecords]
+			apiResp.NextLink = ""
+			apiResp.Truncated = true
+		}
 	}
-	defer resp.Body.Close()
 
-	// Read response with decompression support
-	var reader io.Reader = resp.Body
+	resolveTotalCount(apiResp, params, reportedCount, countRequested)
 
-	// Check if response is gzip-compressed
-	if strings.Contains(resp.Header.Get("Content-Encoding"), "gzip") {
-		gzipReader, err := gzip.NewReader(resp.Body)
+	// Add metadata
+	apiResp.RequestTime = startTime
+	apiResp.ResponseTime = time.Since(startTime)
+	apiResp.RequestParams = params
+
+	if c.cache != nil && !params.NoCache {
+		c.cache.put(key, apiResp)
+	}
+
+	return c.redact(apiResp), nil
+}
+
+// resolveTotalCount sets Count to the number of records returned and fills in
+// TotalCount, marking it exact when the server reported it (as @odata.totalCount, or
+// as @odata.count in answer to $count=true) or when this response holds every
+// remaining record. Otherwise TotalCount is only a lower bound.
+func resolveTotalCount(response *APIResponse, params QueryParams, reportedCount int, countRequested bool) {
+	returned := len(response.Value)
+	switch {
+	case response.totalCountReported:
+		response.TotalCountExact = true
+	case countRequested && response.countReported:
+		response.TotalCount = reportedCount
+		response.TotalCountExact = true
+	case !response.MoreAvailable() && (params.Top <= 0 || returned < params.Top):
+		response.TotalCount = params.Skip + returned
+		response.TotalCountExact = true
+	default:
+		response.TotalCount = params.Skip + returned
+		response.TotalCountExact = false
+	}
+	response.Count = returned
+}
+
+// fetchPage performs a single authenticated request and decodes the response. A
+// non-empty payload is sent as text/plain, as OData expects for POST $query.
+func (c *Client) fetchPage(ctx context.Context, method, apiURL, payload string) (*APIResponse, error) {
+	// Successful bodies are decoded as they stream in rather than buffered whole. The
+	// result is only kept once decoding succeeds, so a retried request starts clean.
+	var apiResp *APIResponse
+	decode := func(reader io.Reader) error {
+		decoded, err := decodeAPIResponse(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+			return fmt.Errorf("failed to parse response: %w", err)
 		}
-		defer gzipReader.Close()
-		reader = gzipReader
+		apiResp = decoded
+		return nil
 	}
 
-	body, err := io.ReadAll(reader)
+	status, body, debugInfo, err := c.send(ctx, "Query", method, apiURL, payload, decode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
 	// Check for error response
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 		var errorResp ErrorResponse
-		if err := json.Unmarshal(body, &errorResp); err == nil {
-			return nil, fmt.Errorf("API error (%d): %s - %s", resp.StatusCode, errorResp.Error.Code, errorResp.Error.Message)
+		if err := json.Unmarshal(body, &errorResp); err == nil && (errorResp.Error.Code != "" || errorResp.Error.Message != "") {
+			return nil, &APIError{Status: status, Message: formatAPIError(status, &errorResp), RequestID: RequestIDFromContext(ctx)}
 		}
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{Status: status, Message: fmt.Sprintf("API request failed with status %d: %s", status, string(body)), RequestID: RequestIDFromContext(ctx)}
 	}
 
-	// Parse successful response
-	var apiResp APIResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if debugInfo != nil {
+		if apiResp.Debug == nil {
+			apiResp.Debug = make(map[string]interface{})
+		}
+		apiResp.Debug["request_id"] = RequestIDFromContext(ctx)
+		apiResp.Debug["requests"] = []map[string]interface{}{debugInfo}
 	}
 
-	// Add metadata
-	apiResp.RequestTime = startTime
-	apiResp.ResponseTime = time.Since(startTime)
-	apiResp.RequestParams = params
+	return apiResp, nil
+}
 
-	return &apiResp, nil
+// send performs an authenticated request. On a 401 or 403 the cached token is
+// cleared and the request is retried exactly once with a fresh token. A non-nil decode
+// consumes a 200 body as it streams in, and the returned body is then nil.
+func (c *Client) send(ctx context.Context, operation, method, requestURL, payload string, decode func(io.Reader) error) (int, []byte, map[string]interface{}, error) {
+	status, body, debugInfo, err := c.sendOnce(ctx, operation, method, requestURL, payload, decode)
+	c.maintenance.record(status, err)
+	if err == nil && (status == http.StatusUnauthorized || status == http.StatusForbidden) {
+		c.logger.Warningf("api", "%s %s returned %d, retrying once with a fresh token", operation, method, status)
+		c.oauthClient.ClearToken()
+		status, body, debugInfo, err = c.sendOnce(ctx, operation, method, requestURL, payload, decode)
+		c.maintenance.record(status, err)
+		if err == nil && (status == http.StatusUnauthorized || status == http.StatusForbidden) {
+			c.logger.Errorf("api", "%s %s still returned %d after refreshing the token; check the credentials' API permissions", operation, method, status)
+		}
+	}
+	switch {
+	case err != nil:
+		c.logger.Errorf("api", "%s %s failed (request ID %s): %v", operation, method, RequestIDFromContext(ctx), err)
+	case status != http.StatusOK:
+		c.logger.Errorf("api", "%s %s returned %d (request ID %s)", operation, method, status, RequestIDFromContext(ctx))
+	}
+	return status, body, debugInfo, err
 }
 
-// GetMetadata retrieves the metadata for the RESO API
-func (c *Client) GetMetadata() (string, error) {
-	// Get access token
-	token, err := c.oauthClient.GetToken()
+// sendOnce performs a single authenticated request and returns the status code,
+// decoded body and debug details
+func (c *Client) sendOnce(ctx context.Context, operation, method, requestURL, payload string, decode func(io.Reader) error) (int, []byte, map[string]interface{}, error) {
+	// Get access token (refreshed as needed on every request)
+	token, err := c.oauthClient.GetTokenContext(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get access token: %w", err)
+		return 0, nil, nil, fmt.Errorf("failed to get access token: %w", err)
 	}
 
-	// Create request  
-	metadataURL := strings.TrimSuffix(c.baseURL, "/odata") + "/$metadata"
-	req, err := http.NewRequest("GET", metadataURL, nil)
+	// Create request
+	var reqBody io.Reader
+	if payload != "" {
+		reqBody = strings.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Host", "listings.cdatalabs.com")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("User-Agent", "RESO-MCP-Server/1.0")
+	c.setHeaders(req, token)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(c.requestIDHeader, requestID)
+	}
+	if payload != "" {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	// Wait for a rate limit slot
+	if err := c.limiter.Wait(ctx); err != nil {
+		return 0, nil, nil, fmt.Errorf("rate limit wait aborted: %w", err)
+	}
 
 	// Make request
+	requestStart := time.Now()
+	metrics.APIRequests.Inc()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		metrics.APIErrors.Inc()
+		return 0, nil, nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		metrics.APIErrors.Inc()
+	}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	// Read response with decompression support
+	reader, release, err := bodyReader(resp)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return 0, nil, nil, err
+	}
+	defer release()
+	counter := &countingReader{reader: reader}
+
+	var body []byte
+	if decode != nil && resp.StatusCode == http.StatusOK {
+		if err := decode(counter); err != nil {
+			return 0, nil, nil, err
+		}
+		// Drain any trailing bytes so the connection can be reused
+		io.Copy(io.Discard, counter)
+	} else {
+		body, err = io.ReadAll(counter)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to read response: %w", err)
+		}
+	}
+	debugInfo := c.debugRequest(operation, method, requestURL, resp, counter.n, time.Since(requestStart))
+	if debugInfo != nil {
+		debugInfo["request_id"] = RequestIDFromContext(ctx)
+	}
+
+	return resp.StatusCode, body, debugInfo, nil
+}
+
+// setHeaders applies the authorization, encoding, User-Agent and Host headers to a request
+func (c *Client) setHeaders(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	// net/http takes the Host header from req.Host, which defaults to the URL host
+	if c.host != "" {
+		req.Host = c.host
+	}
+}
+
+// APIError is a query rejected by the server with a non-200 status
+type APIError struct {
+	Status    int
+	Message   string
+	RequestID string
+}
+
+// Error returns the formatted server error, with the correlation ID to quote to the
+// provider's support team
+func (e *APIError) Error() string {
+	if e.RequestID == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s\n  (request ID: %s)", e.Message, e.RequestID)
+}
+
+// formatAPIError renders an OData error, including any per-target details
+func formatAPIError(status int, errorResp *ErrorResponse) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("API error (%d): %s - %s", status, errorResp.Error.Code, errorResp.Error.Message))
+
+	for _, detail := range errorResp.Error.Details {
+		message.WriteString("\n  - ")
+		if detail.Target != "" {
+			message.WriteString(detail.Target + ": ")
+		}
+		message.WriteString(detail.Message)
+		if detail.Code != "" {
+			message.WriteString(fmt.Sprintf(" (%s)", detail.Code))
+		}
+	}
+
+	return message.String()
+}
+
+// debugRequest logs a completed request to stderr and returns its details when debug
+// is enabled. The Authorization header is never included.
+func (c *Client) debugRequest(operation, method, requestURL string, resp *http.Response, size int, elapsed time.Duration) map[string]interface{} {
+	if !c.debug {
+		return nil
+	}
+
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding == "" {
+		encoding = "identity"
+	}
+
+	log.Printf("[debug] %s %s %s -> %d (content-encoding: %s, %d bytes, %s)",
+		operation, method, requestURL, resp.StatusCode, encoding, size, elapsed)
+
+	return map[string]interface{}{
+		"operation":        operation,
+		"method":           method,
+		"request_url":      requestURL,
+		"status":           resp.StatusCode,
+		"content_encoding": encoding,
+		"bytes":            size,
+		"elapsed_ms":       elapsed.Milliseconds(),
+	}
+}
+
+// resolveNextLink turns an @odata.nextLink (absolute or relative) into a request URL.
+// The link must stay on the configured API scheme and host, so the bearer token is
+// never sent to another server.
+func (c *Client) resolveNextLink(nextLink string) (string, error) {
+	next, err := url.Parse(nextLink)
+	if err != nil {
+		return "", fmt.Errorf("invalid nextLink %q: %w", nextLink, err)
+	}
+	base, err := url.Parse(c.baseURL + "/")
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", c.baseURL, err)
+	}
+
+	resolved := base.ResolveReference(next)
+	if !strings.EqualFold(resolved.Scheme, base.Scheme) || !strings.EqualFold(resolved.Host, base.Host) {
+		return "", fmt.Errorf("link %q does not point at the configured API host %s", nextLink, base.Host)
+	}
+	return resolved.String(), nil
+}
+
+// resolveDeltaLink turns a stored @odata.deltaLink into a request URL. The link
+// must point at entity on the configured API host, so a token from another source
+// cannot send credentials elsewhere or read an entity the policy excludes.
+func (c *Client) resolveDeltaLink(deltaLink, entity string) (string, error) {
+	resolved, err := c.resolveNextLink(deltaLink)
+	if err != nil {
+		return "", fmt.Errorf("delta link rejected: %w", err)
+	}
+	link, err := url.Parse(resolved)
+	if err != nil {
+		return "", fmt.Errorf("invalid delta link %q: %w", deltaLink, err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", c.baseURL, err)
+	}
+
+	if !strings.EqualFold(strings.TrimSuffix(link.Path, "/"), strings.TrimSuffix(base.Path, "/")+"/"+entity) {
+		return "", fmt.Errorf("delta link %q is not a %s query", deltaLink, entity)
+	}
+	return resolved, nil
+}
+
+// GetMetadata retrieves the metadata for the RESO API
+func (c *Client) GetMetadata() (string, error) {
+	return c.GetMetadataContext(context.Background())
+}
+
+// GetMetadataContext retrieves the metadata for the RESO API, aborting when ctx is done
+func (c *Client) GetMetadataContext(ctx context.Context) (string, error) {
+	ctx, requestID := c.withRequestID(ctx)
+	ctx, release := c.bind(ctx)
+	defer release()
+
+	status, body, _, err := c.send(ctx, "GetMetadata", http.MethodGet, c.metadataURL, "", nil)
+	if err != nil {
+		return "", err
 	}
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("metadata request failed with status %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return "", fmt.Errorf("metadata request failed with status %d (request ID %s): %s", status, requestID, string(body))
 	}
 
 	return string(body), nil
@@ -213,6 +728,7 @@ func (c *Client) TestConnection() error {
 		Entity:      "Property",
 		Top:         1,
 		IgnoreNulls: true,
+		NoCache:     true,
 	}
 
 	_, err = c.Query(params)
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/auth"
+)
+
+// newTestClient starts a stub API that issues numbered tokens (test-token-1, ...) at
+// /token and hands every other request to handler, and returns a client pointed at it
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+	return newTestClientWithOptions(t, ClientOptions{}, handler)
+}
+
+// newTestClientWithOptions is newTestClient with client options
+func newTestClientWithOptions(t *testing.T, opts ClientOptions, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"test-token-%d","expires_in":3600,"token_type":"Bearer"}`, tokens.Add(1))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	oauthClient := auth.NewOAuthClient("id", "secret", server.URL+"/token")
+	client := NewClientWithOptions(server.URL+"/odata", oauthClient, opts)
+	return client, server
+}
+
+func TestLongInListFallsBackToPostQuery(t *testing.T) {
+	keys := make([]string, 2000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("'K%d'", i+1)
+	}
+	filter := "ListingKey in (" + strings.Join(keys, ",") + ")"
+
+	requests := 0
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if r.URL.Path != "/odata/Property/$query" {
+			t.Errorf("path = %s, want /odata/Property/$query", r.URL.Path)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("query string = %q, want the options only in the body", r.URL.RawQuery)
+		}
+		if contentType := r.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("Content-Type = %q, want text/plain", contentType)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		options, parseErr := url.ParseQuery(string(body))
+		if err != nil || parseErr != nil {
+			t.Errorf("body is not URL-encoded query options: %v, %v", err, parseErr)
+		}
+		if options.Get("$filter") != filter {
+			t.Errorf("$filter in body has %d bytes, want the %d-byte filter unchanged", len(options.Get("$filter")), len(filter))
+		}
+		if options.Get("$top") != "10" {
+			t.Errorf("$top = %q, want 10", options.Get("$top"))
+		}
+		fmt.Fprint(w, `{"value":[{"ListingKey":"K1"}]}`)
+	})
+
+	response, err := client.Query(QueryParams{Entity: "Property", Filter: filter, Top: 10, NoCache: true})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if requests != 1 || len(response.Value) != 1 {
+		t.Errorf("stub saw %d requests and returned %d records, want 1 and 1", requests, len(response.Value))
+	}
+}
+
+func TestShortQueryUsesGet(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/odata/Property" {
+			t.Errorf("request = %s %s, want GET /odata/Property", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("$filter"); got != "ListingKey in ('K1','K2')" {
+			t.Errorf("$filter = %q", got)
+		}
+		fmt.Fprint(w, `{"value":[]}`)
+	})
+
+	if _, err := client.Query(QueryParams{Entity: "Property", Filter: "ListingKey in ('K1','K2')", NoCache: true}); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+}
+
+func TestUnauthorizedRetriesOnceWithFreshToken(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			var authorizations []string
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				if len(authorizations) == 1 {
+					w.WriteHeader(status)
+					return
+				}
+				fmt.Fprint(w, `{"value":[{"ListingKey":"K1"}]}`)
+			})
+
+			response, err := client.Query(QueryParams{Entity: "Property", NoCache: true})
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if len(response.Value) != 1 {
+				t.Errorf("got %d records, want 1", len(response.Value))
+			}
+			want := []string{"Bearer test-token-1", "Bearer test-token-2"}
+			if strings.Join(authorizations, ",") != strings.Join(want, ",") {
+				t.Errorf("Authorization headers = %v, want %v", authorizations, want)
+			}
+		})
+	}
+}
+
+func TestUnauthorizedRetriesOnlyOnce(t *testing.T) {
+	requests := 0
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":{"code":"Forbidden","message":"no access"}}`)
+	})
+
+	_, err := client.Query(QueryParams{Entity: "Property", NoCache: true})
+	if err == nil || !strings.Contains(err.Error(), "API error (403)") {
+		t.Fatalf("err = %v, want the 403 error", err)
+	}
+	if requests != 2 {
+		t.Errorf("stub saw %d requests, want the original and exactly one retry", requests)
+	}
+}
+
+func TestOtherErrorsAreNotRetried(t *testing.T) {
+	requests := 0
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":"BadRequest","message":"bad filter"}}`)
+	})
+
+	if _, err := client.Query(QueryParams{Entity: "Property", NoCache: true}); err == nil {
+		t.Fatal("Query succeeded, want a 400 error")
+	}
+	if requests != 1 {
+		t.Errorf("stub saw %d requests, want 1", requests)
+	}
+}
+
+func TestFetchAllTruncationDropsNextLink(t *testing.T) {
+	var server *httptest.Server
+	client, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var records []string
+		for i := 0; i < 3; i++ {
+			records = append(records, fmt.Sprintf(`{"ListingKey":"K%d"}`, page*3+i+1))
+		}
+		next := ""
+		if page < 2 {
+			next = fmt.Sprintf(`,"@odata.nextLink":"%s/odata/Property?page=%d"`, server.URL, page+1)
+		}
+		fmt.Fprintf(w, `{"value":[%s]%s}`, strings.Join(records, ","), next)
+	})
+
+	response, err := client.Query(QueryParams{Entity: "Property", FetchAll: true, MaxRecords: 4, NoCache: true})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(response.Value) != 4 {
+		t.Fatalf("got %d records, want 4", len(response.Value))
+	}
+	if response.NextLink != "" {
+		t.Errorf("NextLink = %q, want empty after truncating a page", response.NextLink)
+	}
+	if !response.Truncated || !response.MoreAvailable() {
+		t.Errorf("Truncated = %t, MoreAvailable = %t, want both true", response.Truncated, response.MoreAvailable())
+	}
+	if response.TotalCountExact {
+		t.Errorf("TotalCountExact = true, want false when records were dropped")
+	}
+}
+
+func TestFetchAllRejectsForeignNextLink(t *testing.T) {
+	requests := 0
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"value":[{"ListingKey":"K1"}],"@odata.nextLink":"https://attacker.example/odata/Property?page=1"}`)
+	})
+
+	_, err := client.Query(QueryParams{Entity: "Property", FetchAll: true, NoCache: true})
+	if err == nil || !strings.Contains(err.Error(), "configured API host") {
+		t.Fatalf("err = %v, want a rejected foreign nextLink", err)
+	}
+	if requests != 1 {
+		t.Errorf("stub saw %d requests, want 1", requests)
+	}
+}
+
+func TestResolveMetadataURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"default beside /odata", "https://listings.example.com/odata", "", "https://listings.example.com/$metadata", false},
+		{"relative path", "https://api.example.com/reso/odata", "$metadata", "https://api.example.com/reso/odata/$metadata", false},
+		{"parent path", "https://api.example.com/reso/odata", "../$metadata", "https://api.example.com/reso/$metadata", false},
+		{"absolute path", "https://api.example.com/odata", "/reso/$metadata", "https://api.example.com/reso/$metadata", false},
+		{"absolute URL on the same host", "https://api.example.com/odata", "https://API.example.com/meta", "https://API.example.com/meta", false},
+		{"absolute URL on another host", "https://api.example.com/odata", "https://attacker.example/$metadata", "", true},
+		{"scheme-relative URL", "https://api.example.com/odata", "//attacker.example/$metadata", "", true},
+		{"downgraded scheme", "https://api.example.com/odata", "http://api.example.com/$metadata", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMetadataURL(tt.root, tt.path)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("resolveMetadataURL(%q, %q) = %q, %v, want %q (error %t)", tt.root, tt.path, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForeignMetadataPathKeepsToken(t *testing.T) {
+	var requests atomic.Int32
+	attacker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	t.Cleanup(attacker.Close)
+
+	client, server := newTestClientWithOptions(t, ClientOptions{MetadataPath: attacker.URL + "/$metadata"}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<edmx:Edmx/>`)
+	})
+	if got, want := client.MetadataURL(), server.URL+"/$metadata"; got != want {
+		t.Errorf("MetadataURL = %q, want the default %q", got, want)
+	}
+	if _, err := client.GetMetadata(); err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("foreign metadata host saw %d requests, want none", got)
+	}
+}
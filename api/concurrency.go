@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultMaxConcurrency is the default number of queries allowed upstream at once
+const DefaultMaxConcurrency = 4
+
+// concurrencyLimiter is a semaphore that caps how many queries run at once across
+// every client in the process. Callers beyond the cap queue in arrival order.
+type concurrencyLimiter struct {
+	mutex   sync.Mutex
+	limit   int
+	active  int
+	waiters []chan struct{}
+}
+
+// queries is the process-wide limiter shared by every Client, so sessions in HTTP
+// mode and concurrent tool calls all count against one cap
+var queries = &concurrencyLimiter{limit: DefaultMaxConcurrency}
+
+// slotKey marks a context that already holds a query slot, so nested queries such as
+// the skip limit lookup run inside their caller's slot instead of waiting for another
+type slotKey struct{}
+
+// SetMaxConcurrency sets how many queries may run at once; zero or less removes the cap
+func SetMaxConcurrency(limit int) {
+	queries.setLimit(limit)
+}
+
+// setLimit changes the cap, admitting queued callers that now fit
+func (l *concurrencyLimiter) setLimit(limit int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limit = limit
+	for len(l.waiters) > 0 && (l.limit <= 0 || l.active < l.limit) {
+		l.admit()
+	}
+}
+
+// admit hands a slot to the longest-waiting caller; the caller must hold the mutex
+func (l *concurrencyLimiter) admit() {
+	waiter := l.waiters[0]
+	l.waiters = l.waiters[1:]
+	l.active++
+	close(waiter)
+}
+
+// Acquire waits for a free slot or for ctx to be done. It returns a context marking
+// the slot as held and a function that releases it. A context that already holds a
+// slot is returned unchanged.
+func (l *concurrencyLimiter) Acquire(ctx context.Context) (context.Context, func(), error) {
+	if ctx.Value(slotKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	l.mutex.Lock()
+	if len(l.waiters) == 0 && (l.limit <= 0 || l.active < l.limit) {
+		l.active++
+		l.mutex.Unlock()
+		return context.WithValue(ctx, slotKey{}, true), l.release, nil
+	}
+	waiter := make(chan struct{})
+	l.waiters = append(l.waiters, waiter)
+	l.mutex.Unlock()
+
+	select {
+	case <-waiter:
+		return context.WithValue(ctx, slotKey{}, true), l.release, nil
+	case <-ctx.Done():
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		for i, w := range l.waiters {
+			if w == waiter {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return ctx, nil, ctx.Err()
+			}
+		}
+		// The slot was granted while ctx was being cancelled; pass it on
+		l.releaseLocked()
+		return ctx, nil, ctx.Err()
+	}
+}
+
+// release frees a slot, admitting the next queued caller if any
+func (l *concurrencyLimiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked frees a slot; the caller must hold the mutex
+func (l *concurrencyLimiter) releaseLocked() {
+	l.active--
+	for len(l.waiters) > 0 && (l.limit <= 0 || l.active < l.limit) {
+		l.admit()
+	}
+}
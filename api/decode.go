@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// decodeAPIResponse stream-decodes an OData collection response. Records in the
+// "value" array are decoded one at a time, so the raw body is never held in memory.
+// Of the remaining top-level members only the @odata.* control information and the
+// "group" array are decoded; fields such as from_cache, truncated and
+// redacted_fields are set by the client, and a server must not be able to set them.
+func decodeAPIResponse(reader io.Reader) (*APIResponse, error) {
+	decoder := json.NewDecoder(reader)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	members := make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %v in response object", token)
+		}
+
+		if key == "value" {
+			if records, err = decodeRecords(decoder); err != nil {
+				return nil, fmt.Errorf("value: %w", err)
+			}
+			continue
+		}
+
+		var member json.RawMessage
+		if err := decoder.Decode(&member); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if strings.HasPrefix(key, "@odata.") || key == "group" {
+			members[key] = member
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+
+	var apiResp APIResponse
+	encoded, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &apiResp); err != nil {
+		return nil, err
+	}
+	apiResp.Value = records
+
+	// A zero count is indistinguishable from an omitted one once decoded
+	_, apiResp.countReported = members["@odata.count"]
+	_, apiResp.totalCountReported = members["@odata.totalCount"]
+
+	return &apiResp, nil
+}
+
+// decodeRecords decodes the "value" array one record at a time; null yields no records
+func decodeRecords(decoder *json.Decoder) ([]map[string]interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("expected an array, got %v", token)
+	}
+
+	var records []map[string]interface{}
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("record %d: %w", len(records), err)
+		}
+		records = append(records, record)
+	}
+	return records, expectDelim(decoder, ']')
+}
+
+// expectDelim reads the next token and fails unless it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
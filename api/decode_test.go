@@ -0,0 +1,39 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeIgnoresClientFields(t *testing.T) {
+	const body = `{
+		"@odata.context": "https://api.example.com/odata/$metadata#Property",
+		"@odata.count": 2,
+		"@odata.nextLink": "https://api.example.com/odata/Property?$skip=2",
+		"value": [{"ListingKey": "K1"}, {"ListingKey": "K2"}],
+		"group": [{"City": "Seattle"}],
+		"from_cache": true,
+		"cache_age": 1000000000,
+		"truncated": true,
+		"redacted_fields": ["MemberEmail"],
+		"pages_fetched": 9,
+		"total_count_exact": true,
+		"debug": {"requests": []},
+		"request_params": {"entity": "Member"}
+	}`
+
+	resp, err := decodeAPIResponse(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("decodeAPIResponse: %v", err)
+	}
+	if resp.Context == "" || resp.Count != 2 || !resp.countReported || resp.NextLink == "" {
+		t.Errorf("OData members not decoded: context %q, count %d (reported %t), nextLink %q", resp.Context, resp.Count, resp.countReported, resp.NextLink)
+	}
+	if len(resp.Value) != 2 || len(resp.Group) != 1 {
+		t.Errorf("decoded %d records and %d groups, want 2 and 1", len(resp.Value), len(resp.Group))
+	}
+	if resp.FromCache || resp.CacheAge != 0 || resp.Truncated || resp.RedactedFields != nil || resp.PagesFetched != 0 ||
+		resp.TotalCountExact || resp.Debug != nil || resp.RequestParams.Entity != "" {
+		t.Errorf("client fields were set from the response body: %+v", resp)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// bodyReader returns a reader that decodes a response body according to its
+// Content-Encoding, and a function that releases the decoders. Multiple encodings are
+// undone in reverse order of application; identity (or no header) reads the raw bytes.
+func bodyReader(resp *http.Response) (io.Reader, func(), error) {
+	var reader io.Reader = resp.Body
+	var closers []io.Closer
+	release := func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}
+
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoded, err := decodeReader(reader, strings.ToLower(strings.TrimSpace(encodings[i])))
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		if closer, ok := decoded.(io.Closer); ok && decoded != reader {
+			closers = append(closers, closer)
+		}
+		reader = decoded
+	}
+
+	return reader, release, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int
+}
+
+// Read reads from the wrapped reader, adding to the count
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+// decodeReader wraps reader with a decoder for a single content coding
+func decodeReader(reader io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return reader, nil
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gzipReader, nil
+	case "deflate":
+		return newDeflateReader(reader)
+	case "br":
+		return brotli.NewReader(reader), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+// newDeflateReader decodes "deflate" bodies, which servers send either zlib-wrapped
+// (per RFC 9110) or as a raw DEFLATE stream
+func newDeflateReader(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read deflate header: %w", err)
+	}
+
+	// A zlib stream starts with CMF/FLG bytes whose big-endian value is a multiple of 31
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zlibReader, err := zlib.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create deflate reader: %w", err)
+		}
+		return zlibReader, nil
+	}
+
+	return flate.NewReader(buffered), nil
+}
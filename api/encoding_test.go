@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestQueryDecodesContentEncoding(t *testing.T) {
+	const body = `{"value":[{"ListingKey":"K1","City":"Seattle"}]}`
+
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		writer := newWriter(&buf)
+		writer.Write([]byte(body))
+		writer.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		payload  []byte
+	}{
+		{"gzip", "gzip", compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{"deflate zlib-wrapped", "deflate", compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{"deflate raw", "deflate", compress(func(w io.Writer) io.WriteCloser {
+			writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return writer
+		})},
+		{"identity", "identity", []byte(body)},
+		{"no header", "", []byte(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept-Encoding"); got != "gzip, deflate, br" {
+					t.Errorf("Accept-Encoding = %q, want gzip, deflate, br", got)
+				}
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Write(tt.payload)
+			})
+
+			response, err := client.Query(QueryParams{Entity: "Property"})
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if len(response.Value) != 1 || response.Value[0]["City"] != "Seattle" {
+				t.Errorf("Value = %v, want the decoded record", response.Value)
+			}
+		})
+	}
+}
+
+func TestQueryRejectsUnknownContentEncoding(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "compress")
+		w.Write([]byte("not decodable"))
+	})
+
+	if _, err := client.Query(QueryParams{Entity: "Property"}); err == nil {
+		t.Fatal("Query succeeded, want an unsupported content encoding error")
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Property is a typed view of the common Property fields. Nullable numbers, booleans
+// and timestamps are pointers; dates stay strings in YYYY-MM-DD form.
+type Property struct {
+	ListingKey            string     `json:"ListingKey"`
+	ListingId             string     `json:"ListingId,omitempty"`
+	StandardStatus        string     `json:"StandardStatus,omitempty"`
+	MlsStatus             string     `json:"MlsStatus,omitempty"`
+	PropertyType          string     `json:"PropertyType,omitempty"`
+	PropertySubType       string     `json:"PropertySubType,omitempty"`
+	ListPrice             *float64   `json:"ListPrice,omitempty"`
+	OriginalListPrice     *float64   `json:"OriginalListPrice,omitempty"`
+	ClosePrice            *float64   `json:"ClosePrice,omitempty"`
+	CloseDate             string     `json:"CloseDate,omitempty"`
+	ListingContractDate   string     `json:"ListingContractDate,omitempty"`
+	BedroomsTotal         *int       `json:"BedroomsTotal,omitempty"`
+	BathroomsTotalInteger *int       `json:"BathroomsTotalInteger,omitempty"`
+	BathroomsFull         *int       `json:"BathroomsFull,omitempty"`
+	BathroomsHalf         *int       `json:"BathroomsHalf,omitempty"`
+	LivingArea            *float64   `json:"LivingArea,omitempty"`
+	LotSizeAcres          *float64   `json:"LotSizeAcres,omitempty"`
+	LotSizeSquareFeet     *float64   `json:"LotSizeSquareFeet,omitempty"`
+	YearBuilt             *int       `json:"YearBuilt,omitempty"`
+	Stories               *int       `json:"Stories,omitempty"`
+	GarageSpaces          *float64   `json:"GarageSpaces,omitempty"`
+	PoolPrivateYN         *bool      `json:"PoolPrivateYN,omitempty"`
+	StreetNumber          string     `json:"StreetNumber,omitempty"`
+	StreetName            string     `json:"StreetName,omitempty"`
+	UnparsedAddress       string     `json:"UnparsedAddress,omitempty"`
+	City                  string     `json:"City,omitempty"`
+	StateOrProvince       string     `json:"StateOrProvince,omitempty"`
+	PostalCode            string     `json:"PostalCode,omitempty"`
+	CountyOrParish        string     `json:"CountyOrParish,omitempty"`
+	Latitude              *float64   `json:"Latitude,omitempty"`
+	Longitude             *float64   `json:"Longitude,omitempty"`
+	DaysOnMarket          *int       `json:"DaysOnMarket,omitempty"`
+	PublicRemarks         string     `json:"PublicRemarks,omitempty"`
+	Appliances            []string   `json:"Appliances,omitempty"`
+	PhotosCount           *int       `json:"PhotosCount,omitempty"`
+	ListAgentKey          string     `json:"ListAgentKey,omitempty"`
+	ListAgentMlsId        string     `json:"ListAgentMlsId,omitempty"`
+	ListAgentFullName     string     `json:"ListAgentFullName,omitempty"`
+	ListOfficeKey         string     `json:"ListOfficeKey,omitempty"`
+	ListOfficeMlsId       string     `json:"ListOfficeMlsId,omitempty"`
+	ListOfficeName        string     `json:"ListOfficeName,omitempty"`
+	OnMarketTimestamp     *time.Time `json:"OnMarketTimestamp,omitempty"`
+	ModificationTimestamp *time.Time `json:"ModificationTimestamp,omitempty"`
+	Media                 []Media    `json:"Media,omitempty"`
+}
+
+// Member is a typed view of the common Member fields
+type Member struct {
+	MemberKey             string     `json:"MemberKey"`
+	MemberMlsId           string     `json:"MemberMlsId,omitempty"`
+	MemberFirstName       string     `json:"MemberFirstName,omitempty"`
+	MemberLastName        string     `json:"MemberLastName,omitempty"`
+	MemberFullName        string     `json:"MemberFullName,omitempty"`
+	MemberEmail           string     `json:"MemberEmail,omitempty"`
+	MemberDirectPhone     string     `json:"MemberDirectPhone,omitempty"`
+	MemberMobilePhone     string     `json:"MemberMobilePhone,omitempty"`
+	MemberStatus          string     `json:"MemberStatus,omitempty"`
+	MemberType            string     `json:"MemberType,omitempty"`
+	MemberDesignation     []string   `json:"MemberDesignation,omitempty"`
+	OfficeKey             string     `json:"OfficeKey,omitempty"`
+	ModificationTimestamp *time.Time `json:"ModificationTimestamp,omitempty"`
+}
+
+// Office is a typed view of the common Office fields
+type Office struct {
+	OfficeKey             string     `json:"OfficeKey"`
+	OfficeMlsId           string     `json:"OfficeMlsId,omitempty"`
+	OfficeName            string     `json:"OfficeName,omitempty"`
+	OfficePhone           string     `json:"OfficePhone,omitempty"`
+	OfficeEmail           string     `json:"OfficeEmail,omitempty"`
+	OfficeAddress1        string     `json:"OfficeAddress1,omitempty"`
+	OfficeCity            string     `json:"OfficeCity,omitempty"`
+	OfficeStateOrProvince string     `json:"OfficeStateOrProvince,omitempty"`
+	OfficePostalCode      string     `json:"OfficePostalCode,omitempty"`
+	OfficeStatus          string     `json:"OfficeStatus,omitempty"`
+	ModificationTimestamp *time.Time `json:"ModificationTimestamp,omitempty"`
+}
+
+// Media is a typed view of the common Media fields
+type Media struct {
+	MediaKey                   string     `json:"MediaKey"`
+	ResourceRecordKey          string     `json:"ResourceRecordKey,omitempty"`
+	MediaType                  string     `json:"MediaType,omitempty"`
+	MediaCategory              string     `json:"MediaCategory,omitempty"`
+	MediaURL                   string     `json:"MediaURL,omitempty"`
+	Order                      *int       `json:"Order,omitempty"`
+	Permission                 string     `json:"Permission,omitempty"`
+	ShortDescription           string     `json:"ShortDescription,omitempty"`
+	LongDescription            string     `json:"LongDescription,omitempty"`
+	MediaModificationTimestamp *time.Time `json:"MediaModificationTimestamp,omitempty"`
+	ModificationTimestamp      *time.Time `json:"ModificationTimestamp,omitempty"`
+}
+
+// DecodeProperty converts a Property record into a Property struct. Fields the
+// struct does not cover are ignored.
+func DecodeProperty(record map[string]interface{}) (Property, error) {
+	var property Property
+	err := decodeRecord("Property", record, &property)
+	return property, err
+}
+
+// DecodeMember converts a Member record into a Member struct
+func DecodeMember(record map[string]interface{}) (Member, error) {
+	var member Member
+	err := decodeRecord("Member", record, &member)
+	return member, err
+}
+
+// DecodeOffice converts an Office record into an Office struct
+func DecodeOffice(record map[string]interface{}) (Office, error) {
+	var office Office
+	err := decodeRecord("Office", record, &office)
+	return office, err
+}
+
+// DecodeMedia converts a Media record into a Media struct
+func DecodeMedia(record map[string]interface{}) (Media, error) {
+	var media Media
+	err := decodeRecord("Media", record, &media)
+	return media, err
+}
+
+// HasTypedEntity reports whether entity has a typed struct that CheckRecordTypes uses
+func HasTypedEntity(entity string) bool {
+	switch entity {
+	case "Property", "Member", "Office", "Media":
+		return true
+	}
+	return false
+}
+
+// CheckRecordTypes decodes a record into the typed struct for entity and returns the
+// first type mismatch, or nil when the record decodes or entity has no struct
+func CheckRecordTypes(entity string, record map[string]interface{}) error {
+	var err error
+	switch entity {
+	case "Property":
+		_, err = DecodeProperty(record)
+	case "Member":
+		_, err = DecodeMember(record)
+	case "Office":
+		_, err = DecodeOffice(record)
+	case "Media":
+		_, err = DecodeMedia(record)
+	}
+	return err
+}
+
+// decodeRecord round-trips record through JSON into target, naming the field and the
+// types involved when a value does not fit
+func decodeRecord(entity string, record map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", entity, err)
+	}
+
+	err = json.Unmarshal(data, target)
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%s field %s: expected %s, got JSON %s", entity, typeErr.Field, typeErr.Type, typeErr.Value)
+	case errors.As(err, &timeErr):
+		return fmt.Errorf("%s timestamp %q is not RFC 3339", entity, timeErr.Value)
+	default:
+		return fmt.Errorf("failed to decode %s record: %w", entity, err)
+	}
+}
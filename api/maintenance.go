@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/logging"
+)
+
+// DefaultMaintenanceCooldown is how long queries fail fast once the API has answered
+// MaintenanceThreshold 503s in a row
+const DefaultMaintenanceCooldown = 60 * time.Second
+
+// MaintenanceThreshold is the number of consecutive 503 responses that opens the
+// maintenance breaker
+const MaintenanceThreshold = 3
+
+// MaintenanceError is returned by queries while the API is treated as being in a
+// maintenance window
+type MaintenanceError struct {
+	RetryAfter time.Duration
+	Probing    bool
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Probing {
+		return "service in maintenance: a probe request is checking whether the API is back; retry in a few seconds"
+	}
+	return fmt.Sprintf("service in maintenance: the API answered %d consecutive 503s, so queries are paused; retry after %s",
+		MaintenanceThreshold, (e.RetryAfter + time.Second - 1).Truncate(time.Second))
+}
+
+// maintenanceBreaker stops queries from piling onto an API in a maintenance window.
+// After MaintenanceThreshold consecutive 503s it opens for the cooldown, and queries
+// fail fast with a MaintenanceError. Once the cooldown ends one query goes through as
+// a probe: any other response closes the breaker, and another 503 reopens it.
+type maintenanceBreaker struct {
+	cooldown  time.Duration
+	logger    *logging.Logger
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// newMaintenanceBreaker creates a breaker, or returns nil when cooldown disables it
+func newMaintenanceBreaker(cooldown time.Duration, logger *logging.Logger) *maintenanceBreaker {
+	if cooldown <= 0 {
+		return nil
+	}
+	return &maintenanceBreaker{cooldown: cooldown, logger: logger}
+}
+
+// allow returns a MaintenanceError while the breaker is open or a probe is in flight,
+// and otherwise lets the request through. Once the cooldown has ended the request is
+// the probe, and probe is true; the caller must then call endProbe when it is done.
+func (b *maintenanceBreaker) allow() (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.openUntil.IsZero() {
+		return false, nil
+	}
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return false, &MaintenanceError{RetryAfter: remaining}
+	}
+	if b.probing {
+		return false, &MaintenanceError{Probing: true}
+	}
+	b.probing = true
+	return true, nil
+}
+
+// endProbe releases a probe that ended without a response being recorded, such as a
+// query rejected before it was sent, so the next query can probe instead
+func (b *maintenanceBreaker) endProbe() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.probing = false
+}
+
+// record updates the breaker with the outcome of a request. Requests that got no
+// response leave the count alone but end a probe, so the next query probes again.
+func (b *maintenanceBreaker) record(status int, err error) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch {
+	case err != nil:
+		b.probing = false
+	case status == http.StatusServiceUnavailable:
+		b.failures++
+		if b.probing || b.failures >= MaintenanceThreshold {
+			b.openUntil = time.Now().Add(b.cooldown)
+			b.probing = false
+			b.logger.Warningf("api", "API answered %d consecutive 503s; pausing queries for %s", b.failures, b.cooldown)
+		}
+	default:
+		if !b.openUntil.IsZero() {
+			b.logger.Infof("api", "API answered %d after the maintenance cooldown; resuming queries", status)
+		}
+		b.failures = 0
+		b.openUntil = time.Time{}
+		b.probing = false
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceBreakerPausesQueries(t *testing.T) {
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	client, _ := newTestClientWithOptions(t, ClientOptions{MaintenanceCooldown: 50 * time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"code":"Maintenance","message":"down for maintenance"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value":[{"ListingKey":"K1"}]}`)
+	})
+	params := QueryParams{Entity: "Property", NoCache: true}
+
+	for i := 0; i < MaintenanceThreshold; i++ {
+		_, err := client.Query(params)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+			t.Fatalf("query %d: err = %v, want a 503 APIError", i+1, err)
+		}
+	}
+
+	// The breaker is open: queries fail fast without reaching the API
+	_, err := client.Query(params)
+	var maintenanceErr *MaintenanceError
+	if !errors.As(err, &maintenanceErr) || maintenanceErr.Probing {
+		t.Fatalf("err = %v, want a MaintenanceError during the cooldown", err)
+	}
+	if got := requests.Load(); got != MaintenanceThreshold {
+		t.Errorf("stub saw %d requests, want %d", got, MaintenanceThreshold)
+	}
+
+	// Once the cooldown ends a query probes the API, and success closes the breaker
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Query(params); err != nil {
+			t.Fatalf("query %d after the cooldown: %v", i+1, err)
+		}
+	}
+	if got := requests.Load(); got != MaintenanceThreshold+2 {
+		t.Errorf("stub saw %d requests, want %d", got, MaintenanceThreshold+2)
+	}
+}
+
+func TestMaintenanceBreakerReopensOnFailedProbe(t *testing.T) {
+	breaker := newMaintenanceBreaker(30*time.Millisecond, nil)
+	for i := 0; i < MaintenanceThreshold; i++ {
+		breaker.record(http.StatusServiceUnavailable, nil)
+	}
+	if _, err := breaker.allow(); err == nil {
+		t.Fatal("breaker allowed a query during the cooldown")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	probe, err := breaker.allow()
+	if !probe || err != nil {
+		t.Fatalf("allow = %t, %v, want the first query after the cooldown to probe", probe, err)
+	}
+	var maintenanceErr *MaintenanceError
+	if _, err := breaker.allow(); !errors.As(err, &maintenanceErr) || !maintenanceErr.Probing {
+		t.Errorf("allow during the probe = %v, want a probing MaintenanceError", err)
+	}
+
+	// A single 503 from the probe reopens the breaker for another cooldown
+	breaker.record(http.StatusServiceUnavailable, nil)
+	breaker.endProbe()
+	if _, err := breaker.allow(); !errors.As(err, &maintenanceErr) || maintenanceErr.Probing {
+		t.Errorf("allow after a failed probe = %v, want the breaker open again", err)
+	}
+}
+
+func TestMaintenanceBreakerConcurrentProbe(t *testing.T) {
+	breaker := newMaintenanceBreaker(10*time.Millisecond, nil)
+	for i := 0; i < MaintenanceThreshold; i++ {
+		breaker.record(http.StatusServiceUnavailable, nil)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	var probes atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if probe, err := breaker.allow(); probe && err == nil {
+				probes.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := probes.Load(); got != 1 {
+		t.Errorf("%d queries probed the API, want exactly 1", got)
+	}
+}
+
+func TestMaintenanceBreakerDisabled(t *testing.T) {
+	breaker := newMaintenanceBreaker(0, nil)
+	for i := 0; i < MaintenanceThreshold*2; i++ {
+		breaker.record(http.StatusServiceUnavailable, nil)
+	}
+	if probe, err := breaker.allow(); probe || err != nil {
+		t.Errorf("allow = %t, %v, want a disabled breaker to let queries through", probe, err)
+	}
+}
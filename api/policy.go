@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// EntityNotAllowedError is returned when a query targets a supported entity that
+// the server's allowed_entities policy excludes
+type EntityNotAllowedError struct {
+	Entity  string
+	Allowed []string
+}
+
+// Error explains the policy and lists the entities that may be queried
+func (e *EntityNotAllowedError) Error() string {
+	return fmt.Sprintf("entity %s is not allowed by this server's allowed_entities policy (allowed: %s)",
+		e.Entity, strings.Join(e.Allowed, ", "))
+}
+
+// newEntityPolicy maps configured entity names onto the supported entity names,
+// ignoring case. It returns nil, allowing every entity, when none are configured.
+func newEntityPolicy(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+
+	policy := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		matched := false
+		for _, entity := range GetSupportedEntities() {
+			if strings.EqualFold(entity.Name, name) {
+				policy[entity.Name] = true
+				matched = true
+			}
+		}
+		if !matched {
+			log.Printf("Warning: ignoring unknown entity %q in allowed_entities", name)
+		}
+	}
+	return policy
+}
+
+// AllowedEntities returns the supported entities this client may query, in the
+// order of GetSupportedEntities
+func (c *Client) AllowedEntities() []string {
+	var names []string
+	for _, entity := range GetSupportedEntities() {
+		if c.allowedEntities == nil || c.allowedEntities[entity.Name] {
+			names = append(names, entity.Name)
+		}
+	}
+	return names
+}
+
+// CheckEntityAllowed returns an EntityNotAllowedError when the allowed_entities
+// policy excludes the entity. Unsupported entities are left to IsValidEntity.
+func (c *Client) CheckEntityAllowed(entity string) error {
+	if c.allowedEntities == nil || c.allowedEntities[entity] || !IsValidEntity(entity) {
+		return nil
+	}
+	return &EntityNotAllowedError{Entity: entity, Allowed: c.AllowedEntities()}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultRedactFields returns the contact fields masked when PII redaction is on:
+// Member and Office contact details, the agent and office contact fields embedded
+// in Property, and owner, occupant and showing contact phones
+func DefaultRedactFields() []string {
+	fields := []string{
+		"MemberEmail", "MemberDirectPhone", "MemberMobilePhone", "MemberHomePhone",
+		"MemberOfficePhone", "MemberPreferredPhone", "MemberTollFreePhone", "MemberFax",
+		"MemberPager", "MemberPhoneTTYTDD",
+		"OfficeEmail", "OfficePhone", "OfficeFax", "OfficeLeadEmail", "OfficeLeadPhone",
+		"OwnerPhone", "OccupantPhone", "ShowingContactPhone",
+	}
+	for _, agent := range []string{"ListAgent", "CoListAgent", "BuyerAgent", "CoBuyerAgent"} {
+		for _, suffix := range []string{"Email", "DirectPhone", "MobilePhone", "HomePhone", "OfficePhone", "PreferredPhone", "TollFreePhone", "Fax", "Pager"} {
+			fields = append(fields, agent+suffix)
+		}
+	}
+	for _, office := range []string{"ListOffice", "CoListOffice", "BuyerOffice", "CoBuyerOffice"} {
+		for _, suffix := range []string{"Email", "Phone", "Fax"} {
+			fields = append(fields, office+suffix)
+		}
+	}
+	return fields
+}
+
+// redactor masks configured fields in records, including expanded records
+type redactor struct {
+	fields map[string]bool
+	masked map[string]bool
+}
+
+// newRedactFields builds the set of fields to mask, or nil when redaction is off
+func newRedactFields(fields []string) map[string]bool {
+	if len(fields) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			set[field] = true
+		}
+	}
+	return set
+}
+
+// redact returns the response with redacted copies of its records, leaving the
+// records shared with the query cache untouched
+func (c *Client) redact(response *APIResponse) *APIResponse {
+	if c.redactFields == nil {
+		return response
+	}
+
+	r := &redactor{fields: c.redactFields, masked: make(map[string]bool)}
+	response.Value = r.records(response.Value)
+	response.Group = r.records(response.Group)
+
+	response.RedactedFields = nil
+	for field := range r.masked {
+		response.RedactedFields = append(response.RedactedFields, field)
+	}
+	sort.Strings(response.RedactedFields)
+	return response
+}
+
+// records redacts a list of records
+func (r *redactor) records(records []map[string]interface{}) []map[string]interface{} {
+	if records == nil {
+		return nil
+	}
+	redacted := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		redacted[i] = r.record(record)
+	}
+	return redacted
+}
+
+// record copies a record, masking redacted fields and descending into expansions
+func (r *redactor) record(record map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(record))
+	for field, value := range record {
+		if r.fields[field] && value != nil {
+			redacted[field] = maskValue(value)
+			r.masked[field] = true
+			continue
+		}
+		redacted[field] = r.value(value)
+	}
+	return redacted
+}
+
+// value redacts nested records inside an expanded navigation property
+func (r *redactor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return r.record(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = r.value(item)
+		}
+		return items
+	}
+	return value
+}
+
+// maskValue hides a contact value while keeping enough shape to recognize it:
+// emails keep the first letter and domain (j***@example.com), phone numbers keep
+// their last four digits, and anything else keeps only its first character
+func maskValue(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return "***"
+	}
+	if s == "" {
+		return s
+	}
+
+	if at := strings.LastIndex(s, "@"); at > 0 {
+		return s[:1] + "***" + s[at:]
+	}
+
+	digits := 0
+	for _, ch := range s {
+		if unicode.IsDigit(ch) {
+			digits++
+		}
+	}
+	if digits >= 7 {
+		var masked strings.Builder
+		seen := 0
+		for _, ch := range s {
+			if unicode.IsDigit(ch) {
+				seen++
+				if seen <= digits-4 {
+					ch = '*'
+				}
+			}
+			masked.WriteRune(ch)
+		}
+		return masked.String()
+	}
+
+	return string([]rune(s)[:1]) + "***"
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// DefaultRequestIDHeader is the header used when ClientOptions.RequestIDHeader is unset
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// headerNamePattern matches the characters HTTP allows in a header name
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// requestIDKey marks a context carrying a correlation ID
+type requestIDKey struct{}
+
+// correlation holds the caller-supplied ID used in place of generated ones
+type correlation struct {
+	mutex sync.Mutex
+	id    string
+}
+
+// WithRequestID returns a context whose Query and GetMetadata calls send id as their
+// correlation ID instead of generating a new one
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the correlation ID carried by ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random version 4 UUID
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// SetCorrelationID makes later calls without a context ID send id, so a correlation ID
+// received with a tool call reaches every request the call makes. An empty id goes
+// back to generating one per call.
+func (c *Client) SetCorrelationID(id string) {
+	c.correlation.mutex.Lock()
+	defer c.correlation.mutex.Unlock()
+	c.correlation.id = id
+}
+
+// withRequestID makes sure ctx carries a correlation ID: the one already there, the
+// one set with SetCorrelationID, or a new one. Nested calls such as the skip-limit
+// cursor lookup therefore share the ID of the call that made them.
+func (c *Client) withRequestID(ctx context.Context) (context.Context, string) {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return ctx, id
+	}
+	c.correlation.mutex.Lock()
+	id := c.correlation.id
+	c.correlation.mutex.Unlock()
+	if id == "" {
+		id = NewRequestID()
+	}
+	return WithRequestID(ctx, id), id
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SearchNotSupportedError is returned when the server rejects $search, which not
+// every RESO provider implements
+type SearchNotSupportedError struct {
+	Cause error
+}
+
+// Error explains that free-text search is unavailable and how to filter instead
+func (e *SearchNotSupportedError) Error() string {
+	return fmt.Sprintf("this MLS doesn't support free-text search ($search); use a filter such as contains(PublicRemarks,'pool') instead (server said: %v)", e.Cause)
+}
+
+// Unwrap returns the server error
+func (e *SearchNotSupportedError) Unwrap() error {
+	return e.Cause
+}
+
+// searchUnsupportedHints are phrases servers use when rejecting an unknown query option
+var searchUnsupportedHints = []string{
+	"support", "implement", "unknown", "unrecognized", "not allowed", "invalid query option",
+}
+
+// searchNotSupported reports whether err is a server rejection of $search itself, as
+// opposed to an error in the rest of the query
+func searchNotSupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Status == http.StatusNotImplemented {
+		return true
+	}
+	if apiErr.Status != http.StatusBadRequest {
+		return false
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	if !strings.Contains(message, "search") {
+		return false
+	}
+	for _, hint := range searchUnsupportedHints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSearch turns free text into an OData $search expression. Plain words and the
+// AND, OR and NOT operators pass through; "quoted phrases" and words containing
+// search syntax characters are sent as phrases with quotes and backslashes escaped.
+func formatSearch(text string) string {
+	var terms []string
+	for _, token := range splitSearchTerms(text) {
+		switch {
+		case token.phrase:
+			terms = append(terms, quoteSearchPhrase(token.text))
+		case token.text == "AND" || token.text == "OR" || token.text == "NOT":
+			terms = append(terms, token.text)
+		case strings.ContainsAny(token.text, `()"\`):
+			terms = append(terms, quoteSearchPhrase(token.text))
+		default:
+			terms = append(terms, token.text)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// searchTerm is one word or quoted phrase of free-text search input
+type searchTerm struct {
+	text   string
+	phrase bool
+}
+
+// splitSearchTerms splits on whitespace, keeping "double-quoted phrases" together;
+// an unterminated quote runs to the end of the input
+func splitSearchTerms(text string) []searchTerm {
+	var terms []searchTerm
+	var current strings.Builder
+	inPhrase := false
+
+	flush := func(phrase bool) {
+		if current.Len() > 0 {
+			terms = append(terms, searchTerm{text: current.String(), phrase: phrase})
+			current.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			flush(inPhrase)
+			inPhrase = !inPhrase
+		case !inPhrase && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(inPhrase)
+
+	return terms
+}
+
+// quoteSearchPhrase wraps a phrase in double quotes, escaping quotes and backslashes
+func quoteSearchPhrase(phrase string) string {
+	escaped := strings.ReplaceAll(phrase, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SkipLimitError reports a query that would page past an entity's $skip limit,
+// with a filter cursor suggestion for reaching the same records
+type SkipLimitError struct {
+	Entity string
+	Skip   int
+	Top    int
+	Limit  int
+	// CursorField and CursorOrder come from the first orderby key, when there is one
+	CursorField string
+	CursorOrder string
+	// CursorFilter and NextSkip are set when the cursor value could be looked up
+	CursorFilter string
+	NextSkip     int
+}
+
+// Error explains the limit and how to page deeper with a filter cursor
+func (e *SkipLimitError) Error() string {
+	var msg strings.Builder
+
+	if e.Skip > e.Limit {
+		msg.WriteString(fmt.Sprintf("skip value %d exceeds the %s skip limit of %d", e.Skip, e.Entity, e.Limit))
+	} else {
+		msg.WriteString(fmt.Sprintf("skip %d + top %d crosses the %s skip limit of %d", e.Skip, e.Top, e.Entity, e.Limit))
+	}
+	msg.WriteString(". OData $skip cannot page past this limit, so page deeper with a filter cursor instead")
+
+	switch {
+	case e.CursorFilter != "":
+		msg.WriteString(fmt.Sprintf(": keep orderby '%s %s', add \"%s\" to the filter and use skip=%d", e.CursorField, e.CursorOrder, e.CursorFilter, e.NextSkip))
+		msg.WriteString(fmt.Sprintf(", then repeat with the last %s of each page", e.CursorField))
+		if e.CursorField != "ListingKey" && e.CursorField != "ModificationTimestamp" {
+			msg.WriteString(". Records tied on the cursor value may be skipped; order by a unique field such as ModificationTimestamp to avoid this")
+		}
+	case e.CursorField != "":
+		msg.WriteString(fmt.Sprintf(": keep orderby '%s %s' and add a filter such as \"%s %s <last value seen>\", resetting skip to 0", e.CursorField, e.CursorOrder, e.CursorField, cursorOperator(e.CursorOrder)))
+	default:
+		msg.WriteString(": order by ModificationTimestamp asc and filter \"ModificationTimestamp gt <last value seen>\", resetting skip to 0 for each page (or use fetch_all)")
+	}
+
+	return msg.String()
+}
+
+// checkSkipLimit returns a SkipLimitError when params page past the entity's skip
+// limit, looking up the cursor value at the last reachable position when possible
+func (c *Client) checkSkipLimit(ctx context.Context, params QueryParams) error {
+	limit := GetEntitySkipLimit(params.Entity)
+	if params.Skip <= limit && (params.Top <= 0 || params.FetchAll || params.Skip+params.Top <= limit) {
+		return nil
+	}
+
+	skipErr := &SkipLimitError{
+		Entity: params.Entity,
+		Skip:   params.Skip,
+		Top:    params.Top,
+		Limit:  limit,
+	}
+
+	// The first orderby key is used as the cursor
+	keys := strings.Split(params.OrderBy, ",")
+	parts := strings.Fields(keys[0])
+	if len(parts) == 0 {
+		return skipErr
+	}
+	skipErr.CursorField = parts[0]
+	skipErr.CursorOrder = "asc"
+	if len(parts) > 1 && strings.EqualFold(parts[1], "desc") {
+		skipErr.CursorOrder = "desc"
+	}
+
+	// Fetch the cursor value of the last record reachable with $skip
+	position := min(params.Skip, limit) - 1
+	if position < 0 {
+		return skipErr
+	}
+	lookup := params
+	lookup.Select = skipErr.CursorField
+	lookup.Skip = position
+	lookup.Top = 1
+	lookup.Expand = ""
+	lookup.FetchAll = false
+	response, err := c.QueryContext(ctx, lookup)
+	if err != nil || len(response.Value) == 0 {
+		return skipErr
+	}
+	value, ok := formatCursorValue(response.Value[0][skipErr.CursorField])
+	if !ok {
+		return skipErr
+	}
+
+	skipErr.CursorFilter = fmt.Sprintf("%s %s %s", skipErr.CursorField, cursorOperator(skipErr.CursorOrder), value)
+	skipErr.NextSkip = params.Skip - (position + 1)
+	return skipErr
+}
+
+// cursorOperator returns the comparison that continues past a cursor in the given order
+func cursorOperator(order string) string {
+	if order == "desc" {
+		return "lt"
+	}
+	return "gt"
+}
+
+// formatCursorValue renders a record value as an OData filter literal
+func formatCursorValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return v, true
+		}
+		if _, err := time.Parse("2006-01-02", v); err == nil {
+			return v, true
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'", true
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// Connection pool defaults used when TransportOptions fields are unset
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// TransportOptions tunes the connection pool of a shared HTTP transport
+type TransportOptions struct {
+	// MaxIdleConns caps idle keep-alive connections across all hosts; zero uses
+	// DefaultMaxIdleConns
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle keep-alive connections to one host; zero uses
+	// DefaultMaxIdleConnsPerHost
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes keep-alive connections idle this long; zero uses
+	// DefaultIdleConnTimeout
+	IdleConnTimeout time.Duration
+}
+
+// NewTransport returns an HTTP transport with a keep-alive pool sized for concurrent
+// batch and fetch_all requests. net/http keeps only two idle connections per host by
+// default, so bursts of parallel queries would otherwise open new connections. Share
+// one transport between the OAuth and API clients.
+func NewTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	transport.MaxIdleConns = opts.MaxIdleConns
+	if transport.MaxIdleConns <= 0 {
+		transport.MaxIdleConns = DefaultMaxIdleConns
+	}
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	if transport.MaxIdleConnsPerHost <= 0 {
+		transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	if transport.IdleConnTimeout <= 0 {
+		transport.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	return transport
+}
@@ -14,22 +14,48 @@ type QueryParams struct {
 	Skip        int    `json:"skip,omitempty"`
 	OrderBy     string `json:"orderby,omitempty"`
 	Expand      string `json:"expand,omitempty"`
+	Apply       string `json:"apply,omitempty"`
 	IgnoreNulls bool   `json:"ignorenulls,omitempty"`
 	IgnoreCase  bool   `json:"ignorecase,omitempty"`
+	Search      string `json:"search,omitempty"`
+	FetchAll    bool   `json:"fetch_all,omitempty"`
+	MaxRecords  int    `json:"max_records,omitempty"`
+	DeltaLink   string `json:"delta_link,omitempty"`
+	NoCache     bool   `json:"-"`
 }
 
+// DefaultMaxRecords caps the records collected when FetchAll follows @odata.nextLink
+const DefaultMaxRecords = 5000
+
 // APIResponse represents the standard RESO API response structure
 type APIResponse struct {
-	Context       string                   `json:"@odata.context"`
-	Count         int                      `json:"@odata.count"`
-	TotalCount    int                      `json:"@odata.totalCount"`
-	Value         []map[string]interface{} `json:"value"`
-	Group         []map[string]interface{} `json:"group,omitempty"`
-	NextLink      string                   `json:"@odata.nextLink,omitempty"`
-	Debug         map[string]interface{}   `json:"debug,omitempty"`
-	RequestTime   time.Time                `json:"request_time"`
-	ResponseTime  time.Duration            `json:"response_time"`
-	RequestParams QueryParams              `json:"request_params"`
+	Context         string                   `json:"@odata.context"`
+	Count           int                      `json:"@odata.count"`
+	TotalCount      int                      `json:"@odata.totalCount"`
+	TotalCountExact bool                     `json:"total_count_exact"`
+	Value           []map[string]interface{} `json:"value"`
+	Group           []map[string]interface{} `json:"group,omitempty"`
+	NextLink        string                   `json:"@odata.nextLink,omitempty"`
+	DeltaLink       string                   `json:"@odata.deltaLink,omitempty"`
+	PagesFetched    int                      `json:"pages_fetched,omitempty"`
+	Truncated       bool                     `json:"truncated,omitempty"`
+	FromCache       bool                     `json:"from_cache,omitempty"`
+	CacheAge        time.Duration            `json:"cache_age,omitempty"`
+	Debug           map[string]interface{}   `json:"debug,omitempty"`
+	RedactedFields  []string                 `json:"redacted_fields,omitempty"`
+	RequestTime     time.Time                `json:"request_time"`
+	ResponseTime    time.Duration            `json:"response_time"`
+	RequestParams   QueryParams              `json:"request_params"`
+
+	// Whether the server's body carried each count, set by fetchPage
+	countReported      bool
+	totalCountReported bool
+}
+
+// MoreAvailable reports whether records past this response match the query, either
+// behind NextLink or dropped from the last page fetched by FetchAll
+func (r *APIResponse) MoreAvailable() bool {
+	return r.NextLink != "" || r.Truncated
 }
 
 // ErrorResponse represents an API error response
@@ -103,6 +129,15 @@ func GetSupportedEntities() []SupportedEntity {
 	}
 }
 
+// GetSupportedEntityNames returns the names of the supported RESO entities
+func GetSupportedEntityNames() []string {
+	var names []string
+	for _, entity := range GetSupportedEntities() {
+		names = append(names, entity.Name)
+	}
+	return names
+}
+
 // IsValidEntity checks if the given entity name is supported
 func IsValidEntity(entity string) bool {
 	entities := GetSupportedEntities()
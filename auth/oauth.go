@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -10,53 +11,138 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/logging"
+	"github.com/rennietech/constellation1-mcp-server/metrics"
 )
 
 // TokenResponse represents the OAuth2 token response
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
+// Supported OAuth2 grant types
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+)
+
 // OAuthClient handles OAuth2 authentication for RESO API
 type OAuthClient struct {
-	clientID     string
-	clientSecret string
-	authURL      string
-	token        *TokenResponse
-	tokenExpiry  time.Time
-	mutex        sync.RWMutex
-	httpClient   *http.Client
+	clientID      string
+	clientSecret  string
+	authURL       string
+	scope         string
+	audience      string
+	grantType     string
+	username      string
+	password      string
+	refreshValue  string
+	token         *TokenResponse
+	tokenExpiry   time.Time
+	tokenIssued   time.Time
+	refreshBuffer time.Duration
+	refreshing    bool
+	logger        *logging.Logger
+	mutex         sync.RWMutex
+	httpClient    *http.Client
 }
 
+// OAuthOptions holds optional token request parameters
+type OAuthOptions struct {
+	// Scope is sent as the scope form field when set
+	Scope string
+	// Audience is sent as the audience form field when set
+	Audience string
+	// GrantType is GrantClientCredentials (the default), GrantPassword or
+	// GrantRefreshToken
+	GrantType string
+	// Username and Password are sent with the password grant
+	Username string
+	Password string
+	// RefreshToken is the initial token for the refresh_token grant. A newer refresh
+	// token returned by the server replaces it.
+	RefreshToken string
+	// RefreshBuffer starts a background refresh when the token is this close to
+	// expiry; zero uses DefaultRefreshBuffer
+	RefreshBuffer time.Duration
+	// Logger receives token refresh events; nil logs to stderr only
+	Logger *logging.Logger
+	// Transport carries token requests, so the API client's connection pool can be
+	// shared; nil uses http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// DefaultRefreshBuffer is how close to expiry a token is proactively refreshed
+const DefaultRefreshBuffer = 2 * time.Minute
+
 // NewOAuthClient creates a new OAuth client
 func NewOAuthClient(clientID, clientSecret, authURL string) *OAuthClient {
+	return NewOAuthClientWithOptions(clientID, clientSecret, authURL, OAuthOptions{})
+}
+
+// NewOAuthClientWithOptions creates a new OAuth client with optional token request parameters
+func NewOAuthClientWithOptions(clientID, clientSecret, authURL string, opts OAuthOptions) *OAuthClient {
+	refreshBuffer := opts.RefreshBuffer
+	if refreshBuffer <= 0 {
+		refreshBuffer = DefaultRefreshBuffer
+	}
+
+	grantType := opts.GrantType
+	if grantType == "" {
+		grantType = GrantClientCredentials
+	}
+
 	return &OAuthClient{
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		authURL:      authURL,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		authURL:       authURL,
+		scope:         opts.Scope,
+		audience:      opts.Audience,
+		grantType:     grantType,
+		username:      opts.Username,
+		password:      opts.Password,
+		refreshValue:  opts.RefreshToken,
+		refreshBuffer: refreshBuffer,
+		logger:        opts.Logger,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: opts.Transport,
 		},
 	}
 }
 
 // GetToken returns a valid access token, refreshing if necessary
 func (c *OAuthClient) GetToken() (string, error) {
+	return c.GetTokenContext(context.Background())
+}
+
+// GetTokenContext returns a valid access token, refreshing within ctx if necessary.
+// When the token is within the refresh buffer of expiry, a background refresh is
+// started and the current token is still returned.
+func (c *OAuthClient) GetTokenContext(ctx context.Context) (string, error) {
 	c.mutex.RLock()
 	if c.token != nil && time.Now().Before(c.tokenExpiry) {
 		token := c.token.AccessToken
+		// Tokens that live shorter than the buffer are left to the blocking refresh
+		nearExpiry := time.Until(c.tokenExpiry) < c.refreshBuffer && time.Since(c.tokenIssued) > c.refreshBuffer
 		c.mutex.RUnlock()
+		if nearExpiry {
+			c.refreshInBackground()
+		}
 		return token, nil
 	}
 	c.mutex.RUnlock()
 
-	return c.refreshToken()
+	return c.refreshToken(ctx)
 }
 
 // refreshToken obtains a new access token
-func (c *OAuthClient) refreshToken() (string, error) {
+func (c *OAuthClient) refreshToken(ctx context.Context) (string, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -65,55 +151,140 @@ func (c *OAuthClient) refreshToken() (string, error) {
 		return c.token.AccessToken, nil
 	}
 
-	// Encode credentials in Base64
-	credentials := base64.StdEncoding.EncodeToString([]byte(c.clientID + ":" + c.clientSecret))
+	tokenResp, err := c.requestToken(ctx, c.tokenForm())
+	if err != nil {
+		c.logger.Errorf("auth", "token request failed: %v", err)
+		return "", err
+	}
+	c.storeToken(tokenResp)
+	c.logger.Debugf("auth", "obtained access token valid for %ds", tokenResp.ExpiresIn)
+
+	return tokenResp.AccessToken, nil
+}
+
+// refreshInBackground starts a single asynchronous refresh; callers keep using the
+// current token until it completes. The HTTP request runs without holding the
+// mutex so concurrent GetToken calls are not blocked.
+func (c *OAuthClient) refreshInBackground() {
+	c.mutex.Lock()
+	if c.refreshing {
+		c.mutex.Unlock()
+		return
+	}
+	c.refreshing = true
+	form := c.tokenForm()
+	c.mutex.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.httpClient.Timeout)
+		defer cancel()
+
+		metrics.TokenRefreshes.Inc()
+		tokenResp, err := c.requestToken(ctx, form)
 
-	// Prepare form data
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.refreshing = false
+		if err != nil {
+			c.logger.Warningf("auth", "background token refresh failed, keeping the current token: %v", err)
+			return
+		}
+		c.storeToken(tokenResp)
+		c.logger.Debugf("auth", "refreshed access token in the background, valid for %ds", tokenResp.ExpiresIn)
+	}()
+}
+
+// storeToken records a token response; the caller must hold the write lock
+func (c *OAuthClient) storeToken(tokenResp *TokenResponse) {
+	// Store token with buffer time (subtract 60 seconds for safety)
+	c.token = tokenResp
+	c.tokenIssued = time.Now()
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
+	// Servers may rotate refresh tokens; the previous one can stop working
+	if tokenResp.RefreshToken != "" {
+		c.refreshValue = tokenResp.RefreshToken
+	}
+}
+
+// tokenForm builds the token request form for the configured grant type; the caller
+// must hold the lock, since the refresh token changes as tokens are stored
+func (c *OAuthClient) tokenForm() url.Values {
 	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
+	data.Set("grant_type", c.grantType)
 	data.Set("client_id", c.clientID)
+	switch c.grantType {
+	case GrantPassword:
+		data.Set("username", c.username)
+		data.Set("password", c.password)
+	case GrantRefreshToken:
+		data.Set("refresh_token", c.refreshValue)
+	}
+	if c.scope != "" {
+		data.Set("scope", c.scope)
+	}
+	if c.audience != "" {
+		data.Set("audience", c.audience)
+	}
+	return data
+}
+
+// requestToken performs the token request, counting it and any failure
+func (c *OAuthClient) requestToken(ctx context.Context, form url.Values) (*TokenResponse, error) {
+	metrics.TokenRequests.Inc()
+	tokenResp, err := c.sendTokenRequest(ctx, form)
+	if err != nil {
+		metrics.TokenErrors.Inc()
+	}
+	return tokenResp, err
+}
 
+// sendTokenRequest posts the token request form
+func (c *OAuthClient) sendTokenRequest(ctx context.Context, form url.Values) (*TokenResponse, error) {
 	// Create request
-	req, err := http.NewRequest("POST", c.authURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.authURL, strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
+	// Set headers; public clients of the password and refresh_token grants have no
+	// secret and identify themselves with client_id alone
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Authorization", "Basic "+credentials)
-	req.Header.Set("Host", "authenticate.constellation1apis.com")
+	if c.clientSecret != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.clientID + ":" + c.clientSecret))
+		req.Header.Set("Authorization", "Basic "+credentials)
+	}
 	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
 
 	// Make request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("authentication failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("authentication failed with status %d (%s grant): %s", resp.StatusCode, form.Get("grant_type"), string(body))
 	}
 
 	// Parse response
 	var tokenResp TokenResponse
 	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return "", fmt.Errorf("failed to parse token response: %w", err)
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
 	}
 
-	// Store token with buffer time (subtract 60 seconds for safety)
-	c.token = &tokenResp
-	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
+	return &tokenResp, nil
+}
 
-	return tokenResp.AccessToken, nil
+// GrantType returns the OAuth2 grant type used for token requests
+func (c *OAuthClient) GrantType() string {
+	return c.grantType
 }
 
 // IsTokenValid checks if the current token is valid
@@ -123,6 +294,16 @@ func (c *OAuthClient) IsTokenValid() bool {
 	return c.token != nil && time.Now().Before(c.tokenExpiry)
 }
 
+// TokenExpiry returns when the current token expires, or the zero time when no token is held
+func (c *OAuthClient) TokenExpiry() time.Time {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.token == nil {
+		return time.Time{}
+	}
+	return c.tokenExpiry
+}
+
 // ClearToken clears the stored token (useful for testing or forced refresh)
 func (c *OAuthClient) ClearToken() {
 	c.mutex.Lock()
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer issues numbered tokens (token-1, token-2, ...). Requests after the
+// first wait until release is closed, and fail with 500 while fail is set.
+func newTokenServer(t *testing.T, release <-chan struct{}, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n > 1 {
+			<-release
+		}
+		if fail != nil && fail.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"token_type":"Bearer"}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// ageToken moves the held token into the refresh buffer, as if it had been issued
+// long ago and is about to expire
+func ageToken(c *OAuthClient) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.tokenIssued = time.Now().Add(-time.Hour)
+	c.tokenExpiry = time.Now().Add(time.Minute)
+}
+
+// waitForToken polls until the client holds want or a second passes
+func waitForToken(t *testing.T, c *OAuthClient, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mutex.RLock()
+		got := c.token.AccessToken
+		c.mutex.RUnlock()
+		if got == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("token was not replaced with %s", want)
+}
+
+func TestBackgroundRefreshDuringGetToken(t *testing.T) {
+	release := make(chan struct{})
+	server, requests := newTokenServer(t, release, nil)
+	client := NewOAuthClient("id", "secret", server.URL)
+
+	if token, err := client.GetToken(); err != nil || token != "token-1" {
+		t.Fatalf("GetToken = %q, %v, want token-1", token, err)
+	}
+	ageToken(client)
+
+	// While the refresh is in flight every caller keeps getting the current token
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := client.GetToken(); err != nil || token != "token-1" {
+				t.Errorf("GetToken during refresh = %q, %v, want token-1", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	waitForToken(t, client, "token-2")
+	if token, err := client.GetToken(); err != nil || token != "token-2" {
+		t.Errorf("GetToken after refresh = %q, %v, want token-2", token, err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("token endpoint saw %d requests, want 2 (one background refresh)", got)
+	}
+}
+
+func TestFailedBackgroundRefreshKeepsToken(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var fail atomic.Bool
+	server, requests := newTokenServer(t, release, &fail)
+	client := NewOAuthClient("id", "secret", server.URL)
+
+	if _, err := client.GetToken(); err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	fail.Store(true)
+	ageToken(client)
+
+	if token, err := client.GetToken(); err != nil || token != "token-1" {
+		t.Fatalf("GetToken = %q, %v, want token-1", token, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		client.mutex.RLock()
+		refreshing := client.refreshing
+		client.mutex.RUnlock()
+		if !refreshing || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The failed refresh is forgotten, so the next call tries again and succeeds
+	fail.Store(false)
+	if token, err := client.GetToken(); err != nil || token != "token-1" {
+		t.Fatalf("GetToken after failed refresh = %q, %v, want token-1", token, err)
+	}
+	waitForToken(t, client, "token-3")
+	if got := requests.Load(); got != 3 {
+		t.Errorf("token endpoint saw %d requests, want 3", got)
+	}
+}
@@ -4,14 +4,76 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the configuration for the RESO MCP server
 type Config struct {
+	ClientID           string              `json:"client_id"`
+	ClientSecret       string              `json:"client_secret"`
+	ClientIDFile       string              `json:"client_id_file,omitempty"`
+	ClientSecretFile   string              `json:"client_secret_file,omitempty"`
+	AuthURL            string              `json:"auth_url"`
+	BaseURL            string              `json:"base_url"`
+	MetadataPath       string              `json:"metadata_path,omitempty"`
+	Scope              string              `json:"scope,omitempty"`
+	Audience           string              `json:"audience,omitempty"`
+	GrantType          string              `json:"grant_type,omitempty"`
+	Username           string              `json:"username,omitempty"`
+	Password           string              `json:"password,omitempty"`
+	RefreshToken       string              `json:"refresh_token,omitempty"`
+	TokenRefresh       time.Duration       `json:"token_refresh_buffer,omitempty"`
+	Debug              bool                `json:"debug,omitempty"`
+	HTTPTimeout        time.Duration       `json:"http_timeout,omitempty"`
+	CacheTTL           time.Duration       `json:"query_cache_ttl"`
+	RateLimit          float64             `json:"rate_limit"`
+	MaxConcurrency     int                 `json:"max_concurrency"`
+	DefaultTop         int                 `json:"default_top"`
+	MaxTop             int                 `json:"max_top"`
+	MaxResponseBytes   int                 `json:"max_response_bytes"`
+	MaxExpandDepth     int                 `json:"max_expand_depth,omitempty"`
+	ExpandTop          int                 `json:"expand_top,omitempty"`
+	UserAgent          string              `json:"user_agent,omitempty"`
+	HostHeader         string              `json:"host_header,omitempty"`
+	RequestIDHeader    string              `json:"request_id_header,omitempty"`
+	FieldCategories    map[string]string   `json:"field_categories,omitempty"`
+	FieldAliases       map[string]string   `json:"field_aliases,omitempty"`
+	AllowedEntities    []string            `json:"allowed_entities,omitempty"`
+	AllowedOperators   []string            `json:"allowed_operators,omitempty"`
+	RedactPII          bool                `json:"redact_pii,omitempty"`
+	RedactFields       []string            `json:"redact_fields,omitempty"`
+	DisableCount       bool                `json:"disable_count,omitempty"`
+	AutoSelect         bool                `json:"auto_select,omitempty"`
+	DisableAutoOrder   bool                `json:"disable_auto_orderby,omitempty"`
+	MaxIdleConns       int                 `json:"max_idle_conns,omitempty"`
+	MaxIdleConnsHost   int                 `json:"max_idle_conns_per_host,omitempty"`
+	IdleConnTimeout    time.Duration       `json:"idle_conn_timeout,omitempty"`
+	MaintCooldown      time.Duration       `json:"maintenance_cooldown,omitempty"`
+	Timezone           string              `json:"timezone,omitempty"`
+	DefaultEntity      string              `json:"default_entity,omitempty"`
+	RequireCredentials bool                `json:"require_credentials,omitempty"`
+	EagerConnect       bool                `json:"eager_connect,omitempty"`
+	PresetsFile        string              `json:"presets_file,omitempty"`
+	MetadataSources    []string            `json:"metadata_source,omitempty"`
+	Profile            string              `json:"profile,omitempty"`
+	Profiles           map[string]*Profile `json:"-"`
+}
+
+// Profile holds a named set of credentials and optional endpoint overrides
+type Profile struct {
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
-	AuthURL      string `json:"auth_url"`
-	BaseURL      string `json:"base_url"`
+	AuthURL      string `json:"auth_url,omitempty"`
+	BaseURL      string `json:"base_url,omitempty"`
+	MetadataPath string `json:"metadata_path,omitempty"`
+}
+
+// ProfilesFile represents the JSON file referenced by RESO_CONFIG_FILE
+type ProfilesFile struct {
+	DefaultProfile string              `json:"default_profile,omitempty"`
+	Profiles       map[string]*Profile `json:"profiles"`
 }
 
 // MCPSettings represents the MCP server settings format
@@ -23,8 +85,15 @@ type MCPSettings struct {
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		AuthURL: "https://authenticate.constellation1apis.com/oauth2/token",
-		BaseURL: "https://listings.cdatalabs.com/odata",
+		AuthURL:          "https://authenticate.constellation1apis.com/oauth2/token",
+		BaseURL:          "https://listings.cdatalabs.com/odata",
+		CacheTTL:         60 * time.Second,
+		RateLimit:        5,
+		MaxConcurrency:   4,
+		DefaultTop:       10,
+		MaxTop:           1000,
+		MaxResponseBytes: 512 * 1024,
+		MaintCooldown:    60 * time.Second,
 	}
 }
 
@@ -42,11 +111,354 @@ func (c *Config) LoadFromMCPSettings(settings map[string]interface{}) error {
 		c.ClientSecret = clientSecret
 	}
 
+	// Credentials mounted as files are used when the value itself is not given
+	var fileErr error
+	if path, ok := settings["client_id_file"].(string); ok && path != "" {
+		c.ClientIDFile = path
+		if clientID, _ := settings["client_id"].(string); clientID == "" {
+			c.ClientID, fileErr = ReadSecretFile(path)
+		}
+	}
+	if path, ok := settings["client_secret_file"].(string); ok && path != "" {
+		c.ClientSecretFile = path
+		if clientSecret, _ := settings["client_secret"].(string); clientSecret == "" {
+			var err error
+			if c.ClientSecret, err = ReadSecretFile(path); err != nil && fileErr == nil {
+				fileErr = err
+			}
+		}
+	}
+
+	if authURL, ok := settings["auth_url"].(string); ok && authURL != "" {
+		c.AuthURL = authURL
+	}
+
+	if baseURL, ok := settings["base_url"].(string); ok && baseURL != "" {
+		c.BaseURL = baseURL
+	}
+
+	if scope, ok := settings["scope"].(string); ok && scope != "" {
+		c.Scope = scope
+	}
+
+	if audience, ok := settings["audience"].(string); ok && audience != "" {
+		c.Audience = audience
+	}
+
+	if grantType, ok := settings["grant_type"].(string); ok && grantType != "" {
+		c.GrantType = strings.ToLower(strings.TrimSpace(grantType))
+	}
+	if username, ok := settings["username"].(string); ok && username != "" {
+		c.Username = username
+	}
+	if password, ok := settings["password"].(string); ok && password != "" {
+		c.Password = password
+	}
+	if refreshToken, ok := settings["refresh_token"].(string); ok && refreshToken != "" {
+		c.RefreshToken = refreshToken
+	}
+
+	if debug, ok := settings["debug"].(bool); ok {
+		c.Debug = debug
+	}
+
+	switch ttl := settings["query_cache_ttl"].(type) {
+	case float64:
+		c.CacheTTL = time.Duration(ttl * float64(time.Second))
+	case string:
+		if d, err := ParseDuration(ttl); err == nil {
+			c.CacheTTL = d
+		}
+	}
+
+	if userAgent, ok := settings["user_agent"].(string); ok && userAgent != "" {
+		c.UserAgent = userAgent
+	}
+
+	if host, ok := settings["host_header"].(string); ok && host != "" {
+		c.HostHeader = host
+	}
+
+	if header, ok := settings["request_id_header"].(string); ok && header != "" {
+		c.RequestIDHeader = header
+	}
+
+	if metadataPath, ok := settings["metadata_path"].(string); ok && metadataPath != "" {
+		c.MetadataPath = metadataPath
+	}
+
+	if rps, ok := SettingsFloat(settings["rate_limit"]); ok {
+		c.RateLimit = rps
+	}
+
+	if limit, ok := settingsInt(settings["max_concurrency"]); ok {
+		c.MaxConcurrency = limit
+	}
+
+	if top, ok := settingsInt(settings["default_top"]); ok {
+		c.DefaultTop = top
+	}
+
+	if top, ok := settingsInt(settings["max_top"]); ok {
+		c.MaxTop = top
+	}
+
+	if size, ok := settingsInt(settings["max_response_bytes"]); ok {
+		c.MaxResponseBytes = size
+	}
+
+	if depth, ok := settingsInt(settings["max_expand_depth"]); ok {
+		c.MaxExpandDepth = depth
+	}
+	if top, ok := settingsInt(settings["expand_top"]); ok {
+		c.ExpandTop = top
+	}
+
+	if conns, ok := settingsInt(settings["max_idle_conns"]); ok {
+		c.MaxIdleConns = conns
+	}
+	if conns, ok := settingsInt(settings["max_idle_conns_per_host"]); ok {
+		c.MaxIdleConnsHost = conns
+	}
+	switch timeout := settings["idle_conn_timeout"].(type) {
+	case float64:
+		c.IdleConnTimeout = time.Duration(timeout * float64(time.Second))
+	case string:
+		if d, err := ParseDuration(timeout); err == nil {
+			c.IdleConnTimeout = d
+		}
+	}
+	switch cooldown := settings["maintenance_cooldown"].(type) {
+	case float64:
+		c.MaintCooldown = time.Duration(cooldown * float64(time.Second))
+	case string:
+		if d, err := ParseDuration(cooldown); err == nil {
+			c.MaintCooldown = d
+		}
+	}
+
+	if entities, ok := settingsList(settings["allowed_entities"]); ok {
+		c.AllowedEntities = entities
+	}
+	if operators, ok := settingsList(settings["allowed_operators"]); ok {
+		c.AllowedOperators = lowerAll(operators)
+	}
+
+	switch redact := settings["redact_pii"].(type) {
+	case bool:
+		c.RedactPII = redact
+	case string:
+		if parsed, err := strconv.ParseBool(redact); err == nil {
+			c.RedactPII = parsed
+		}
+	}
+
+	if fields, ok := settingsList(settings["redact_fields"]); ok {
+		c.RedactFields = fields
+	}
+
+	switch disable := settings["disable_count"].(type) {
+	case bool:
+		c.DisableCount = disable
+	case string:
+		if parsed, err := strconv.ParseBool(disable); err == nil {
+			c.DisableCount = parsed
+		}
+	}
+
+	switch autoSelect := settings["auto_select"].(type) {
+	case bool:
+		c.AutoSelect = autoSelect
+	case string:
+		if parsed, err := strconv.ParseBool(autoSelect); err == nil {
+			c.AutoSelect = parsed
+		}
+	}
+
+	switch disable := settings["disable_auto_orderby"].(type) {
+	case bool:
+		c.DisableAutoOrder = disable
+	case string:
+		if parsed, err := strconv.ParseBool(disable); err == nil {
+			c.DisableAutoOrder = parsed
+		}
+	}
+
+	if timezone, ok := settings["timezone"].(string); ok && timezone != "" {
+		c.Timezone = timezone
+	}
+	if entity, ok := settings["default_entity"].(string); ok && strings.TrimSpace(entity) != "" {
+		c.DefaultEntity = strings.TrimSpace(entity)
+	}
+
+	switch require := settings["require_credentials"].(type) {
+	case bool:
+		c.RequireCredentials = require
+	case string:
+		if parsed, err := strconv.ParseBool(require); err == nil {
+			c.RequireCredentials = parsed
+		}
+	}
+
+	switch eager := settings["eager_connect"].(type) {
+	case bool:
+		c.EagerConnect = eager
+	case string:
+		if parsed, err := strconv.ParseBool(eager); err == nil {
+			c.EagerConnect = parsed
+		}
+	}
+
+	if presetsFile, ok := settings["presets_file"].(string); ok && presetsFile != "" {
+		c.PresetsFile = presetsFile
+	}
+
+	if sources, ok := settingsList(settings["metadata_source"]); ok {
+		if parsed, err := ParseMetadataSources(strings.Join(sources, ",")); err == nil {
+			c.MetadataSources = parsed
+		}
+	}
+
+	switch categories := settings["field_categories"].(type) {
+	case map[string]interface{}:
+		c.FieldCategories = make(map[string]string, len(categories))
+		for field, category := range categories {
+			if name, ok := category.(string); ok {
+				c.FieldCategories[field] = name
+			}
+		}
+	case string:
+		if parsed, err := ParseFieldCategories(categories); err == nil {
+			c.FieldCategories = parsed
+		}
+	}
+
+	switch aliases := settings["field_aliases"].(type) {
+	case map[string]interface{}:
+		c.FieldAliases = make(map[string]string, len(aliases))
+		for alias, field := range aliases {
+			if name, ok := field.(string); ok {
+				c.FieldAliases[alias] = name
+			}
+		}
+	case string:
+		if parsed, err := ParseFieldAliases(aliases); err == nil {
+			c.FieldAliases = parsed
+		}
+	}
+
+	switch buffer := settings["token_refresh_buffer"].(type) {
+	case float64:
+		c.TokenRefresh = time.Duration(buffer * float64(time.Second))
+	case string:
+		if d, err := ParseDuration(buffer); err == nil {
+			c.TokenRefresh = d
+		}
+	}
+
+	switch timeout := settings["http_timeout"].(type) {
+	case float64:
+		c.HTTPTimeout = time.Duration(timeout * float64(time.Second))
+	case string:
+		if d, err := ParseDuration(timeout); err == nil {
+			c.HTTPTimeout = d
+		}
+	}
+
+	// Resolve a named credential profile, if one is selected
+	profile, _ := settings["profile"].(string)
+	if profile == "" {
+		profile = os.Getenv("CLIENT_PROFILE")
+	}
+	if err := c.UseProfile(profile); err != nil {
+		return err
+	}
+	if fileErr != nil {
+		return fileErr
+	}
+
 	// Don't require credentials during MCP initialization
 	// They will be validated when actually needed
 	return nil
 }
 
+// ReadSecretFile reads a credential from a file such as a mounted Kubernetes or
+// Docker secret, trimming the trailing newline editors and tools usually add
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("credential file %s is empty", path)
+	}
+	return value, nil
+}
+
+// LoadProfiles loads named credential profiles from a JSON file
+func (c *Config) LoadProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file ProfilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	c.Profiles = file.Profiles
+	if c.Profile == "" {
+		c.Profile = file.DefaultProfile
+	}
+	return nil
+}
+
+// UseProfile merges the named profile into the configuration. Profiles are loaded
+// from RESO_CONFIG_FILE on first use; an empty name falls back to the file's
+// default profile, and is a no-op when no profiles are defined.
+func (c *Config) UseProfile(name string) error {
+	if c.Profiles == nil {
+		if path := os.Getenv("RESO_CONFIG_FILE"); path != "" {
+			if err := c.LoadProfiles(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, exists := c.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	if profile.ClientID != "" {
+		c.ClientID = profile.ClientID
+	}
+	if profile.ClientSecret != "" {
+		c.ClientSecret = profile.ClientSecret
+	}
+	if profile.AuthURL != "" {
+		c.AuthURL = profile.AuthURL
+	}
+	if profile.BaseURL != "" {
+		c.BaseURL = profile.BaseURL
+	}
+	if profile.MetadataPath != "" {
+		c.MetadataPath = profile.MetadataPath
+	}
+	c.Profile = name
+
+	return nil
+}
+
 // LoadFromEnv loads configuration from environment variables
 func (c *Config) LoadFromEnv() {
 	if clientID := os.Getenv("RESO_CLIENT_ID"); clientID != "" {
@@ -55,12 +467,336 @@ func (c *Config) LoadFromEnv() {
 	if clientSecret := os.Getenv("RESO_CLIENT_SECRET"); clientSecret != "" {
 		c.ClientSecret = clientSecret
 	}
+	if path := firstEnv("CLIENT_ID_FILE", "RESO_CLIENT_ID_FILE"); path != "" && c.ClientID == "" {
+		if clientID, err := ReadSecretFile(path); err == nil {
+			c.ClientID, c.ClientIDFile = clientID, path
+		}
+	}
+	if path := firstEnv("CLIENT_SECRET_FILE", "RESO_CLIENT_SECRET_FILE"); path != "" && c.ClientSecret == "" {
+		if clientSecret, err := ReadSecretFile(path); err == nil {
+			c.ClientSecret, c.ClientSecretFile = clientSecret, path
+		}
+	}
 	if authURL := os.Getenv("RESO_AUTH_URL"); authURL != "" {
 		c.AuthURL = authURL
 	}
 	if baseURL := os.Getenv("RESO_BASE_URL"); baseURL != "" {
 		c.BaseURL = baseURL
 	}
+	if metadataPath := os.Getenv("RESO_METADATA_PATH"); metadataPath != "" {
+		c.MetadataPath = metadataPath
+	}
+	if scope := os.Getenv("RESO_OAUTH_SCOPE"); scope != "" {
+		c.Scope = scope
+	}
+	if audience := os.Getenv("RESO_OAUTH_AUDIENCE"); audience != "" {
+		c.Audience = audience
+	}
+	if grantType := os.Getenv("RESO_OAUTH_GRANT_TYPE"); grantType != "" {
+		c.GrantType = strings.ToLower(strings.TrimSpace(grantType))
+	}
+	if username := os.Getenv("RESO_OAUTH_USERNAME"); username != "" {
+		c.Username = username
+	}
+	if password := os.Getenv("RESO_OAUTH_PASSWORD"); password != "" {
+		c.Password = password
+	}
+	if refreshToken := os.Getenv("RESO_OAUTH_REFRESH_TOKEN"); refreshToken != "" {
+		c.RefreshToken = refreshToken
+	}
+	if debug, err := strconv.ParseBool(os.Getenv("RESO_DEBUG")); err == nil {
+		c.Debug = debug
+	}
+	if timeout, err := ParseDuration(os.Getenv("RESO_HTTP_TIMEOUT")); err == nil {
+		c.HTTPTimeout = timeout
+	}
+	if buffer, err := ParseDuration(os.Getenv("RESO_TOKEN_REFRESH_BUFFER")); err == nil {
+		c.TokenRefresh = buffer
+	}
+	if ttl, err := ParseDuration(os.Getenv("RESO_QUERY_CACHE_TTL")); err == nil {
+		c.CacheTTL = ttl
+	}
+	if rps, err := strconv.ParseFloat(os.Getenv("RESO_RATE_LIMIT"), 64); err == nil {
+		c.RateLimit = rps
+	}
+	if limit, err := strconv.Atoi(os.Getenv("RESO_MAX_CONCURRENCY")); err == nil {
+		c.MaxConcurrency = limit
+	}
+	if userAgent := os.Getenv("RESO_USER_AGENT"); userAgent != "" {
+		c.UserAgent = userAgent
+	}
+	if host := os.Getenv("RESO_HOST_HEADER"); host != "" {
+		c.HostHeader = host
+	}
+	if header := os.Getenv("RESO_REQUEST_ID_HEADER"); header != "" {
+		c.RequestIDHeader = header
+	}
+	if top, err := strconv.Atoi(os.Getenv("RESO_DEFAULT_TOP")); err == nil {
+		c.DefaultTop = top
+	}
+	if top, err := strconv.Atoi(os.Getenv("RESO_MAX_TOP")); err == nil {
+		c.MaxTop = top
+	}
+	if size, err := strconv.Atoi(os.Getenv("RESO_MAX_RESPONSE_BYTES")); err == nil {
+		c.MaxResponseBytes = size
+	}
+	if depth, err := strconv.Atoi(os.Getenv("RESO_MAX_EXPAND_DEPTH")); err == nil {
+		c.MaxExpandDepth = depth
+	}
+	if top, err := strconv.Atoi(os.Getenv("RESO_EXPAND_TOP")); err == nil {
+		c.ExpandTop = top
+	}
+	if conns, err := strconv.Atoi(os.Getenv("RESO_MAX_IDLE_CONNS")); err == nil {
+		c.MaxIdleConns = conns
+	}
+	if conns, err := strconv.Atoi(os.Getenv("RESO_MAX_IDLE_CONNS_PER_HOST")); err == nil {
+		c.MaxIdleConnsHost = conns
+	}
+	if timeout, err := ParseDuration(os.Getenv("RESO_IDLE_CONN_TIMEOUT")); err == nil {
+		c.IdleConnTimeout = timeout
+	}
+	if cooldown, err := ParseDuration(os.Getenv("RESO_MAINTENANCE_COOLDOWN")); err == nil {
+		c.MaintCooldown = cooldown
+	}
+	if entities, ok := settingsList(os.Getenv("RESO_ALLOWED_ENTITIES")); ok {
+		c.AllowedEntities = entities
+	}
+	if operators, ok := settingsList(os.Getenv("RESO_ALLOWED_OPERATORS")); ok {
+		c.AllowedOperators = lowerAll(operators)
+	}
+	if redact, err := strconv.ParseBool(os.Getenv("RESO_REDACT_PII")); err == nil {
+		c.RedactPII = redact
+	}
+	if fields, ok := settingsList(os.Getenv("RESO_REDACT_FIELDS")); ok {
+		c.RedactFields = fields
+	}
+	if disable, err := strconv.ParseBool(os.Getenv("RESO_DISABLE_COUNT")); err == nil {
+		c.DisableCount = disable
+	}
+	if autoSelect, err := strconv.ParseBool(os.Getenv("RESO_AUTO_SELECT")); err == nil {
+		c.AutoSelect = autoSelect
+	}
+	if disable, err := strconv.ParseBool(os.Getenv("RESO_DISABLE_AUTO_ORDERBY")); err == nil {
+		c.DisableAutoOrder = disable
+	}
+	if timezone := os.Getenv("RESO_TIMEZONE"); timezone != "" {
+		c.Timezone = timezone
+	}
+	if entity := strings.TrimSpace(os.Getenv("RESO_DEFAULT_ENTITY")); entity != "" {
+		c.DefaultEntity = entity
+	}
+	if require, err := strconv.ParseBool(os.Getenv("RESO_REQUIRE_CREDENTIALS")); err == nil {
+		c.RequireCredentials = require
+	}
+	if eager, err := strconv.ParseBool(os.Getenv("RESO_EAGER_CONNECT")); err == nil {
+		c.EagerConnect = eager
+	}
+	if presetsFile := os.Getenv("RESO_PRESETS_FILE"); presetsFile != "" {
+		c.PresetsFile = presetsFile
+	}
+	if categories, err := ParseFieldCategories(os.Getenv("RESO_FIELD_CATEGORIES")); err == nil && len(categories) > 0 {
+		c.FieldCategories = categories
+	}
+	if aliases, err := ParseFieldAliases(os.Getenv("RESO_FIELD_ALIASES")); err == nil && len(aliases) > 0 {
+		c.FieldAliases = aliases
+	}
+	if sources, err := ParseMetadataSources(os.Getenv("RESO_METADATA_SOURCE")); err == nil && len(sources) > 0 {
+		c.MetadataSources = sources
+	}
+}
+
+// DefaultMetadataSources is the order metadata sources are tried in when
+// metadata_source is unset
+var DefaultMetadataSources = []string{"cache", "api", "file"}
+
+// ParseMetadataSources parses a comma-separated metadata source order such as
+// "api,cache". Each of cache, api and file may appear once; sources left out are not
+// tried at all.
+func ParseMetadataSources(value string) ([]string, error) {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, source := range strings.Split(value, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if source == "" {
+			continue
+		}
+		switch {
+		case source != "cache" && source != "api" && source != "file":
+			return nil, fmt.Errorf("invalid metadata_source %q (use cache, api or file)", source)
+		case seen[source]:
+			return nil, fmt.Errorf("metadata_source lists %q more than once", source)
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// ParseFieldCategories parses field category overrides given either as a JSON object
+// or as comma-separated Field=Category pairs
+func ParseFieldCategories(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	categories := make(map[string]string)
+	if value == "" {
+		return categories, nil
+	}
+
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &categories); err != nil {
+			return nil, fmt.Errorf("invalid field_categories JSON: %w", err)
+		}
+		return categories, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		field, category, ok := strings.Cut(pair, "=")
+		field, category = strings.TrimSpace(field), strings.TrimSpace(category)
+		if !ok || field == "" || category == "" {
+			return nil, fmt.Errorf("invalid field_categories entry %q (expected Field=Category)", strings.TrimSpace(pair))
+		}
+		categories[field] = category
+	}
+	return categories, nil
+}
+
+// ParseFieldAliases parses field aliases given either as a JSON object or as
+// comma-separated alias=Field pairs. An empty field removes a built-in alias.
+func ParseFieldAliases(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	aliases := make(map[string]string)
+	if value == "" {
+		return aliases, nil
+	}
+
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &aliases); err != nil {
+			return nil, fmt.Errorf("invalid field_aliases JSON: %w", err)
+		}
+		return aliases, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		alias, field, ok := strings.Cut(pair, "=")
+		alias, field = strings.TrimSpace(alias), strings.TrimSpace(field)
+		if !ok || alias == "" {
+			return nil, fmt.Errorf("invalid field_aliases entry %q (expected alias=Field)", strings.TrimSpace(pair))
+		}
+		aliases[alias] = field
+	}
+	return aliases, nil
+}
+
+// firstEnv returns the value of the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// settingsInt reads an integer setting given as a JSON number or a numeric string
+func settingsInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+// settingsList reads a list setting given as a JSON array of strings or a
+// comma-separated string, reporting false when it is absent or empty
+func settingsList(value interface{}) ([]string, bool) {
+	var items []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+	case []string:
+		items = v
+	case string:
+		items = strings.Split(v, ",")
+	}
+
+	var list []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list, len(list) > 0
+}
+
+// SettingsList reads a list setting given as a JSON array of strings or a
+// comma-separated string, returning nil when it is absent or empty
+func SettingsList(value interface{}) []string {
+	list, _ := settingsList(value)
+	return list
+}
+
+// SettingsFloat reads a numeric setting given as a JSON number or a numeric string,
+// reporting false when it is absent or unparseable
+func SettingsFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+// SettingsBool reads a boolean setting given as a JSON boolean or a string such as
+// "true", reporting false when it is absent or unparseable
+func SettingsBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		parsed, _ := strconv.ParseBool(v)
+		return parsed
+	}
+	return false
+}
+
+// lowerAll returns the items lower-cased
+func lowerAll(items []string) []string {
+	lowered := make([]string, len(items))
+	for i, item := range items {
+		lowered[i] = strings.ToLower(item)
+	}
+	return lowered
+}
+
+// ParseDuration parses a Go duration ("30s", "2m") or a plain number of seconds
+func ParseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Location returns the time zone timestamps are rendered in: Timezone when it is a
+// valid IANA name, otherwise UTC
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
 }
 
 // Validate checks if the configuration is valid
@@ -68,8 +804,8 @@ func (c *Config) Validate() error {
 	if c.ClientID == "" {
 		return fmt.Errorf("client_id is required")
 	}
-	if c.ClientSecret == "" {
-		return fmt.Errorf("client_secret is required")
+	if err := c.validateGrant(); err != nil {
+		return err
 	}
 	if c.AuthURL == "" {
 		return fmt.Errorf("auth_url is required")
@@ -85,8 +821,31 @@ func (c *Config) ValidateCredentials() error {
 	if c.ClientID == "" {
 		return fmt.Errorf("client_id is required - please configure in MCP settings")
 	}
-	if c.ClientSecret == "" {
-		return fmt.Errorf("client_secret is required - please configure in MCP settings")
+	if err := c.validateGrant(); err != nil {
+		return fmt.Errorf("%w - please configure in MCP settings", err)
+	}
+	return nil
+}
+
+// validateGrant checks that the credentials the configured grant type sends are
+// present. The client secret is only required for client_credentials, since password
+// and refresh_token grants may be used by public clients without one.
+func (c *Config) validateGrant() error {
+	switch c.GrantType {
+	case "", "client_credentials":
+		if c.ClientSecret == "" {
+			return fmt.Errorf("client_secret is required")
+		}
+	case "password":
+		if c.Username == "" || c.Password == "" {
+			return fmt.Errorf("username and password are required for the password grant")
+		}
+	case "refresh_token":
+		if c.RefreshToken == "" {
+			return fmt.Errorf("refresh_token is required for the refresh_token grant")
+		}
+	default:
+		return fmt.Errorf("unsupported grant_type %q (use client_credentials, password or refresh_token)", c.GrantType)
 	}
 	return nil
 }
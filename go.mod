@@ -1,3 +1,5 @@
 module github.com/rennietech/constellation1-mcp-server
 
 go 1.21
+
+require github.com/andybalholm/brotli v1.1.1
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/metrics"
+)
+
+// mcpEndpoint is the path that accepts MCP-over-HTTP requests
+const mcpEndpoint = "/mcp"
+
+// metricsEndpoint serves the process-wide counters in the Prometheus text format
+const metricsEndpoint = "/metrics"
+
+// sessionHeader carries the session ID assigned at initialize
+const sessionHeader = "Mcp-Session-Id"
+
+// sessionIdleTimeout is how long an unused session is kept before it is closed
+const sessionIdleTimeout = 30 * time.Minute
+
+// sessionReapInterval is how often sessions are checked for idleness
+const sessionReapInterval = time.Minute
+
+// maxHTTPSessions caps live sessions; initialize is refused with 503 until a session
+// ends or goes idle
+const maxHTTPSessions = 256
+
+// errTooManySessions is returned by newSession when maxSessions are live
+var errTooManySessions = errors.New("too many open sessions; end an unused session or retry later")
+
+// maxHTTPMessageSize bounds the body of a single JSON-RPC POST
+const maxHTTPMessageSize = 10 << 20
+
+// sessionEventBuffer is how many server-sent messages are queued per session; later
+// messages are dropped while no event stream is draining the queue
+const sessionEventBuffer = 256
+
+// httpSession is one client's MCP server and its queue of server-sent messages.
+// Messages for a session are handled one at a time, as on stdio.
+type httpSession struct {
+	mutex    sync.Mutex
+	server   *MCPServer
+	events   chan []byte
+	lastSeen time.Time
+	// done is closed when the session ends to stop its event stream
+	done chan struct{}
+}
+
+// Write queues one framed message from the session's server for the event stream
+func (s *httpSession) Write(p []byte) (int, error) {
+	select {
+	case s.events <- bytes.TrimSpace(append([]byte(nil), p...)):
+	default:
+	}
+	return len(p), nil
+}
+
+// close ends the session's event stream and shuts its server down, giving a request
+// still running up to timeout to finish. The server's API client then releases its
+// idle connections.
+func (s *httpSession) close(timeout time.Duration) {
+	close(s.done)
+	s.server.Shutdown(timeout)
+}
+
+// httpTransport serves MCP as JSON-RPC over HTTP POST, with notifications streamed
+// over server-sent events. Each session gets its own MCPServer so per-client
+// credentials passed to initialize stay separate.
+type httpTransport struct {
+	settings    map[string]interface{}
+	sessions    map[string]*httpSession
+	maxSessions int
+	mutex       sync.Mutex
+	// closing is closed at shutdown to end event streams and the idle reaper
+	closing chan struct{}
+}
+
+// newHTTPTransport creates a transport whose sessions start from settings
+func newHTTPTransport(settings map[string]interface{}) *httpTransport {
+	return &httpTransport{
+		settings:    settings,
+		sessions:    make(map[string]*httpSession),
+		maxSessions: maxHTTPSessions,
+		closing:     make(chan struct{}),
+	}
+}
+
+// handler routes /mcp and /metrics, requiring token when it is set
+func (t *httpTransport) handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(mcpEndpoint, t)
+	mux.HandleFunc(metricsEndpoint, serveMetrics)
+	return requireBearerToken(token, mux)
+}
+
+// serveHTTP listens on addr and serves MCP until the listener fails or a SIGINT or
+// SIGTERM arrives. Shutdown lets in-flight requests finish for up to shutdownTimeout,
+// then cancels whatever is still running in each session.
+//
+// Every session queries RESO with the operator's credentials, so an address without
+// a host binds to 127.0.0.1, and a non-loopback address needs a bearer token unless
+// allowUnauthenticated is set. With a token, /mcp and /metrics require it.
+func serveHTTP(addr, token string, allowUnauthenticated bool, settings map[string]interface{}) error {
+	addr = listenAddress(addr)
+	if token == "" && !isLoopbackAddress(addr) {
+		if !allowUnauthenticated {
+			return fmt.Errorf("refusing to serve %s without authentication: set -http-token (or RESO_HTTP_TOKEN), listen on 127.0.0.1, or pass -allow-unauthenticated", addr)
+		}
+		log.Printf("Warning: serving %s without authentication; anyone who can reach it can query RESO with the configured credentials", addr)
+	}
+
+	transport := newHTTPTransport(settings)
+	httpServer := &http.Server{Addr: addr, Handler: transport.handler(token)}
+	go transport.reapIdleSessions()
+
+	log.Printf("Listening for MCP over HTTP on %s%s (metrics on %s)", addr, mcpEndpoint, metricsEndpoint)
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case sig := <-shutdownSignals():
+		log.Printf("Received %s, shutting down", sig)
+	}
+
+	// Stop accepting connections and wait for in-flight requests to reply
+	close(transport.closing)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	log.Printf("Shutdown: waiting up to %s for in-flight HTTP requests", shutdownTimeout)
+	drained := httpServer.Shutdown(ctx) == nil
+
+	// Cancel whatever is still running and close every session
+	transport.mutex.Lock()
+	sessions := make([]*httpSession, 0, len(transport.sessions))
+	for id, session := range transport.sessions {
+		sessions = append(sessions, session)
+		delete(transport.sessions, id)
+	}
+	transport.mutex.Unlock()
+	if !drained {
+		log.Printf("Shutdown: HTTP requests still running after %s", shutdownTimeout)
+	}
+	for _, session := range sessions {
+		session.close(0)
+	}
+	log.Printf("Shutdown complete: closed %d sessions", len(sessions))
+	return nil
+}
+
+// listenAddress binds an address without a host, such as ":8080", to the loopback
+// interface; "0.0.0.0:8080" must be given to listen on every interface
+func listenAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// isLoopbackAddress reports whether addr only accepts connections from this machine
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireBearerToken answers 401 to requests without "Authorization: Bearer <token>",
+// or passes every request through when token is empty
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="reso-mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveMetrics writes the counters of every session as plain text
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics.WriteText(w)
+}
+
+// ServeHTTP dispatches POST (JSON-RPC), GET (event stream) and DELETE (end session)
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleEvents(w, r)
+	case http.MethodDelete:
+		if !t.closeSession(r.Header.Get(sessionHeader)) {
+			http.Error(w, "unknown or missing "+sessionHeader, http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost runs one JSON-RPC message through the session's server. initialize
+// without a session header starts a new session, which is closed again if
+// initialize fails.
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessageSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPMessageSize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var msg MCPMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, MCPMessage{
+			JSONRPC: "2.0",
+			Error: &MCPError{
+				Code:    -32700,
+				Message: fmt.Sprintf("Parse error: %s", err.Error()),
+			},
+		})
+		return
+	}
+
+	sessionID := r.Header.Get(sessionHeader)
+	created := msg.Method == "initialize" && sessionID == ""
+	var session *httpSession
+	if created {
+		sessionID, session, err = t.newSession()
+		if errors.Is(err, errTooManySessions) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if session = t.session(sessionID); session == nil {
+		http.Error(w, "unknown or missing "+sessionHeader+"; send initialize first", http.StatusNotFound)
+		return
+	}
+
+	response, ok := t.handleMessage(session, msg)
+	if !ok {
+		http.Error(w, "session is closed", http.StatusServiceUnavailable)
+		return
+	}
+	if created && response.Error != nil {
+		t.closeSession(sessionID)
+	} else {
+		w.Header().Set(sessionHeader, sessionID)
+	}
+	if response.JSONRPC == "" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleMessage runs msg through the session's server, reporting false if the
+// session has been closed. The session counts as in use until the reply is ready.
+func (t *httpTransport) handleMessage(session *httpSession, msg MCPMessage) (MCPMessage, bool) {
+	if !session.server.beginRequest() {
+		return MCPMessage{}, false
+	}
+	defer session.server.endRequest()
+	defer t.touch(session)
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	return session.server.HandleMessage(msg), true
+}
+
+// handleEvents streams the session's notifications as server-sent events until the
+// client disconnects or the session ends
+func (t *httpTransport) handleEvents(w http.ResponseWriter, r *http.Request) {
+	session := t.session(r.Header.Get(sessionHeader))
+	if session == nil {
+		http.Error(w, "unknown or missing "+sessionHeader, http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.closing:
+			return
+		case <-session.done:
+			return
+		case event := <-session.events:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+			flusher.Flush()
+		}
+	}
+}
+
+// newSession creates a session with its own server, or returns errTooManySessions
+// when maxSessions are already live
+func (t *httpTransport) newSession() (string, *httpSession, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to create session ID: %w", err)
+	}
+	id := hex.EncodeToString(idBytes)
+
+	session := &httpSession{
+		events:   make(chan []byte, sessionEventBuffer),
+		lastSeen: time.Now(),
+		done:     make(chan struct{}),
+	}
+	session.server = NewMCPServer()
+	session.server.out = session
+	if len(t.settings) > 0 {
+		session.server.pendingSettings = make(map[string]interface{}, len(t.settings))
+		for k, v := range t.settings {
+			session.server.pendingSettings[k] = v
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.sessions) >= t.maxSessions {
+		return "", nil, errTooManySessions
+	}
+	t.sessions[id] = session
+	return id, session, nil
+}
+
+// session returns the live session with the given ID, or nil
+func (t *httpTransport) session(id string) *httpSession {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	session, ok := t.sessions[id]
+	if !ok {
+		return nil
+	}
+	session.lastSeen = time.Now()
+	return session
+}
+
+// touch marks the session as used now
+func (t *httpTransport) touch(session *httpSession) {
+	t.mutex.Lock()
+	session.lastSeen = time.Now()
+	t.mutex.Unlock()
+}
+
+// closeSession removes the session with the given ID and closes it, reporting
+// whether it was live
+func (t *httpTransport) closeSession(id string) bool {
+	t.mutex.Lock()
+	session, ok := t.sessions[id]
+	delete(t.sessions, id)
+	t.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	session.close(shutdownTimeout)
+	return true
+}
+
+// reapIdleSessions closes idle sessions every sessionReapInterval until the
+// transport shuts down
+func (t *httpTransport) reapIdleSessions() {
+	ticker := time.NewTicker(sessionReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.closing:
+			return
+		case <-ticker.C:
+			if n := t.closeIdleSessions(sessionIdleTimeout); n > 0 {
+				log.Printf("Closed %d HTTP sessions idle for over %s", n, sessionIdleTimeout)
+			}
+		}
+	}
+}
+
+// closeIdleSessions closes every session unused for longer than idle and returns
+// how many were closed
+func (t *httpTransport) closeIdleSessions(idle time.Duration) int {
+	t.mutex.Lock()
+	var sessions []*httpSession
+	for id, session := range t.sessions {
+		if time.Since(session.lastSeen) > idle {
+			sessions = append(sessions, session)
+			delete(t.sessions, id)
+		}
+	}
+	t.mutex.Unlock()
+	for _, session := range sessions {
+		session.close(shutdownTimeout)
+	}
+	return len(sessions)
+}
+
+// writeJSON writes a JSON-RPC message as the HTTP response body
+func writeJSON(w http.ResponseWriter, status int, msg MCPMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		log.Printf("Error writing HTTP response: %v", err)
+	}
+}
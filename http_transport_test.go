@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const initializeMessage = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+// newTestHTTPServer serves an httpTransport whose sessions load metadata from the
+// local file rather than the API
+func newTestHTTPServer(t *testing.T, token string, settings map[string]interface{}) (*httptest.Server, *httpTransport) {
+	t.Helper()
+	merged := map[string]interface{}{"metadata_source": "file"}
+	for k, v := range settings {
+		merged[k] = v
+	}
+	transport := newHTTPTransport(merged)
+	server := httptest.NewServer(transport.handler(token))
+	t.Cleanup(func() {
+		server.Close()
+		close(transport.closing)
+		transport.closeIdleSessions(-time.Second)
+	})
+	return server, transport
+}
+
+// sendMCP sends one request to the /mcp endpoint, with the session and bearer
+// token headers set when they are not empty
+func sendMCP(t *testing.T, server *httptest.Server, method, token, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+mcpEndpoint, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, mcpEndpoint, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// decodeMCP decodes a JSON-RPC response body
+func decodeMCP(t *testing.T, resp *http.Response) MCPMessage {
+	t.Helper()
+	var msg MCPMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return msg
+}
+
+// serverClosed reports whether a session's server has been shut down
+func serverClosed(s *MCPServer) bool {
+	s.shutdownMutex.Lock()
+	defer s.shutdownMutex.Unlock()
+	return s.closing
+}
+
+func TestHTTPSessionLifecycle(t *testing.T) {
+	server, transport := newTestHTTPServer(t, "", nil)
+
+	resp := sendMCP(t, server, http.MethodPost, "", "", initializeMessage)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize status = %d, want 200", resp.StatusCode)
+	}
+	if msg := decodeMCP(t, resp); msg.Error != nil {
+		t.Fatalf("initialize error: %+v", msg.Error)
+	}
+	sessionID := resp.Header.Get(sessionHeader)
+	if sessionID == "" {
+		t.Fatal("initialize did not assign a session")
+	}
+	session := transport.session(sessionID)
+
+	resp = sendMCP(t, server, http.MethodPost, "", sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("tools/list status = %d, want 200", resp.StatusCode)
+	}
+	if msg := decodeMCP(t, resp); msg.Error != nil || msg.Result == nil {
+		t.Fatalf("tools/list = %+v, want a result", msg)
+	}
+
+	resp = sendMCP(t, server, http.MethodPost, "", sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("notification status = %d, want 202", resp.StatusCode)
+	}
+
+	resp = sendMCP(t, server, http.MethodDelete, "", sessionID, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want 204", resp.StatusCode)
+	}
+	if !serverClosed(session.server) {
+		t.Error("DELETE left the session's server running")
+	}
+	select {
+	case <-session.done:
+	default:
+		t.Error("DELETE left the session's event stream open")
+	}
+
+	resp = sendMCP(t, server, http.MethodPost, "", sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST after DELETE status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPUnknownSession(t *testing.T) {
+	server, _ := newTestHTTPServer(t, "", nil)
+
+	tests := []struct {
+		name      string
+		method    string
+		sessionID string
+		body      string
+	}{
+		{"POST without a session", http.MethodPost, "", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+		{"POST with an unknown session", http.MethodPost, "0123456789abcdef", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+		{"event stream for an unknown session", http.MethodGet, "0123456789abcdef", ""},
+		{"DELETE of an unknown session", http.MethodDelete, "0123456789abcdef", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := sendMCP(t, server, tt.method, "", tt.sessionID, tt.body)
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHTTPRequiresBearerToken(t *testing.T) {
+	server, transport := newTestHTTPServer(t, "secret", nil)
+
+	for _, token := range []string{"", "wrong"} {
+		resp := sendMCP(t, server, http.MethodPost, token, "", initializeMessage)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", token, resp.StatusCode)
+		}
+		if resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("token %q: 401 without a WWW-Authenticate header", token)
+		}
+	}
+	if n := len(transport.sessions); n != 0 {
+		t.Errorf("unauthorized requests created %d sessions", n)
+	}
+
+	resp := sendMCP(t, server, http.MethodPost, "secret", "", initializeMessage)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(sessionHeader) == "" {
+		t.Errorf("status = %d with the right token, want 200 and a session", resp.StatusCode)
+	}
+}
+
+func TestHTTPFailedInitializeDropsSession(t *testing.T) {
+	server, transport := newTestHTTPServer(t, "", map[string]interface{}{"require_credentials": true})
+
+	resp := sendMCP(t, server, http.MethodPost, "", "", initializeMessage)
+	if msg := decodeMCP(t, resp); msg.Error == nil {
+		t.Fatal("initialize without required credentials succeeded")
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.Errorf("failed initialize assigned session %q", id)
+	}
+	if n := len(transport.sessions); n != 0 {
+		t.Errorf("failed initialize left %d sessions", n)
+	}
+}
+
+func TestHTTPSessionLimit(t *testing.T) {
+	server, transport := newTestHTTPServer(t, "", nil)
+	transport.maxSessions = 1
+
+	if resp := sendMCP(t, server, http.MethodPost, "", "", initializeMessage); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first initialize status = %d, want 200", resp.StatusCode)
+	}
+	if resp := sendMCP(t, server, http.MethodPost, "", "", initializeMessage); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("initialize over the limit: status = %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestHTTPClosesIdleSessions(t *testing.T) {
+	server, transport := newTestHTTPServer(t, "", nil)
+
+	resp := sendMCP(t, server, http.MethodPost, "", "", initializeMessage)
+	session := transport.session(resp.Header.Get(sessionHeader))
+	if session == nil {
+		t.Fatal("initialize did not create a session")
+	}
+
+	if n := transport.closeIdleSessions(time.Hour); n != 0 {
+		t.Fatalf("closed %d active sessions", n)
+	}
+	transport.mutex.Lock()
+	session.lastSeen = time.Now().Add(-2 * time.Hour)
+	transport.mutex.Unlock()
+	if n := transport.closeIdleSessions(time.Hour); n != 1 {
+		t.Fatalf("closed %d idle sessions, want 1", n)
+	}
+	if !serverClosed(session.server) {
+		t.Error("reaping left the session's server running")
+	}
+}
@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Level is an MCP log level, ordered by severity as in RFC 5424
+type Level int
+
+// Log levels defined by the MCP logging capability
+const (
+	Debug Level = iota
+	Info
+	Notice
+	Warning
+	Error
+	Critical
+	Alert
+	Emergency
+)
+
+// levelNames are the MCP names of each level, indexed by Level
+var levelNames = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// maxPending caps the events queued before notifications are started
+const maxPending = 100
+
+// String returns the MCP name of the level
+func (l Level) String() string {
+	if l < Debug || l > Emergency {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel converts an MCP level name to a Level
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(name), levelName) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level %q (expected one of %s)", name, strings.Join(levelNames, ", "))
+}
+
+// Notifier delivers a log event to the MCP client as a notifications/message
+type Notifier func(level Level, logger string, message string)
+
+// Logger routes internal events to the MCP client at or above the client's chosen
+// level, and mirrors them to stderr. A nil Logger only writes to stderr.
+type Logger struct {
+	mutex   sync.Mutex
+	level   Level
+	notify  Notifier
+	started bool
+	pending []event
+}
+
+// event is a log event queued until notifications start
+type event struct {
+	level   Level
+	logger  string
+	message string
+}
+
+// New creates a logger that sends events at Info and above through notify once
+// Start is called
+func New(notify Notifier) *Logger {
+	return &Logger{
+		level:  Info,
+		notify: notify,
+	}
+}
+
+// SetLevel sets the minimum level sent to the client
+func (l *Logger) SetLevel(level Level) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.level = level
+}
+
+// Level returns the minimum level sent to the client
+func (l *Logger) Level() Level {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.level
+}
+
+// Start enables client notifications, first sending any events logged before the
+// client finished initializing
+func (l *Logger) Start() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.started {
+		return
+	}
+	l.started = true
+	for _, e := range l.pending {
+		if e.level >= l.level && l.notify != nil {
+			l.notify(e.level, e.logger, e.message)
+		}
+	}
+	l.pending = nil
+}
+
+// Logf records an event from the named component
+func (l *Logger) Logf(level Level, logger, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+
+	if l == nil {
+		if level >= Info {
+			log.Printf("[%s] %s: %s", level, logger, message)
+		}
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// Mirror to stderr whatever the client would see, and always Info and above
+	if level >= Info || level >= l.level {
+		log.Printf("[%s] %s: %s", level, logger, message)
+	}
+
+	if !l.started {
+		if len(l.pending) < maxPending {
+			l.pending = append(l.pending, event{level: level, logger: logger, message: message})
+		}
+		return
+	}
+	if level >= l.level && l.notify != nil {
+		l.notify(level, logger, message)
+	}
+}
+
+// Debugf records a debug event
+func (l *Logger) Debugf(logger, format string, args ...interface{}) {
+	l.Logf(Debug, logger, format, args...)
+}
+
+// Infof records an informational event
+func (l *Logger) Infof(logger, format string, args ...interface{}) {
+	l.Logf(Info, logger, format, args...)
+}
+
+// Warningf records a warning event
+func (l *Logger) Warningf(logger, format string, args ...interface{}) {
+	l.Logf(Warning, logger, format, args...)
+}
+
+// Errorf records an error event
+func (l *Logger) Errorf(logger, format string, args ...interface{}) {
+	l.Logf(Error, logger, format, args...)
+}
@@ -1,20 +1,29 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // IANA zones for RESO_TIMEZONE in images without a zoneinfo database
 
 	"github.com/rennietech/constellation1-mcp-server/api"
 	"github.com/rennietech/constellation1-mcp-server/auth"
 	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/logging"
 	"github.com/rennietech/constellation1-mcp-server/tools"
 )
 
+// serverVersion is reported in serverInfo and the default User-Agent
+const serverVersion = "1.0.0"
+
 // MCPMessage represents a message in the MCP protocol
 type MCPMessage struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -46,6 +55,18 @@ type InitializeResult struct {
 	ServerInfo      map[string]interface{} `json:"serverInfo"`
 }
 
+// SetLevelParams represents the parameters for the logging/setLevel method
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+// LogMessageParams represents the parameters of a notifications/message notification
+type LogMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
 // ListToolsResult represents the result of the tools/list method
 type ListToolsResult struct {
 	Tools []tools.MCPTool `json:"tools"`
@@ -55,6 +76,7 @@ type ListToolsResult struct {
 type CallToolParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
 }
 
 // MCPResource represents an MCP resource
@@ -87,53 +109,362 @@ type MCPResourceContent struct {
 	Text     string `json:"text,omitempty"`
 }
 
+// MCPPrompt represents an MCP prompt template
+type MCPPrompt struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument represents an argument accepted by a prompt template
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ListPromptsResult represents the result of the prompts/list method
+type ListPromptsResult struct {
+	Prompts []MCPPrompt `json:"prompts"`
+}
+
+// GetPromptParams represents the parameters for the prompts/get method
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// GetPromptResult represents the result of the prompts/get method
+type GetPromptResult struct {
+	Description string             `json:"description,omitempty"`
+	Messages    []MCPPromptMessage `json:"messages"`
+}
+
+// MCPPromptMessage represents a message returned by a prompt template
+type MCPPromptMessage struct {
+	Role    string           `json:"role"`
+	Content tools.MCPContent `json:"content"`
+}
+
 // MCPServer represents the MCP server
 type MCPServer struct {
 	config          *config.Config
 	apiClient       *api.Client
 	resoTool        *tools.ResoQueryTool
 	helpTool        *tools.ResoHelpTool
+	mergeTool       *tools.ResoMergeTool
+	compsTool       *tools.ResoComparablesTool
+	schemaTool      *tools.ResoSchemaTool
+	syncTool        *tools.ResoSyncTool
+	distinctTool    *tools.ResoDistinctTool
+	statusTool      *tools.ResoStatusTool
+	batchTool       *tools.ResoBatchTool
+	detailTool      *tools.ResoPropertyDetailTool
+	photosTool      *tools.ResoPhotosTool
+	enumLookupTool  *tools.ResoEnumLookupTool
+	openHousesTool  *tools.ResoOpenHousesTool
+	marketStatsTool *tools.ResoMarketStatsTool
+	presetsTool     *tools.ResoPresetsTool
+	diffTool        *tools.ResoDiffTool
 	pendingSettings map[string]interface{}
+	logger          *logging.Logger
+	inflight        sync.WaitGroup
+	shutdownMutex   sync.Mutex
+	closing         bool
+	active          int
+	connection      *ConnectionCheck
+	out             io.Writer
+	outMutex        sync.Mutex
+	transport       string
 }
 
 // NewMCPServer creates a new MCP server
 func NewMCPServer() *MCPServer {
-	return &MCPServer{
-		config: config.DefaultConfig(),
+	server := &MCPServer{
+		config:    config.DefaultConfig(),
+		out:       os.Stdout,
+		transport: transportLine,
+	}
+	server.logger = logging.New(server.sendLogMessage)
+	return server
+}
+
+// writeMessage writes one JSON-RPC message to the client; responses and
+// notifications from background work are serialized so lines never interleave
+func (s *MCPServer) writeMessage(msg MCPMessage) {
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error marshaling response: %v", err)
+		return
+	}
+
+	s.outMutex.Lock()
+	defer s.outMutex.Unlock()
+	if err := writeFrame(s.out, s.transport, messageBytes); err != nil {
+		log.Printf("Error writing message: %v", err)
 	}
 }
 
+// sendLogMessage delivers a log event to the client as notifications/message
+func (s *MCPServer) sendLogMessage(level logging.Level, logger string, message string) {
+	s.writeMessage(MCPMessage{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params: LogMessageParams{
+			Level:  level.String(),
+			Logger: logger,
+			Data:   message,
+		},
+	})
+}
+
 // Initialize initializes the MCP server with configuration
 func (s *MCPServer) Initialize(settings map[string]interface{}) error {
-	// Load configuration from settings
-	if err := s.config.LoadFromMCPSettings(settings); err != nil {
-		// Try loading from environment variables as fallback
+	// Load configuration from settings. A profile or credential file that cannot be
+	// read fails initialize rather than silently running with other credentials.
+	if settings == nil {
 		s.config.LoadFromEnv()
+	} else if err := s.config.LoadFromMCPSettings(settings); err != nil {
+		s.logger.Errorf("config", "Settings could not be applied: %v", err)
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Create OAuth client (even if credentials are not yet provided)
-	oauthClient := auth.NewOAuthClient(s.config.ClientID, s.config.ClientSecret, s.config.AuthURL)
+	// Deployments that treat missing credentials as a misconfiguration fail here
+	// rather than on the first tool call
+	if s.config.RequireCredentials {
+		if err := s.config.ValidateCredentials(); err != nil {
+			s.logger.Errorf("config", "Credentials are required but not configured: %v", err)
+			return fmt.Errorf("credentials are required: %w", err)
+		}
+	}
 
-	// Create API client
-	s.apiClient = api.NewClient(s.config.BaseURL, oauthClient)
+	// Create OAuth and API clients (even if credentials are not yet provided)
+	oauthClient, apiClient := s.newClients()
+	s.apiClient = apiClient
 
 	// Create tools
-	s.resoTool = tools.NewResoQueryTool(s.apiClient, s.config)
-	s.helpTool = tools.NewResoHelpToolWithAPI(s.apiClient)
+	metadataSources := s.config.MetadataSources
+	if len(metadataSources) == 0 {
+		metadataSources = config.DefaultMetadataSources
+	}
+	s.helpTool = tools.NewResoHelpToolWithSources(s.apiClient, metadataSources)
+	s.helpTool.SetFieldAliases(s.config.FieldAliases)
+	s.resoTool = tools.NewResoQueryToolWithMetadata(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.mergeTool = tools.NewResoMergeTool(s.apiClient, s.config)
+	s.compsTool = tools.NewResoComparablesTool(s.apiClient, s.config)
+	s.schemaTool = tools.NewResoSchemaTool(s.helpTool.GetMetadataParser())
+	s.syncTool = tools.NewResoSyncTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.distinctTool = tools.NewResoDistinctTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.statusTool = tools.NewResoStatusTool(s.apiClient, oauthClient, s.config, s.helpTool.GetMetadataParser())
+	s.batchTool = tools.NewResoBatchTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.detailTool = tools.NewResoPropertyDetailTool(s.apiClient, s.config)
+	s.photosTool = tools.NewResoPhotosTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.enumLookupTool = tools.NewResoEnumLookupTool(s.helpTool.GetMetadataParser())
+	s.openHousesTool = tools.NewResoOpenHousesTool(s.apiClient, s.config)
+	s.marketStatsTool = tools.NewResoMarketStatsTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.presetsTool = tools.NewResoPresetsTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+	s.diffTool = tools.NewResoDiffTool(s.apiClient, s.config, s.helpTool.GetMetadataParser())
+
+	if s.config.Timezone != "" {
+		if _, err := time.LoadLocation(s.config.Timezone); err != nil {
+			s.logger.Warningf("config", "Unknown timezone %q, showing timestamps in UTC", s.config.Timezone)
+		}
+	}
+	if entity := s.config.DefaultEntity; entity != "" && (!api.IsValidEntity(entity) || s.apiClient.CheckEntityAllowed(entity) != nil) {
+		s.logger.Warningf("config", "Default entity %q cannot be queried; reso_query calls without an entity will fail", entity)
+	}
+
+	if parser := s.helpTool.GetMetadataParser(); parser != nil {
+		parser.SetCategoryOverrides(s.config.FieldCategories)
+		s.logger.Infof("metadata", "Metadata loaded from %s: %d entities", s.helpTool.MetadataSource(), len(parser.GetEntityNames()))
+	} else {
+		s.logger.Warningf("metadata", "Metadata not loaded from any of %s; dynamic help and field validation are disabled", strings.Join(metadataSources, ", "))
+	}
+
+	if unknown := tools.UnknownFilterOperators(s.config.AllowedOperators); len(unknown) > 0 {
+		s.logger.Warningf("config", "allowed_operators lists unknown filter operators, which have no effect: %s", strings.Join(unknown, ", "))
+	}
 
-	// Don't test connection during initialization - defer until first tool call
-	// This allows the MCP server to start even if RESO API is temporarily unavailable
+	if s.config.PresetsFile != "" {
+		if presets, err := s.presetsTool.LoadPresets(); err != nil {
+			s.logger.Warningf("config", "Query presets unavailable: %v", err)
+		} else {
+			s.logger.Infof("config", "Loaded %d query preset(s) from %s", len(presets), s.config.PresetsFile)
+		}
+	}
+
+	// The connection is normally tested on the first tool call, so the server starts
+	// even if the RESO API is down. With eager_connect it is tested now and reported
+	// in the initialize result, but a failure still does not stop startup.
+	s.connection = nil
+	if s.config.EagerConnect {
+		s.connection = s.checkConnection(oauthClient)
+		if s.connection.Error != "" {
+			s.logger.Warningf("connection", "Connection test failed: %s", s.connection.Error)
+		} else {
+			s.logger.Infof("connection", "Connection test passed in %d ms", s.connection.LatencyMs)
+		}
+	}
 
 	return nil
 }
 
-// HandleMessage handles an incoming MCP message
+// ConnectionCheck is the result of the eager_connect test reported in serverInfo
+type ConnectionCheck struct {
+	Reachable     bool   `json:"reachable"`
+	Authenticated bool   `json:"authenticated"`
+	LatencyMs     int64  `json:"latencyMs"`
+	Error         string `json:"error,omitempty"`
+}
+
+// checkConnection authenticates and runs a one-record test query. Missing
+// credentials are reported as a failed check rather than attempted.
+func (s *MCPServer) checkConnection(oauthClient *auth.OAuthClient) *ConnectionCheck {
+	check := &ConnectionCheck{}
+	if err := s.config.ValidateCredentials(); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	start := time.Now()
+	defer func() { check.LatencyMs = time.Since(start).Milliseconds() }()
+
+	if _, err := oauthClient.GetToken(); err != nil {
+		check.Error = fmt.Sprintf("authentication failed: %v", err)
+		return check
+	}
+	check.Authenticated = true
+
+	if err := s.apiClient.TestConnection(); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Reachable = true
+	return check
+}
+
+// newClients creates the OAuth and API clients described by the server's config
+func (s *MCPServer) newClients() (*auth.OAuthClient, *api.Client) {
+	// Token and API requests share one connection pool
+	transport := api.NewTransport(api.TransportOptions{
+		MaxIdleConns:        s.config.MaxIdleConns,
+		MaxIdleConnsPerHost: s.config.MaxIdleConnsHost,
+		IdleConnTimeout:     s.config.IdleConnTimeout,
+	})
+
+	oauthClient := auth.NewOAuthClientWithOptions(s.config.ClientID, s.config.ClientSecret, s.config.AuthURL, auth.OAuthOptions{
+		Scope:         s.config.Scope,
+		Audience:      s.config.Audience,
+		GrantType:     s.config.GrantType,
+		Username:      s.config.Username,
+		Password:      s.config.Password,
+		RefreshToken:  s.config.RefreshToken,
+		RefreshBuffer: s.config.TokenRefresh,
+		Logger:        s.logger,
+		Transport:     transport,
+	})
+
+	userAgent := s.config.UserAgent
+	if userAgent == "" {
+		userAgent = "RESO-MCP-Server/" + serverVersion
+	}
+	var redactFields []string
+	if s.config.RedactPII {
+		redactFields = s.config.RedactFields
+		if len(redactFields) == 0 {
+			redactFields = api.DefaultRedactFields()
+		}
+	}
+	// The cap is process-wide; every session applies the same flag/environment value
+	api.SetMaxConcurrency(s.config.MaxConcurrency)
+
+	apiClient := api.NewClientWithOptions(s.config.BaseURL, oauthClient, api.ClientOptions{
+		Debug:               s.config.Debug,
+		Timeout:             s.config.HTTPTimeout,
+		CacheTTL:            s.config.CacheTTL,
+		RateLimit:           s.config.RateLimit,
+		UserAgent:           userAgent,
+		Host:                s.config.HostHeader,
+		Logger:              s.logger,
+		MetadataPath:        s.config.MetadataPath,
+		AllowedEntities:     s.config.AllowedEntities,
+		RedactFields:        redactFields,
+		DisableCount:        s.config.DisableCount,
+		RequestIDHeader:     s.config.RequestIDHeader,
+		Transport:           transport,
+		MaintenanceCooldown: s.config.MaintCooldown,
+	})
+	return oauthClient, apiClient
+}
+
+// HandleMessage handles an incoming MCP message. Notifications are processed but
+// never answered; an empty MCPMessage means nothing should be sent.
 func (s *MCPServer) HandleMessage(msg MCPMessage) MCPMessage {
+	// Replies to server-initiated requests need no answer
+	if msg.Method == "" && (msg.Result != nil || msg.Error != nil) {
+		return MCPMessage{}
+	}
+
+	response := s.dispatch(msg)
+
+	// The spec forbids responding to notifications, even for unknown methods
+	if isNotification(msg) {
+		if response.Error != nil {
+			s.logger.Debugf("mcp", "Ignoring notification %s: %s", msg.Method, response.Error.Message)
+		}
+		return MCPMessage{}
+	}
+	return response
+}
+
+// correlationMetaKeys are the tools/call _meta keys a client may use to pass its own
+// correlation ID, in order of preference
+var correlationMetaKeys = []string{"requestId", "correlationId"}
+
+// maxCorrelationIDLength bounds a client-supplied correlation ID
+const maxCorrelationIDLength = 128
+
+// correlationID returns the correlation ID a client sent in a tool call's _meta, or ""
+// when it sent none that is safe to use as a header value
+func correlationID(meta map[string]interface{}) string {
+	for _, key := range correlationMetaKeys {
+		id, _ := meta[key].(string)
+		id = strings.TrimSpace(id)
+		if id == "" || len(id) > maxCorrelationIDLength {
+			continue
+		}
+		printable := true
+		for _, r := range id {
+			if r <= ' ' || r > '~' {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return id
+		}
+	}
+	return ""
+}
+
+// isNotification reports whether msg is a JSON-RPC notification: it has no id, or
+// uses a notifications/ method
+func isNotification(msg MCPMessage) bool {
+	return msg.ID == nil || strings.HasPrefix(msg.Method, "notifications/")
+}
+
+// dispatch routes a message to its method handler
+func (s *MCPServer) dispatch(msg MCPMessage) MCPMessage {
 	switch msg.Method {
 	case "initialize":
 		return s.handleInitialize(msg)
-	case "initialized":
+	case "initialized", "notifications/initialized":
 		return s.handleInitialized(msg)
+	case "notifications/cancelled":
+		// Tool calls run synchronously, so there is nothing in flight to cancel
+		return MCPMessage{}
+	case "logging/setLevel":
+		return s.handleSetLevel(msg)
 	case "tools/list":
 		return s.handleToolsList(msg)
 	case "tools/call":
@@ -142,6 +473,10 @@ func (s *MCPServer) HandleMessage(msg MCPMessage) MCPMessage {
 		return s.handleResourcesList(msg)
 	case "resources/read":
 		return s.handleResourcesRead(msg)
+	case "prompts/list":
+		return s.handlePromptsList(msg)
+	case "prompts/get":
+		return s.handlePromptsGet(msg)
 	default:
 		return MCPMessage{
 			JSONRPC: "2.0",
@@ -163,51 +498,10 @@ func (s *MCPServer) handleInitialize(msg MCPMessage) MCPMessage {
 		}
 	}
 
-	// Start with pending settings from command line/environment
-	var settings map[string]interface{}
-	if s.pendingSettings != nil {
-		settings = make(map[string]interface{})
-		for k, v := range s.pendingSettings {
-			settings[k] = v
-		}
-	}
-
-	// Extract settings - MCP clients may pass settings in different ways
-	// Method 1: Check if settings are in capabilities
-	if clientCaps, ok := params.Capabilities["settings"].(map[string]interface{}); ok {
-		if settings == nil {
-			settings = make(map[string]interface{})
-		}
-		for k, v := range clientCaps {
-			settings[k] = v // JSON-RPC settings override env/args
-		}
-	}
-
-	// Method 2: Check if settings are directly in params (common for some MCP clients)
-	if rawParams, ok := msg.Params.(map[string]interface{}); ok {
-		if settingsData, exists := rawParams["settings"]; exists {
-			if settingsMap, ok := settingsData.(map[string]interface{}); ok {
-				if settings == nil {
-					settings = make(map[string]interface{})
-				}
-				for k, v := range settingsMap {
-					settings[k] = v // JSON-RPC settings override env/args
-				}
-			}
-		}
-	}
-
-	// Method 3: Check if the entire params contains client_id/client_secret directly
-	if rawParams, ok := msg.Params.(map[string]interface{}); ok {
-		if settings == nil {
-			settings = make(map[string]interface{})
-		}
-		if clientID, exists := rawParams["client_id"]; exists {
-			settings["client_id"] = clientID
-		}
-		if clientSecret, exists := rawParams["client_secret"]; exists {
-			settings["client_secret"] = clientSecret
-		}
+	// Merge the client's settings over the command line/environment defaults
+	settings, ignored := mergeInitializeSettings(s.pendingSettings, params, msg.Params)
+	if len(ignored) > 0 {
+		s.logger.Warningf("config", "Ignored settings that can only be set by flag or environment variable: %s", strings.Join(ignored, ", "))
 	}
 
 	// Initialize server with settings
@@ -232,16 +526,23 @@ func (s *MCPServer) handleInitialize(msg MCPMessage) MCPMessage {
 				"subscribe":   false,
 				"listChanged": false,
 			},
+			"prompts": map[string]interface{}{
+				"listChanged": false,
+			},
+			"logging": map[string]interface{}{},
 		},
 		ServerInfo: map[string]interface{}{
 			"name":        "constellation1-mcp-server",
-			"version":     "1.0.0",
+			"version":     serverVersion,
 			"description": "RESO (Real Estate Standards Organization) MCP Server providing comprehensive access to MLS data through the Constellation1 API. Features include property listings, agent information, office details, media files, and market analytics with advanced filtering, entity expansion, and privacy controls.",
 			"author":      "Rennie Technologies",
 			"homepage":    "https://github.com/rennietech/constellation1-mcp-server",
 			"license":     "MIT",
 		},
 	}
+	if s.connection != nil {
+		result.ServerInfo["connection"] = s.connection
+	}
 
 	return MCPMessage{
 		JSONRPC: "2.0",
@@ -252,10 +553,42 @@ func (s *MCPServer) handleInitialize(msg MCPMessage) MCPMessage {
 
 // handleInitialized handles the initialized notification
 func (s *MCPServer) handleInitialized(msg MCPMessage) MCPMessage {
+	// The client is ready, so log events queued during initialization can be sent
+	s.logger.Start()
+
 	// This is a notification, no response needed
 	return MCPMessage{}
 }
 
+// handleSetLevel handles the logging/setLevel method
+func (s *MCPServer) handleSetLevel(msg MCPMessage) MCPMessage {
+	var params SetLevelParams
+	if msg.Params != nil {
+		if paramsBytes, err := json.Marshal(msg.Params); err == nil {
+			json.Unmarshal(paramsBytes, &params)
+		}
+	}
+
+	level, err := logging.ParseLevel(params.Level)
+	if err != nil {
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid params: %s", err.Error()),
+			},
+		}
+	}
+	s.logger.SetLevel(level)
+
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
 // handleToolsList handles the tools/list method
 func (s *MCPServer) handleToolsList(msg MCPMessage) MCPMessage {
 	if s.resoTool == nil || s.helpTool == nil {
@@ -270,10 +603,7 @@ func (s *MCPServer) handleToolsList(msg MCPMessage) MCPMessage {
 	}
 
 	result := ListToolsResult{
-		Tools: []tools.MCPTool{
-			s.resoTool.GetToolDefinition(),
-			s.helpTool.GetToolDefinition(),
-		},
+		Tools: s.toolDefinitions(),
 	}
 
 	return MCPMessage{
@@ -283,6 +613,28 @@ func (s *MCPServer) handleToolsList(msg MCPMessage) MCPMessage {
 	}
 }
 
+// toolDefinitions returns the definitions of every registered tool
+func (s *MCPServer) toolDefinitions() []tools.MCPTool {
+	return []tools.MCPTool{
+		s.resoTool.GetToolDefinition(),
+		s.helpTool.GetToolDefinition(),
+		s.mergeTool.GetToolDefinition(),
+		s.compsTool.GetToolDefinition(),
+		s.schemaTool.GetToolDefinition(),
+		s.syncTool.GetToolDefinition(),
+		s.distinctTool.GetToolDefinition(),
+		s.statusTool.GetToolDefinition(),
+		s.batchTool.GetToolDefinition(),
+		s.detailTool.GetToolDefinition(),
+		s.photosTool.GetToolDefinition(),
+		s.enumLookupTool.GetToolDefinition(),
+		s.openHousesTool.GetToolDefinition(),
+		s.marketStatsTool.GetToolDefinition(),
+		s.presetsTool.GetToolDefinition(),
+		s.diffTool.GetToolDefinition(),
+	}
+}
+
 // handleToolsCall handles the tools/call method
 func (s *MCPServer) handleToolsCall(msg MCPMessage) MCPMessage {
 	if s.resoTool == nil {
@@ -312,6 +664,30 @@ func (s *MCPServer) handleToolsCall(msg MCPMessage) MCPMessage {
 		}
 	}
 
+	// Reject arguments that do not match the tool's input schema before running it
+	for _, definition := range s.toolDefinitions() {
+		if definition.Name != params.Name {
+			continue
+		}
+		if err := tools.ValidateArguments(definition.InputSchema, params.Arguments, definition.SelfChecked...); err != nil {
+			return MCPMessage{
+				JSONRPC: "2.0",
+				ID:      msg.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: fmt.Sprintf("Invalid params: %s", err.Error()),
+				},
+			}
+		}
+	}
+
+	// Send the caller's correlation ID with every API request this call makes. Calls
+	// on one server run one at a time, so the ID cannot leak into another call.
+	if requestID := correlationID(params.Meta); requestID != "" {
+		s.apiClient.SetCorrelationID(requestID)
+		defer s.apiClient.SetCorrelationID("")
+	}
+
 	switch params.Name {
 	case "reso_query":
 		result := s.resoTool.Execute(params.Arguments)
@@ -327,6 +703,104 @@ func (s *MCPServer) handleToolsCall(msg MCPMessage) MCPMessage {
 			ID:      msg.ID,
 			Result:  result,
 		}
+	case "reso_merge_raw":
+		result := s.mergeTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_comparables":
+		result := s.compsTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_schema":
+		result := s.schemaTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_sync":
+		result := s.syncTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_distinct":
+		result := s.distinctTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_status":
+		result := s.statusTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_batch":
+		result := s.batchTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_property_detail":
+		result := s.detailTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_photos":
+		result := s.photosTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_enum_lookup":
+		result := s.enumLookupTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_open_houses":
+		result := s.openHousesTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_market_stats":
+		result := s.marketStatsTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_presets":
+		result := s.presetsTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
+	case "reso_diff":
+		result := s.diffTool.Execute(params.Arguments)
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		}
 	default:
 		return MCPMessage{
 			JSONRPC: "2.0",
@@ -414,6 +888,202 @@ func (s *MCPServer) handleResourcesRead(msg MCPMessage) MCPMessage {
 	}
 }
 
+// getPrompts returns the prompt templates offered by the server
+func (s *MCPServer) getPrompts() []MCPPrompt {
+	return []MCPPrompt{
+		{
+			Name:        "find_active_listings",
+			Description: "Find active listings in a city under a maximum price",
+			Arguments: []MCPPromptArgument{
+				{Name: "city", Description: "City name, e.g. Seattle", Required: true},
+				{Name: "max_price", Description: "Maximum list price in dollars", Required: true},
+				{Name: "min_beds", Description: "Minimum number of bedrooms"},
+			},
+		},
+		{
+			Name:        "build_comps",
+			Description: "Build comparable sales for a property address",
+			Arguments: []MCPPromptArgument{
+				{Name: "address", Description: "Street address of the subject property", Required: true},
+			},
+		},
+		{
+			Name:        "agent_lookup",
+			Description: "Look up an agent and their office by name",
+			Arguments: []MCPPromptArgument{
+				{Name: "name", Description: "Agent's full or last name", Required: true},
+			},
+		},
+		{
+			Name:        "recent_sales",
+			Description: "Summarize recent closed sales in a city",
+			Arguments: []MCPPromptArgument{
+				{Name: "city", Description: "City name, e.g. Seattle", Required: true},
+				{Name: "days", Description: "Look-back window in days (default 30)"},
+			},
+		},
+	}
+}
+
+// handlePromptsList handles the prompts/list method
+func (s *MCPServer) handlePromptsList(msg MCPMessage) MCPMessage {
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result:  ListPromptsResult{Prompts: s.getPrompts()},
+	}
+}
+
+// handlePromptsGet handles the prompts/get method
+func (s *MCPServer) handlePromptsGet(msg MCPMessage) MCPMessage {
+	var params GetPromptParams
+	if msg.Params != nil {
+		if paramsBytes, err := json.Marshal(msg.Params); err == nil {
+			json.Unmarshal(paramsBytes, &params)
+		}
+	}
+
+	var prompt *MCPPrompt
+	for _, p := range s.getPrompts() {
+		if p.Name == params.Name {
+			prompt = &p
+			break
+		}
+	}
+	if prompt == nil {
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Prompt not found: %s", params.Name),
+			},
+		}
+	}
+
+	// Check required arguments
+	for _, arg := range prompt.Arguments {
+		if arg.Required && strings.TrimSpace(params.Arguments[arg.Name]) == "" {
+			return MCPMessage{
+				JSONRPC: "2.0",
+				ID:      msg.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: fmt.Sprintf("Missing required argument '%s' for prompt %s", arg.Name, prompt.Name),
+				},
+			}
+		}
+	}
+
+	text, err := renderPrompt(prompt.Name, params.Arguments)
+	if err != nil {
+		return MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid argument for prompt %s: %s", prompt.Name, err.Error()),
+			},
+		}
+	}
+
+	return MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result: GetPromptResult{
+			Description: prompt.Description,
+			Messages: []MCPPromptMessage{
+				{
+					Role:    "user",
+					Content: tools.MCPContent{Type: "text", Text: text},
+				},
+			},
+		},
+	}
+}
+
+// renderPrompt fills in a prompt template with guidance for calling reso_query.
+// Text arguments are quoted as OData string literals, and numeric arguments must
+// parse as numbers so no argument can change the filter's structure.
+func renderPrompt(name string, args map[string]string) (string, error) {
+	// Quote a value as an OData string literal
+	literal := func(key string) string {
+		return tools.QuoteODataString(strings.TrimSpace(args[key]))
+	}
+
+	switch name {
+	case "find_active_listings":
+		maxPrice, err := strconv.ParseFloat(strings.TrimSpace(args["max_price"]), 64)
+		if err != nil || maxPrice < 0 {
+			return "", fmt.Errorf("max_price must be a number, got %q", args["max_price"])
+		}
+		filter := fmt.Sprintf("StandardStatus eq 'Active' and City eq %s and ListPrice le %s", literal("city"), strconv.FormatFloat(maxPrice, 'f', -1, 64))
+		if beds := strings.TrimSpace(args["min_beds"]); beds != "" {
+			minBeds, err := strconv.Atoi(beds)
+			if err != nil || minBeds < 0 {
+				return "", fmt.Errorf("min_beds must be a whole number, got %q", args["min_beds"])
+			}
+			filter += fmt.Sprintf(" and BedroomsTotal ge %d", minBeds)
+		}
+		return fmt.Sprintf(`Find active listings in %s priced at or under $%s.
+
+Call the reso_query tool with:
+- entity: "Property"
+- filter: "%s"
+- select: "ListingKey,UnparsedAddress,City,ListPrice,BedroomsTotal,BathroomsTotalInteger,LivingArea,DaysOnMarket"
+- orderby: "ListPrice desc"
+- top: 25
+- ignorenulls: true
+
+Summarize the results as a table of address, price, beds, baths and square footage.`, args["city"], args["max_price"], filter), nil
+
+	case "build_comps":
+		return fmt.Sprintf(`Build comparable sales for the property at %s.
+
+1. Call reso_query with entity "Property", filter "startswith(UnparsedAddress,%s)", select "ListingKey,UnparsedAddress,City,BedroomsTotal,BathroomsTotalInteger,LivingArea,Latitude,Longitude" and top 1 to confirm the subject property.
+2. Call the reso_comparables tool with address "%s" (or the confirmed listing_key) to find similar closed sales nearby.
+3. Present the comparables with their close price, close date, distance and price per square foot, and suggest a value range for the subject.`, args["address"], literal("address"), args["address"]), nil
+
+	case "agent_lookup":
+		return fmt.Sprintf(`Look up the agent named %s.
+
+Call the reso_query tool with:
+- entity: "Member"
+- filter: "contains(MemberFullName,%s)"
+- select: "MemberMlsId,MemberFullName,MemberEmail,MemberDirectPhone,OfficeName,MemberStatus"
+- top: 10
+- ignorecase: true
+- ignorenulls: true
+
+If several agents match, list them and ask which one is meant.`, args["name"], literal("name")), nil
+
+	case "recent_sales":
+		days := 30
+		if value := strings.TrimSpace(args["days"]); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return "", fmt.Errorf("days must be a positive whole number, got %q", args["days"])
+			}
+			days = parsed
+		}
+		since := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+		return fmt.Sprintf(`Summarize closed sales in %s over the last %d days.
+
+Call the reso_query tool with:
+- entity: "Property"
+- filter: "StandardStatus eq 'Closed' and City eq %s and CloseDate ge %s"
+- select: "ListingKey,UnparsedAddress,ClosePrice,ListPrice,CloseDate,DaysOnMarket,LivingArea"
+- orderby: "CloseDate desc"
+- fetch_all: true
+- max_records: 500
+- ignorenulls: true
+
+Report the number of sales, the median close price, the average days on market and the average sale-to-list price ratio.`, args["city"], days, literal("city"), since), nil
+	}
+
+	return "", nil
+}
+
 // getFieldReferenceContent returns the complete RESO field reference guide
 func (s *MCPServer) getFieldReferenceContent() string {
 	// Use dynamic content from help tool if available
@@ -604,23 +1274,258 @@ func main() {
 	// Parse command line arguments
 	var clientID = flag.String("client-id", "", "RESO API Client ID")
 	var clientSecret = flag.String("client-secret", "", "RESO API Client Secret")
+	var debug = flag.Bool("debug", false, "Log every RESO API request to stderr")
+	var transport = flag.String("transport", transportLine, "stdio message framing: 'line' (newline-delimited JSON) or 'framed' (Content-Length headers)")
+	var check = flag.Bool("check", false, "Test the configured credentials against the API, print the result to stderr and exit")
+	var listen = flag.String("listen", "", "Serve MCP over HTTP on this address instead of stdio (e.g. :8080, which binds 127.0.0.1; use 0.0.0.0:8080 for every interface)")
+	var httpToken = flag.String("http-token", "", "Bearer token required on /mcp and /metrics in HTTP mode (or RESO_HTTP_TOKEN)")
+	var allowUnauthenticated = flag.Bool("allow-unauthenticated", false, "Allow -listen on a non-loopback address without -http-token")
+	var requireCredentials = flag.Bool("require-credentials", false, "Fail initialize when no client ID and secret are configured, instead of on the first tool call")
 	flag.Parse()
 
+	if *check {
+		os.Exit(runCheck(collectSettings(*clientID, *clientSecret, *debug, false)))
+	}
+
 	server := NewMCPServer()
-	scanner := bufio.NewScanner(os.Stdin)
+	reader, err := newMessageReader(*transport, os.Stdin)
+	if err != nil {
+		log.Fatalf("Invalid -transport: %v", err)
+	}
+	server.transport = *transport
 
 	log.Println("RESO MCP Server starting...")
 
 	// Store settings for later use but don't pre-initialize
 	// This avoids sending any messages before the MCP client is ready
+	envSettings := collectSettings(*clientID, *clientSecret, *debug, *requireCredentials)
+	warnMissingCredentials(envSettings)
+
+	// HTTP mode gives each session its own server, seeded with these settings
+	if *listen != "" {
+		token := *httpToken
+		if token == "" {
+			token = os.Getenv("RESO_HTTP_TOKEN")
+		}
+		if err := serveHTTP(*listen, token, *allowUnauthenticated, envSettings); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Store settings in server for use during initialization
+	if len(envSettings) > 0 {
+		log.Printf("Found settings from environment/args, will use during initialization")
+		// Store in server for later use
+		server.pendingSettings = envSettings
+	}
+
+	// On SIGINT/SIGTERM, let the message being handled finish and reply, then exit
+	go func() {
+		sig := <-shutdownSignals()
+		log.Printf("Received %s, shutting down", sig)
+		server.Shutdown(shutdownTimeout)
+		log.Println("Shutdown complete")
+		os.Exit(0)
+	}()
+
+	for {
+		payload, err := reader.ReadMessage()
+		if err == io.EOF {
+			log.Println("Input closed, shutting down")
+			break
+		}
+		if err != nil {
+			log.Printf("Error reading input: %v", err)
+			break
+		}
+
+		var msg MCPMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			log.Printf("Error parsing message: %v", err)
+			continue
+		}
+
+		if !server.beginRequest() {
+			continue
+		}
+		response := server.HandleMessage(msg)
+
+		// Only send response if it's not empty (for notifications)
+		if response.JSONRPC != "" {
+			server.writeMessage(response)
+		}
+		server.endRequest()
+	}
+
+	server.Shutdown(shutdownTimeout)
+	log.Println("Shutdown complete")
+}
+
+// serverOnlySettings may only come from flags and environment variables. A client
+// must not redirect the operator's credentials to another auth or API server, have
+// the server read arbitrary files as credentials, switch to another tenant's
+// credential profile, or widen the entity and operator allowlists.
+var serverOnlySettings = map[string]bool{
+	"auth_url":            true,
+	"base_url":            true,
+	"client_id_file":      true,
+	"client_secret_file":  true,
+	"require_credentials": true,
+	"max_concurrency":     true,
+	"presets_file":        true,
+	"allowed_entities":    true,
+	"allowed_operators":   true,
+	"metadata_path":       true,
+	"host_header":         true,
+	"profile":             true,
+}
+
+// clientLowerOnlySettings are operator limits a client may tighten but not loosen.
+// Zero turns each of them off, so a client may not send zero either.
+var clientLowerOnlySettings = map[string]bool{
+	"rate_limit":         true,
+	"max_top":            true,
+	"max_response_bytes": true,
+}
+
+// mergeInitializeSettings builds the settings applied at initialize. Later sources
+// override earlier ones:
+//
+//  1. defaults from flags and environment variables (collectSettings)
+//  2. params.capabilities.settings
+//  3. params.settings
+//  4. client_id and client_secret given directly in params
+//
+// Null and empty string values never override, so a client sending
+// "client_id": null keeps the configured credential. Server-only keys from the
+// client are dropped and returned as ignored, as are an attempt to turn redaction off
+// and a client value that would raise a clientLowerOnlySettings limit; client
+// redact_fields are added to the configured ones. The result is nil when no source
+// supplied anything, so the caller can fall back to the environment.
+func mergeInitializeSettings(defaults map[string]interface{}, params InitializeParams, rawParams interface{}) (map[string]interface{}, []string) {
+	var settings map[string]interface{}
+	var ignored []string
+
+	apply := func(source map[string]interface{}, fromClient bool) {
+		for _, key := range sortedSettingKeys(source) {
+			value := source[key]
+			if value == nil || value == "" {
+				continue
+			}
+			if fromClient && serverOnlySettings[key] {
+				ignored = append(ignored, key)
+				continue
+			}
+			if fromClient && (key == "redact_pii" || key == "redact_fields") {
+				merged, ok := addClientRedaction(settings, key, value)
+				if !ok {
+					ignored = append(ignored, key)
+					continue
+				}
+				value = merged
+			}
+			if fromClient && clientLowerOnlySettings[key] && !lowersClientLimit(settings, key, value) {
+				ignored = append(ignored, key)
+				continue
+			}
+			if settings == nil {
+				settings = make(map[string]interface{})
+			}
+			settings[key] = value
+		}
+	}
+
+	apply(defaults, false)
+	if clientCaps, ok := params.Capabilities["settings"].(map[string]interface{}); ok {
+		apply(clientCaps, true)
+	}
+	if raw, ok := rawParams.(map[string]interface{}); ok {
+		if settingsMap, ok := raw["settings"].(map[string]interface{}); ok {
+			apply(settingsMap, true)
+		}
+		apply(map[string]interface{}{
+			"client_id":     raw["client_id"],
+			"client_secret": raw["client_secret"],
+		}, true)
+	}
+
+	return settings, ignored
+}
+
+// addClientRedaction merges a client's redact_pii or redact_fields into the settings
+// so far. A client may turn redaction on and add fields, but never turn it off or
+// drop a field: redact_pii false is refused, and redact_fields is added to the
+// configured list, or to the default list when none is configured. The list only
+// takes effect with redact_pii on, which may be set before or after it.
+func addClientRedaction(settings map[string]interface{}, key string, value interface{}) (interface{}, bool) {
+	if key == "redact_pii" {
+		return true, config.SettingsBool(value)
+	}
+
+	fields := config.SettingsList(settings["redact_fields"])
+	if len(fields) == 0 {
+		fields = api.DefaultRedactFields()
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		seen[field] = true
+	}
+	for _, field := range config.SettingsList(value) {
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields, len(fields) > 0
+}
+
+// lowersClientLimit reports whether a client's value for a lower-only limit is
+// positive and no higher than the limit so far, which is the configured value or the
+// built-in default. A configured zero means no limit, so any positive value lowers it.
+func lowersClientLimit(settings map[string]interface{}, key string, value interface{}) bool {
+	limit, ok := config.SettingsFloat(value)
+	if !ok || limit <= 0 {
+		return false
+	}
+
+	current, ok := config.SettingsFloat(settings[key])
+	if !ok {
+		defaults := config.DefaultConfig()
+		switch key {
+		case "rate_limit":
+			current = defaults.RateLimit
+		case "max_top":
+			current = float64(defaults.MaxTop)
+		case "max_response_bytes":
+			current = float64(defaults.MaxResponseBytes)
+		}
+	}
+	return current <= 0 || limit <= current
+}
+
+// sortedSettingKeys returns the keys of a settings map in order, so ignored keys are
+// reported consistently
+func sortedSettingKeys(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectSettings gathers settings from command-line flags and environment
+// variables, to be applied when the client sends initialize
+func collectSettings(clientID, clientSecret string, debug, requireCredentials bool) map[string]interface{} {
 	envSettings := make(map[string]interface{})
 
 	// 1. Command line arguments (highest priority)
-	if *clientID != "" {
-		envSettings["client_id"] = *clientID
+	if clientID != "" {
+		envSettings["client_id"] = clientID
 	}
-	if *clientSecret != "" {
-		envSettings["client_secret"] = *clientSecret
+	if clientSecret != "" {
+		envSettings["client_secret"] = clientSecret
 	}
 
 	// 2. Standard environment variables
@@ -647,39 +1552,279 @@ func main() {
 		envSettings["client_secret"] = clientSecret
 	}
 
-	// Store settings in server for use during initialization
-	if len(envSettings) > 0 {
-		log.Printf("Found settings from environment/args, will use during initialization")
-		// Store in server for later use
-		server.pendingSettings = envSettings
+	// 5. Credentials mounted as files (CLIENT_ID_FILE/CLIENT_SECRET_FILE or their
+	// RESO_ forms), read at initialize when no value was given directly
+	for _, name := range []string{"CLIENT_ID_FILE", "RESO_CLIENT_ID_FILE"} {
+		if path := os.Getenv(name); path != "" && envSettings["client_id"] == nil && envSettings["client_id_file"] == nil {
+			envSettings["client_id_file"] = path
+		}
+	}
+	for _, name := range []string{"CLIENT_SECRET_FILE", "RESO_CLIENT_SECRET_FILE"} {
+		if path := os.Getenv(name); path != "" && envSettings["client_secret"] == nil && envSettings["client_secret_file"] == nil {
+			envSettings["client_secret_file"] = path
+		}
 	}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	// 6. Auth and API endpoints (RESO_AUTH_URL, RESO_BASE_URL)
+	if authURL := os.Getenv("RESO_AUTH_URL"); authURL != "" {
+		envSettings["auth_url"] = authURL
+	}
+	if baseURL := os.Getenv("RESO_BASE_URL"); baseURL != "" {
+		envSettings["base_url"] = baseURL
+	}
+
+	// 7. Debug logging (flag or RESO_DEBUG)
+	if debug {
+		envSettings["debug"] = true
+	} else if debugEnv, err := strconv.ParseBool(os.Getenv("RESO_DEBUG")); err == nil {
+		envSettings["debug"] = debugEnv
+	}
+
+	// 8. HTTP timeout (RESO_HTTP_TIMEOUT, e.g. "15s" or "15")
+	if timeout := os.Getenv("RESO_HTTP_TIMEOUT"); timeout != "" {
+		envSettings["http_timeout"] = timeout
+	}
+
+	// 9. Query cache TTL (RESO_QUERY_CACHE_TTL, "0" disables)
+	if ttl := os.Getenv("RESO_QUERY_CACHE_TTL"); ttl != "" {
+		envSettings["query_cache_ttl"] = ttl
+	}
+
+	// 10. Rate limit (RESO_RATE_LIMIT requests per second, "0" disables)
+	if rps := os.Getenv("RESO_RATE_LIMIT"); rps != "" {
+		envSettings["rate_limit"] = rps
+	}
+
+	// 11. OAuth scope, audience and grant type, with the password and refresh_token
+	// grants' credentials
+	if scope := os.Getenv("RESO_OAUTH_SCOPE"); scope != "" {
+		envSettings["scope"] = scope
+	}
+	if audience := os.Getenv("RESO_OAUTH_AUDIENCE"); audience != "" {
+		envSettings["audience"] = audience
+	}
+	if grantType := os.Getenv("RESO_OAUTH_GRANT_TYPE"); grantType != "" {
+		envSettings["grant_type"] = grantType
+	}
+	if username := os.Getenv("RESO_OAUTH_USERNAME"); username != "" {
+		envSettings["username"] = username
+	}
+	if password := os.Getenv("RESO_OAUTH_PASSWORD"); password != "" {
+		envSettings["password"] = password
+	}
+	if refreshToken := os.Getenv("RESO_OAUTH_REFRESH_TOKEN"); refreshToken != "" {
+		envSettings["refresh_token"] = refreshToken
+	}
+
+	// 12. Proactive token refresh buffer (RESO_TOKEN_REFRESH_BUFFER, e.g. "2m")
+	if buffer := os.Getenv("RESO_TOKEN_REFRESH_BUFFER"); buffer != "" {
+		envSettings["token_refresh_buffer"] = buffer
+	}
+
+	// 13. User-Agent, Host and request ID header overrides
+	if userAgent := os.Getenv("RESO_USER_AGENT"); userAgent != "" {
+		envSettings["user_agent"] = userAgent
+	}
+	if host := os.Getenv("RESO_HOST_HEADER"); host != "" {
+		envSettings["host_header"] = host
+	}
+	if header := os.Getenv("RESO_REQUEST_ID_HEADER"); header != "" {
+		envSettings["request_id_header"] = header
+	}
+
+	// 14. Default and maximum top for reso_query
+	if top := os.Getenv("RESO_DEFAULT_TOP"); top != "" {
+		envSettings["default_top"] = top
+	}
+	if top := os.Getenv("RESO_MAX_TOP"); top != "" {
+		envSettings["max_top"] = top
+	}
+
+	// 15. $metadata location relative to the service root (RESO_METADATA_PATH)
+	if metadataPath := os.Getenv("RESO_METADATA_PATH"); metadataPath != "" {
+		envSettings["metadata_path"] = metadataPath
+	}
+
+	// 16. Fields guide category overrides (RESO_FIELD_CATEGORIES)
+	if categories := os.Getenv("RESO_FIELD_CATEGORIES"); categories != "" {
+		if _, err := config.ParseFieldCategories(categories); err != nil {
+			log.Printf("Ignoring RESO_FIELD_CATEGORIES: %v", err)
+		} else {
+			envSettings["field_categories"] = categories
 		}
+	}
 
-		var msg MCPMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			log.Printf("Error parsing message: %v", err)
-			continue
+	// 17. Response size limit for reso_query (RESO_MAX_RESPONSE_BYTES, "0" disables)
+	if size := os.Getenv("RESO_MAX_RESPONSE_BYTES"); size != "" {
+		envSettings["max_response_bytes"] = size
+	}
+
+	// 18. Entities agents may query (RESO_ALLOWED_ENTITIES, comma-separated)
+	if entities := os.Getenv("RESO_ALLOWED_ENTITIES"); entities != "" {
+		envSettings["allowed_entities"] = entities
+	}
+
+	// 19. PII redaction (RESO_REDACT_PII, with an optional RESO_REDACT_FIELDS list)
+	if redact := os.Getenv("RESO_REDACT_PII"); redact != "" {
+		envSettings["redact_pii"] = redact
+	}
+	if fields := os.Getenv("RESO_REDACT_FIELDS"); fields != "" {
+		envSettings["redact_fields"] = fields
+	}
+
+	// 20. Opt out of $count=true (RESO_DISABLE_COUNT)
+	if disable := os.Getenv("RESO_DISABLE_COUNT"); disable != "" {
+		envSettings["disable_count"] = disable
+	}
+
+	// 21. Select each entity's common fields when a query gives none (RESO_AUTO_SELECT)
+	if autoSelect := os.Getenv("RESO_AUTO_SELECT"); autoSelect != "" {
+		envSettings["auto_select"] = autoSelect
+	}
+
+	// 22. Connection pool tuning (RESO_MAX_IDLE_CONNS, RESO_MAX_IDLE_CONNS_PER_HOST,
+	// RESO_IDLE_CONN_TIMEOUT)
+	if conns := os.Getenv("RESO_MAX_IDLE_CONNS"); conns != "" {
+		envSettings["max_idle_conns"] = conns
+	}
+	if conns := os.Getenv("RESO_MAX_IDLE_CONNS_PER_HOST"); conns != "" {
+		envSettings["max_idle_conns_per_host"] = conns
+	}
+	if timeout := os.Getenv("RESO_IDLE_CONN_TIMEOUT"); timeout != "" {
+		envSettings["idle_conn_timeout"] = timeout
+	}
+
+	// 23. Time zone for timestamps in summaries (RESO_TIMEZONE, an IANA name)
+	if timezone := os.Getenv("RESO_TIMEZONE"); timezone != "" {
+		envSettings["timezone"] = timezone
+	}
+
+	// 24. Fail initialize without credentials (-require-credentials or RESO_REQUIRE_CREDENTIALS)
+	if requireCredentials {
+		envSettings["require_credentials"] = true
+	} else if require, err := strconv.ParseBool(os.Getenv("RESO_REQUIRE_CREDENTIALS")); err == nil {
+		envSettings["require_credentials"] = require
+	}
+
+	// 25. Deepest nested $expand the provider accepts (RESO_MAX_EXPAND_DEPTH, "0" for any)
+	if depth := os.Getenv("RESO_MAX_EXPAND_DEPTH"); depth != "" {
+		envSettings["max_expand_depth"] = depth
+	}
+
+	// 26. Queries allowed upstream at once (RESO_MAX_CONCURRENCY, "0" for no cap)
+	if limit := os.Getenv("RESO_MAX_CONCURRENCY"); limit != "" {
+		envSettings["max_concurrency"] = limit
+	}
+
+	// 27. JSON file of named reso_presets queries (RESO_PRESETS_FILE)
+	if presetsFile := os.Getenv("RESO_PRESETS_FILE"); presetsFile != "" {
+		envSettings["presets_file"] = presetsFile
+	}
+
+	// 28. Opt out of the default key-based sort (RESO_DISABLE_AUTO_ORDERBY)
+	if disable := os.Getenv("RESO_DISABLE_AUTO_ORDERBY"); disable != "" {
+		envSettings["disable_auto_orderby"] = disable
+	}
+
+	// 29. Order metadata sources are tried in (RESO_METADATA_SOURCE, e.g. "api,cache,file")
+	if sources := os.Getenv("RESO_METADATA_SOURCE"); sources != "" {
+		if _, err := config.ParseMetadataSources(sources); err != nil {
+			log.Printf("Ignoring RESO_METADATA_SOURCE: %v", err)
+		} else {
+			envSettings["metadata_source"] = sources
 		}
+	}
 
-		response := server.HandleMessage(msg)
+	// 30. Filter operators and functions queries may use (RESO_ALLOWED_OPERATORS,
+	// comma-separated; unset allows all)
+	if operators := os.Getenv("RESO_ALLOWED_OPERATORS"); operators != "" {
+		envSettings["allowed_operators"] = operators
+	}
 
-		// Only send response if it's not empty (for notifications)
-		if response.JSONRPC != "" {
-			responseBytes, err := json.Marshal(response)
-			if err != nil {
-				log.Printf("Error marshaling response: %v", err)
-				continue
-			}
-			fmt.Println(string(responseBytes))
+	// 31. Test the connection during initialize (RESO_EAGER_CONNECT)
+	if eager := os.Getenv("RESO_EAGER_CONNECT"); eager != "" {
+		envSettings["eager_connect"] = eager
+	}
+
+	// 32. Friendly field aliases layered over the built-in ones (RESO_FIELD_ALIASES)
+	if aliases := os.Getenv("RESO_FIELD_ALIASES"); aliases != "" {
+		if _, err := config.ParseFieldAliases(aliases); err != nil {
+			log.Printf("Ignoring RESO_FIELD_ALIASES: %v", err)
+		} else {
+			envSettings["field_aliases"] = aliases
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading input: %v", err)
+	// 33. Default $top for expanded collections that set none (RESO_EXPAND_TOP, "0" for no cap)
+	if top := os.Getenv("RESO_EXPAND_TOP"); top != "" {
+		envSettings["expand_top"] = top
+	}
+
+	// 34. Pause after repeated 503s (RESO_MAINTENANCE_COOLDOWN, e.g. "60s", "0" disables)
+	if cooldown := os.Getenv("RESO_MAINTENANCE_COOLDOWN"); cooldown != "" {
+		envSettings["maintenance_cooldown"] = cooldown
+	}
+
+	// 35. Entity reso_query uses when none is given (RESO_DEFAULT_ENTITY, e.g. "Property")
+	if entity := os.Getenv("RESO_DEFAULT_ENTITY"); entity != "" {
+		envSettings["default_entity"] = entity
+	}
+	return envSettings
+}
+
+// warnMissingCredentials logs at startup when credentials are required but the flags
+// and environment do not provide them, so only a client sending them at initialize
+// can still start a session
+func warnMissingCredentials(settings map[string]interface{}) {
+	startup := config.DefaultConfig()
+	if settings == nil {
+		startup.LoadFromEnv()
+	} else if err := startup.LoadFromMCPSettings(settings); err != nil {
+		log.Printf("Warning: invalid configuration (%v); initialize will fail until it is fixed", err)
+		return
+	}
+	if !startup.RequireCredentials {
+		return
+	}
+	if err := startup.ValidateCredentials(); err != nil {
+		log.Printf("Warning: credentials are required but not configured (%v); initialize will fail unless the client sends client_id and client_secret", err)
+	}
+}
+
+// runCheck authenticates and runs a one-record test query with the given settings,
+// reporting the outcome and timings on stderr. It returns the process exit code.
+func runCheck(settings map[string]interface{}) int {
+	server := NewMCPServer()
+	if settings == nil {
+		server.config.LoadFromEnv()
+	} else if err := server.config.LoadFromMCPSettings(settings); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: invalid configuration: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Checking connection to %s\n", server.config.BaseURL)
+	if err := server.config.ValidateCredentials(); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		return 1
+	}
+
+	oauthClient, apiClient := server.newClients()
+
+	start := time.Now()
+	if _, err := oauthClient.GetToken(); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: authentication failed after %s: %v\n", time.Since(start).Round(time.Millisecond), err)
+		return 1
 	}
+	authTime := time.Since(start)
+
+	queryStart := time.Now()
+	if err := apiClient.TestConnection(); err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: %v (after %s)\n", err, time.Since(queryStart).Round(time.Millisecond))
+		return 1
+	}
+	queryTime := time.Since(queryStart)
+
+	fmt.Fprintf(os.Stderr, "PASS: authenticated in %s, test query in %s\n",
+		authTime.Round(time.Millisecond), queryTime.Round(time.Millisecond))
+	return 0
 }
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeInitializeSettingsPrecedence(t *testing.T) {
+	defaults := map[string]interface{}{
+		"client_id": "env-id",
+		"base_url":  "https://api.example.com/odata",
+		"max_top":   "100",
+		"log_level": "info",
+		"cache_ttl": "5m",
+	}
+	params := InitializeParams{Capabilities: map[string]interface{}{
+		"settings": map[string]interface{}{
+			"max_top":   "50",
+			"log_level": "debug",
+			"timeout":   "10s",
+		},
+	}}
+	rawParams := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"log_level": "warn",
+			"client_id": "settings-id",
+			"cache_ttl": "",
+		},
+		"client_id":     "direct-id",
+		"client_secret": nil,
+	}
+
+	settings, ignored := mergeInitializeSettings(defaults, params, rawParams)
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"base_url", "https://api.example.com/odata"}, // defaults only
+		{"cache_ttl", "5m"},                           // empty client value is skipped
+		{"timeout", "10s"},                            // capabilities.settings only
+		{"max_top", "50"},                             // capabilities.settings over defaults
+		{"log_level", "warn"},                         // params.settings over capabilities.settings
+		{"client_id", "direct-id"},                    // direct client_id over params.settings
+	}
+	for _, tt := range tests {
+		if got := settings[tt.key]; got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if _, ok := settings["client_secret"]; ok {
+		t.Errorf("client_secret = %v, want a null value skipped", settings["client_secret"])
+	}
+	if len(ignored) != 0 {
+		t.Errorf("ignored = %v, want none", ignored)
+	}
+}
+
+func TestMergeInitializeSettingsEmpty(t *testing.T) {
+	settings, ignored := mergeInitializeSettings(nil, InitializeParams{}, map[string]interface{}{"client_id": ""})
+	if settings != nil || ignored != nil {
+		t.Errorf("merge = %v, %v, want nil so the caller falls back to the environment", settings, ignored)
+	}
+}
+
+func TestMergeInitializeSettingsServerOnly(t *testing.T) {
+	defaults := map[string]interface{}{
+		"base_url":         "https://api.example.com/odata",
+		"auth_url":         "https://auth.example.com/token",
+		"allowed_entities": "Property",
+	}
+	params := InitializeParams{Capabilities: map[string]interface{}{
+		"settings": map[string]interface{}{
+			"base_url":          "https://attacker.example/odata",
+			"allowed_operators": "eq,ne,gt,lt,contains",
+		},
+	}}
+	rawParams := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"allowed_entities":   "Property,Member",
+			"auth_url":           "https://attacker.example/token",
+			"client_secret_file": "/etc/shadow",
+			"max_concurrency":    "100",
+			"metadata_path":      "//attacker.example/$metadata",
+			"host_header":        "attacker.example",
+			"profile":            "other-tenant",
+		},
+	}
+
+	settings, ignored := mergeInitializeSettings(defaults, params, rawParams)
+
+	if settings["base_url"] != "https://api.example.com/odata" {
+		t.Errorf("base_url = %v, want the server's value", settings["base_url"])
+	}
+	if settings["auth_url"] != "https://auth.example.com/token" {
+		t.Errorf("auth_url = %v, want the server's value", settings["auth_url"])
+	}
+	if settings["allowed_entities"] != "Property" {
+		t.Errorf("allowed_entities = %v, want the server's value", settings["allowed_entities"])
+	}
+	for _, key := range []string{"allowed_operators", "client_secret_file", "host_header", "max_concurrency", "metadata_path", "profile"} {
+		if _, ok := settings[key]; ok {
+			t.Errorf("%s = %v, want the client's value ignored", key, settings[key])
+		}
+	}
+	want := []string{"allowed_operators", "base_url", "allowed_entities", "auth_url", "client_secret_file", "host_header", "max_concurrency", "metadata_path", "profile"}
+	if !reflect.DeepEqual(ignored, want) {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+}
+
+func TestMergeInitializeSettingsRedactionOnlyAdds(t *testing.T) {
+	defaults := map[string]interface{}{
+		"redact_pii":    "true",
+		"redact_fields": "ListAgentEmail,ListAgentDirectPhone",
+	}
+	rawParams := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"redact_pii":    false,
+			"redact_fields": []interface{}{"ListAgentDirectPhone", "OwnerName"},
+		},
+	}
+
+	settings, ignored := mergeInitializeSettings(defaults, InitializeParams{}, rawParams)
+
+	if settings["redact_pii"] != "true" {
+		t.Errorf("redact_pii = %v, want the server's true kept", settings["redact_pii"])
+	}
+	wantFields := []string{"ListAgentEmail", "ListAgentDirectPhone", "OwnerName"}
+	if !reflect.DeepEqual(settings["redact_fields"], wantFields) {
+		t.Errorf("redact_fields = %v, want %v", settings["redact_fields"], wantFields)
+	}
+	if !reflect.DeepEqual(ignored, []string{"redact_pii"}) {
+		t.Errorf("ignored = %v, want [redact_pii]", ignored)
+	}
+}
+
+func TestMergeInitializeSettingsClientEnablesRedaction(t *testing.T) {
+	rawParams := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"redact_pii":    true,
+			"redact_fields": "OwnerName",
+		},
+	}
+
+	settings, ignored := mergeInitializeSettings(nil, InitializeParams{}, rawParams)
+
+	if settings["redact_pii"] != true {
+		t.Errorf("redact_pii = %v, want the client's true applied", settings["redact_pii"])
+	}
+	fields, _ := settings["redact_fields"].([]string)
+	if len(fields) < 2 || fields[len(fields)-1] != "OwnerName" {
+		t.Errorf("redact_fields = %v, want the default fields plus OwnerName", settings["redact_fields"])
+	}
+	if len(ignored) != 0 {
+		t.Errorf("ignored = %v, want none", ignored)
+	}
+}
+
+func TestMergeInitializeSettingsRateLimitOnlyLowers(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaults    map[string]interface{}
+		client      interface{}
+		want        interface{}
+		wantIgnored []string
+	}{
+		{"lower rate applies", map[string]interface{}{"rate_limit": "5"}, 2.0, 2.0, nil},
+		{"higher rate is ignored", map[string]interface{}{"rate_limit": "5"}, 50.0, "5", []string{"rate_limit"}},
+		{"zero cannot turn the limiter off", map[string]interface{}{"rate_limit": "5"}, 0.0, "5", []string{"rate_limit"}},
+		{"unparseable rate is ignored", map[string]interface{}{"rate_limit": "5"}, "fast", "5", []string{"rate_limit"}},
+		{"default rate is the limit when unset", nil, 50.0, nil, []string{"rate_limit"}},
+		{"any rate lowers an unlimited server", map[string]interface{}{"rate_limit": "0"}, 20.0, 20.0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawParams := map[string]interface{}{"settings": map[string]interface{}{"rate_limit": tt.client}}
+			settings, ignored := mergeInitializeSettings(tt.defaults, InitializeParams{}, rawParams)
+			if got := settings["rate_limit"]; got != tt.want {
+				t.Errorf("rate_limit = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(ignored, tt.wantIgnored) {
+				t.Errorf("ignored = %v, want %v", ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestMergeInitializeSettingsResponseLimitsOnlyLower(t *testing.T) {
+	defaults := map[string]interface{}{
+		"max_top": "200",
+	}
+	tests := []struct {
+		name        string
+		client      map[string]interface{}
+		want        map[string]interface{}
+		wantIgnored []string
+	}{
+		{
+			name:   "lower values apply",
+			client: map[string]interface{}{"max_top": "50", "max_response_bytes": 65536.0},
+			want:   map[string]interface{}{"max_top": "50", "max_response_bytes": 65536.0},
+		},
+		{
+			name:        "higher values are ignored",
+			client:      map[string]interface{}{"max_top": 5000.0, "max_response_bytes": 10485760.0},
+			want:        map[string]interface{}{"max_top": "200", "max_response_bytes": nil},
+			wantIgnored: []string{"max_response_bytes", "max_top"},
+		},
+		{
+			name:        "zero cannot turn a limit off",
+			client:      map[string]interface{}{"max_top": "0", "max_response_bytes": 0.0},
+			want:        map[string]interface{}{"max_top": "200", "max_response_bytes": nil},
+			wantIgnored: []string{"max_response_bytes", "max_top"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawParams := map[string]interface{}{"settings": tt.client}
+			settings, ignored := mergeInitializeSettings(defaults, InitializeParams{}, rawParams)
+			for key, want := range tt.want {
+				if got := settings[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			if !reflect.DeepEqual(ignored, tt.wantIgnored) {
+				t.Errorf("ignored = %v, want %v", ignored, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestMergeInitializeSettingsUnlimitedServerLimit(t *testing.T) {
+	defaults := map[string]interface{}{"max_response_bytes": "0"}
+	rawParams := map[string]interface{}{"settings": map[string]interface{}{"max_response_bytes": 4096.0}}
+
+	settings, ignored := mergeInitializeSettings(defaults, InitializeParams{}, rawParams)
+	if settings["max_response_bytes"] != 4096.0 || len(ignored) != 0 {
+		t.Errorf("max_response_bytes = %v, ignored = %v, want a client limit applied when the operator set none", settings["max_response_bytes"], ignored)
+	}
+}
+
+func TestInitializeProfileErrors(t *testing.T) {
+	dir := t.TempDir()
+	profiles := filepath.Join(dir, "profiles.json")
+	if err := os.WriteFile(profiles, []byte(`{"profiles":{"seattle":{"client_id":"sea-id","client_secret":"sea-secret"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"profiles":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		configFile string
+		profile    string
+		wantErr    string
+	}{
+		{"missing config file", filepath.Join(dir, "missing.json"), "", "failed to read config file"},
+		{"malformed config file", malformed, "", "failed to parse config file"},
+		{"unknown profile", profiles, "portland", `profile "portland" not found`},
+		{"known profile", profiles, "seattle", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESO_CONFIG_FILE", tt.configFile)
+			t.Setenv("CLIENT_PROFILE", tt.profile)
+
+			server := NewMCPServer()
+			err := server.Initialize(map[string]interface{}{"metadata_source": "file"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Initialize: %v", err)
+				}
+				if server.config.ClientID != "sea-id" {
+					t.Errorf("ClientID = %q, want the profile's", server.config.ClientID)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Initialize error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPromptEscapesArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompt   string
+		args     map[string]string
+		want     string
+		wantErr  string
+		unwanted string
+	}{
+		{
+			name:   "quoted city",
+			prompt: "find_active_listings",
+			args:   map[string]string{"city": "Coeur d'Alene", "max_price": "500000", "min_beds": "3"},
+			want:   "StandardStatus eq 'Active' and City eq 'Coeur d''Alene' and ListPrice le 500000 and BedroomsTotal ge 3",
+		},
+		{
+			name:     "city cannot close the literal",
+			prompt:   "recent_sales",
+			args:     map[string]string{"city": "x' or City ne 'y"},
+			want:     "City eq 'x'' or City ne ''y'",
+			unwanted: "City eq 'x' or",
+		},
+		{
+			name:    "max_price must be a number",
+			prompt:  "find_active_listings",
+			args:    map[string]string{"city": "Seattle", "max_price": "1 or true"},
+			wantErr: "max_price must be a number",
+		},
+		{
+			name:    "min_beds must be a whole number",
+			prompt:  "find_active_listings",
+			args:    map[string]string{"city": "Seattle", "max_price": "900000", "min_beds": "2 or ListPrice gt 0"},
+			wantErr: "min_beds must be a whole number",
+		},
+		{
+			name:    "days must be positive",
+			prompt:  "recent_sales",
+			args:    map[string]string{"city": "Seattle", "days": "-5"},
+			wantErr: "days must be a positive whole number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := renderPrompt(tt.prompt, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderPrompt: %v", err)
+			}
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("prompt does not contain %q:\n%s", tt.want, text)
+			}
+			if tt.unwanted != "" && strings.Contains(text, tt.unwanted) {
+				t.Errorf("prompt contains %q:\n%s", tt.unwanted, text)
+			}
+		})
+	}
+}
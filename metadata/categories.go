@@ -0,0 +1,110 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+)
+
+// otherCategory holds fields that match no override or rule
+const otherCategory = "Other"
+
+// categoryRule assigns a category to fields whose lowercased name contains any of
+// the given substrings or ends with any of the given suffixes
+type categoryRule struct {
+	Category string
+	Contains []string
+	Suffixes []string
+}
+
+// categoryRules are the built-in field heuristics in precedence order. When a field
+// matches several rules, the first one wins, so more specific patterns (such as
+// "mlsarea" for location) must come before broader ones ("area" for sizes).
+var categoryRules = []categoryRule{
+	{Category: "Identification", Contains: []string{"key", "id", "mlsid"}},
+	{Category: "Address & Location", Contains: []string{"street", "city", "state", "postal", "address", "latitude", "longitude", "mlsarea"}},
+	{Category: "Pricing & Financial", Contains: []string{"price", "tax", "cost", "expense", "fee", "income"}},
+	{Category: "Property Details", Contains: []string{"bedroom", "bathroom", "room", "area", "year", "built", "stories", "lot"}},
+	{Category: "Agent & Office Info", Contains: []string{"agent", "office", "member", "broker"}},
+	{Category: "Status & Dates", Contains: []string{"status", "timestamp", "date", "time", "market", "modification"}},
+	{Category: "Features & Amenities", Contains: []string{"feature", "amenity", "appliance", "heating", "cooling", "parking", "pool", "garage", "fireplace"}, Suffixes: []string{"yn"}},
+	{Category: "Media & Marketing", Contains: []string{"media", "photo", "video", "image", "virtual", "url"}},
+}
+
+// CategoryOrder returns the categories of a GetFieldsByCategory result in a stable
+// order: the built-in categories in rule order, then categories introduced by
+// overrides alphabetically, then Other
+func CategoryOrder(categories map[string][]string) []string {
+	order := make([]string, 0, len(categories))
+	builtIn := make(map[string]bool, len(categoryRules)+1)
+	for _, rule := range categoryRules {
+		builtIn[rule.Category] = true
+		if _, ok := categories[rule.Category]; ok {
+			order = append(order, rule.Category)
+		}
+	}
+	builtIn[otherCategory] = true
+
+	var custom []string
+	for category := range categories {
+		if !builtIn[category] {
+			custom = append(custom, category)
+		}
+	}
+	sort.Strings(custom)
+	order = append(order, custom...)
+
+	if _, ok := categories[otherCategory]; ok {
+		order = append(order, otherCategory)
+	}
+	return order
+}
+
+// SetCategoryOverrides replaces the field → category overrides consulted before the
+// built-in rules. Keys are field names ("TaxAnnualAmount") or entity-qualified names
+// ("Property.DaysOnMarket"), matched case-insensitively; a qualified key wins over a
+// bare one.
+func (p *MetadataParser) SetCategoryOverrides(overrides map[string]string) {
+	p.categoryOverrides = make(map[string]string, len(overrides))
+	for field, category := range overrides {
+		field = strings.ToLower(strings.TrimSpace(field))
+		category = strings.TrimSpace(category)
+		if field != "" && category != "" {
+			p.categoryOverrides[field] = category
+		}
+	}
+}
+
+// categorizeField returns the overridden category for a field, or the category of
+// the first built-in rule it matches
+func (p *MetadataParser) categorizeField(entityName, fieldName string) string {
+	lower := strings.ToLower(fieldName)
+
+	if category, ok := p.categoryOverrides[strings.ToLower(entityName)+"."+lower]; ok {
+		return category
+	}
+	if category, ok := p.categoryOverrides[lower]; ok {
+		return category
+	}
+
+	for _, rule := range categoryRules {
+		if rule.matches(lower) {
+			return rule.Category
+		}
+	}
+	return otherCategory
+}
+
+// matches reports whether a lowercased field name matches the rule
+func (r categoryRule) matches(lower string) bool {
+	for _, substring := range r.Contains {
+		if strings.Contains(lower, substring) {
+			return true
+		}
+	}
+	for _, suffix := range r.Suffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCategoryOrderStable(t *testing.T) {
+	categories := map[string][]string{
+		"Other":               {"Foo"},
+		"Zoning":              {"ZoningDescription"},
+		"Status & Dates":      {"StandardStatus"},
+		"Identification":      {"ListingKey"},
+		"HOA":                 {"AssociationFee"},
+		"Pricing & Financial": {"ListPrice"},
+		"Media & Marketing":   {"PhotosCount"},
+	}
+	want := []string{
+		"Identification",
+		"Pricing & Financial",
+		"Status & Dates",
+		"Media & Marketing",
+		"HOA",
+		"Zoning",
+		"Other",
+	}
+
+	for i := 0; i < 50; i++ {
+		if got := CategoryOrder(categories); !reflect.DeepEqual(got, want) {
+			t.Fatalf("call %d: CategoryOrder() = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestCategoryOrderWithBundledMetadata(t *testing.T) {
+	p := NewMetadataParser()
+	if err := p.ParseFromFile("../constellation1_metadata.xml"); err != nil {
+		t.Fatalf("loading metadata: %v", err)
+	}
+	p.SetCategoryOverrides(map[string]string{"Property.AssociationFee": "HOA"})
+
+	first := CategoryOrder(p.GetFieldsByCategory("Property"))
+	if len(first) == 0 || first[0] != "Identification" {
+		t.Fatalf("CategoryOrder() = %v, want Identification first", first)
+	}
+	for i := 0; i < 20; i++ {
+		if got := CategoryOrder(p.GetFieldsByCategory("Property")); !reflect.DeepEqual(got, first) {
+			t.Fatalf("call %d: CategoryOrder() = %v, want %v", i+2, got, first)
+		}
+	}
+	if guide := p.GenerateFieldsGuide("Property"); guide != p.GenerateFieldsGuide("Property") {
+		t.Error("GenerateFieldsGuide output differs between calls")
+	}
+}
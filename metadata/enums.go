@@ -0,0 +1,220 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Enum match kinds, from strongest to weakest
+const (
+	EnumMatchExact      = "exact"
+	EnumMatchNormalized = "normalized"
+	EnumMatchPartial    = "partial"
+	EnumMatchFuzzy      = "fuzzy"
+)
+
+// EnumMatch is an enum member matched by LookupEnumMembers
+type EnumMatch struct {
+	Member *EnumMemberInfo
+	// Kind is EnumMatchExact, EnumMatchNormalized, EnumMatchPartial or EnumMatchFuzzy
+	Kind string
+	// Score ranks matches of the same kind; higher is closer
+	Score float64
+}
+
+// ResolveEnum finds an enum by enum type name or by the name of an enum-typed field
+// of entityName. The entity field of the same name is returned too when it uses the
+// enum, so callers can tell collections apart. Names are matched case-insensitively.
+func (p *MetadataParser) ResolveEnum(entityName, name string) (*EnumInfo, *PropertyInfo, bool) {
+	var field *PropertyInfo
+	if entity, exists := p.Entities[entityName]; exists {
+		for fieldName, prop := range entity.Properties {
+			if prop.EnumType != "" && strings.EqualFold(fieldName, name) {
+				field = prop
+				break
+			}
+		}
+	}
+
+	enum, exists := p.Enums[name]
+	if !exists {
+		for enumName, candidate := range p.Enums {
+			if strings.EqualFold(enumName, name) {
+				enum, exists = candidate, true
+				break
+			}
+		}
+	}
+
+	switch {
+	case exists && field != nil && p.Enums[field.EnumType] == enum:
+		return enum, field, true
+	case exists:
+		return enum, nil, true
+	case field != nil:
+		enum, exists = p.Enums[field.EnumType]
+		return enum, field, exists
+	}
+	return nil, nil, false
+}
+
+// LookupEnumMembers matches a value against an enum's member names (the identifiers
+// used in filters) and StandardNames (the display strings), in either direction.
+// Matches are tried in order: exact, then ignoring case, spacing and punctuation, then
+// members whose words start with every word of the value ("single family"), then
+// small edit distances. Only the strongest kind found is returned, best first, up to
+// limit members.
+func (p *MetadataParser) LookupEnumMembers(enum *EnumInfo, value string, limit int) []EnumMatch {
+	value = strings.TrimSpace(value)
+	if enum == nil || value == "" {
+		return nil
+	}
+
+	normalizedValue := normalizeEnumText(value)
+	valueWords := enumWords(value)
+
+	byKind := make(map[string][]EnumMatch)
+	for _, member := range enum.Members {
+		names := []string{member.Name}
+		if member.StandardName != "" {
+			names = append(names, member.StandardName)
+		}
+
+		kind, score := "", 0.0
+		for _, name := range names {
+			candidateKind, candidateScore := matchEnumName(value, normalizedValue, valueWords, name)
+			if candidateKind != "" && (kind == "" || enumMatchRank(candidateKind) < enumMatchRank(kind) ||
+				(candidateKind == kind && candidateScore > score)) {
+				kind, score = candidateKind, candidateScore
+			}
+		}
+		if kind != "" {
+			byKind[kind] = append(byKind[kind], EnumMatch{Member: member, Kind: kind, Score: score})
+		}
+	}
+
+	for _, kind := range []string{EnumMatchExact, EnumMatchNormalized, EnumMatchPartial, EnumMatchFuzzy} {
+		matches := byKind[kind]
+		if len(matches) == 0 {
+			continue
+		}
+		sort.Slice(matches, func(i, j int) bool {
+			if matches[i].Score != matches[j].Score {
+				return matches[i].Score > matches[j].Score
+			}
+			return matches[i].Member.Name < matches[j].Member.Name
+		})
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+		return matches
+	}
+	return nil
+}
+
+// matchEnumName classifies how value matches one member name, returning an empty kind
+// when it does not match at all
+func matchEnumName(value, normalizedValue string, valueWords []string, name string) (string, float64) {
+	if value == name {
+		return EnumMatchExact, 1
+	}
+
+	normalizedName := normalizeEnumText(name)
+	if normalizedValue == normalizedName {
+		return EnumMatchNormalized, 1
+	}
+
+	// Prefer the member whose name is closest in length to the value
+	score := float64(len(normalizedValue)) / float64(max(len(normalizedName), 1))
+	if len(valueWords) > 0 && wordsPrefixMatch(valueWords, enumWords(name)) {
+		return EnumMatchPartial, score
+	}
+	if len(normalizedValue) >= 4 && strings.Contains(normalizedName, normalizedValue) {
+		return EnumMatchPartial, score
+	}
+
+	maxDistance := max(len(normalizedValue)/4, 1)
+	if distance := levenshtein(normalizedValue, normalizedName); distance <= maxDistance {
+		return EnumMatchFuzzy, 1 - float64(distance)/float64(max(len(normalizedName), 1))
+	}
+	return "", 0
+}
+
+// enumMatchRank orders match kinds, strongest first
+func enumMatchRank(kind string) int {
+	switch kind {
+	case EnumMatchExact:
+		return 0
+	case EnumMatchNormalized:
+		return 1
+	case EnumMatchPartial:
+		return 2
+	}
+	return 3
+}
+
+// wordsPrefixMatch reports whether every value word starts a distinct name word, in order
+func wordsPrefixMatch(valueWords, nameWords []string) bool {
+	next := 0
+	for _, word := range valueWords {
+		found := false
+		for next < len(nameWords) {
+			candidate := nameWords[next]
+			next++
+			if strings.HasPrefix(candidate, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeEnumText lowercases text and drops everything but letters and digits
+func normalizeEnumText(text string) string {
+	var normalized strings.Builder
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return normalized.String()
+}
+
+// enumWords splits display text or a CamelCase member name into lowercase words
+func enumWords(text string) []string {
+	var words []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			// A new CamelCase word: "SingleFamily" or the "Family" in "HOAFamily"
+			flush()
+		}
+		current.WriteRune(unicode.ToLower(r))
+	}
+	flush()
+
+	return words
+}
+
+// SuggestEnumNames returns up to limit enum type names closest to the given name
+func (p *MetadataParser) SuggestEnumNames(name string, limit int) []string {
+	return closestMatches(p.GetEnumNames(), name, limit)
+}
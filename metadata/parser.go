@@ -11,17 +11,22 @@ import (
 
 // MetadataParser handles parsing of RESO metadata XML
 type MetadataParser struct {
-	Entities map[string]*EntityInfo
-	Enums    map[string]*EnumInfo
+	Entities     map[string]*EntityInfo
+	Enums        map[string]*EnumInfo
+	ComplexTypes map[string]*ComplexTypeInfo
+
+	categoryOverrides map[string]string
 }
 
 // EntityInfo represents an entity from the metadata
 type EntityInfo struct {
-	Name        string
-	Properties  map[string]*PropertyInfo
-	Description string
-	IsBaseType  bool
-	BaseType    string
+	Name                 string
+	Properties           map[string]*PropertyInfo
+	NavigationProperties map[string]*NavigationPropertyInfo
+	Description          string
+	IsBaseType           bool
+	BaseType             string
+	KeyFields            []string
 }
 
 // PropertyInfo represents a property/field from the metadata
@@ -32,6 +37,21 @@ type PropertyInfo struct {
 	IsRequired   bool
 	IsCollection bool
 	EnumType     string
+	ComplexType  string
+}
+
+// ComplexTypeInfo represents a complex type from the metadata, a structured value
+// without a key of its own
+type ComplexTypeInfo struct {
+	Name       string
+	Properties map[string]*PropertyInfo
+}
+
+// NavigationPropertyInfo represents a relationship that can be expanded
+type NavigationPropertyInfo struct {
+	Name         string
+	TargetType   string
+	IsCollection bool
 }
 
 // EnumInfo represents an enum type from the metadata
@@ -70,10 +90,11 @@ type Schema struct {
 
 // EntityType represents an entity definition
 type EntityType struct {
-	Name       string     `xml:"Name,attr"`
-	BaseType   string     `xml:"BaseType,attr"`
-	Properties []Property `xml:"Property"`
-	Keys       []Key      `xml:"Key"`
+	Name                 string               `xml:"Name,attr"`
+	BaseType             string               `xml:"BaseType,attr"`
+	Properties           []Property           `xml:"Property"`
+	NavigationProperties []NavigationProperty `xml:"NavigationProperty"`
+	Keys                 []Key                `xml:"Key"`
 }
 
 // Property represents a property/field definition
@@ -85,6 +106,13 @@ type Property struct {
 	Precision string `xml:"Precision,attr"`
 }
 
+// NavigationProperty represents a navigation property definition
+type NavigationProperty struct {
+	Name           string `xml:"Name,attr"`
+	Type           string `xml:"Type,attr"`
+	ContainsTarget string `xml:"ContainsTarget,attr"`
+}
+
 // Key represents entity key definition
 type Key struct {
 	PropertyRefs []PropertyRef `xml:"PropertyRef"`
@@ -112,8 +140,17 @@ type EnumMember struct {
 
 // Annotation represents metadata annotations
 type Annotation struct {
-	Term   string `xml:"Term,attr"`
-	String string `xml:"String,attr"`
+	Term          string `xml:"Term,attr"`
+	String        string `xml:"String,attr"`
+	StringElement string `xml:"String"`
+}
+
+// Value returns the annotation's string value, from either the attribute or child element form
+func (a Annotation) Value() string {
+	if a.String != "" {
+		return a.String
+	}
+	return strings.TrimSpace(a.StringElement)
 }
 
 // ComplexType represents complex type definitions
@@ -125,8 +162,9 @@ type ComplexType struct {
 // NewMetadataParser creates a new metadata parser
 func NewMetadataParser() *MetadataParser {
 	return &MetadataParser{
-		Entities: make(map[string]*EntityInfo),
-		Enums:    make(map[string]*EnumInfo),
+		Entities:     make(map[string]*EntityInfo),
+		Enums:        make(map[string]*EnumInfo),
+		ComplexTypes: make(map[string]*ComplexTypeInfo),
 	}
 }
 
@@ -157,15 +195,59 @@ func (p *MetadataParser) ParseFromReader(reader io.Reader) error {
 			p.parseEnumType(enumType, schema.Namespace)
 		}
 
+		// Parse complex types
+		for _, complexType := range schema.ComplexTypes {
+			p.parseComplexType(complexType, schema.Namespace)
+		}
+
 		// Parse entity types
 		for _, entityType := range schema.EntityTypes {
 			p.parseEntityType(entityType, schema.Namespace)
 		}
 	}
 
+	p.inheritKeyFields()
+	p.resolveTypes()
+
 	return nil
 }
 
+// resolveTypes links properties of entities and complex types to the enum and complex
+// types they refer to. It runs once every schema is parsed, so a type may refer to a
+// type declared in a later schema.
+func (p *MetadataParser) resolveTypes() {
+	for _, entity := range p.Entities {
+		for _, prop := range entity.Properties {
+			prop.EnumType = p.extractEnumType(prop.Type)
+			prop.ComplexType = p.extractComplexType(prop.Type)
+		}
+	}
+	for _, complexType := range p.ComplexTypes {
+		for _, prop := range complexType.Properties {
+			prop.EnumType = p.extractEnumType(prop.Type)
+			prop.ComplexType = p.extractComplexType(prop.Type)
+		}
+	}
+}
+
+// inheritKeyFields gives derived entity types without their own <Key> the key of
+// their base type, following the BaseType chain
+func (p *MetadataParser) inheritKeyFields() {
+	for _, entity := range p.Entities {
+		base := entity
+		for depth := 0; len(base.KeyFields) == 0 && base.BaseType != "" && depth < len(p.Entities); depth++ {
+			next, exists := p.Entities[base.BaseType[strings.LastIndex(base.BaseType, ".")+1:]]
+			if !exists {
+				break
+			}
+			base = next
+		}
+		if len(entity.KeyFields) == 0 && len(base.KeyFields) > 0 {
+			entity.KeyFields = append([]string(nil), base.KeyFields...)
+		}
+	}
+}
+
 // parseEnumType processes an enum type definition
 func (p *MetadataParser) parseEnumType(enumType EnumType, namespace string) {
 	fullName := enumType.Name
@@ -185,13 +267,22 @@ func (p *MetadataParser) parseEnumType(enumType EnumType, namespace string) {
 			Value: member.Value,
 		}
 
-		// Extract standard name from annotations
+		// Extract standard name and description from annotations, preferring a
+		// short Description over a LongDescription
+		var longDescription string
 		for _, annotation := range member.Annotations {
-			if strings.Contains(annotation.Term, "StandardName") {
-				memberInfo.StandardName = annotation.String
-				break
+			switch {
+			case strings.Contains(annotation.Term, "StandardName"):
+				memberInfo.StandardName = annotation.Value()
+			case strings.Contains(annotation.Term, "LongDescription"):
+				longDescription = annotation.Value()
+			case strings.Contains(annotation.Term, "Description"):
+				memberInfo.Description = annotation.Value()
 			}
 		}
+		if memberInfo.Description == "" {
+			memberInfo.Description = longDescription
+		}
 
 		enumInfo.Members[member.Name] = memberInfo
 	}
@@ -200,13 +291,46 @@ func (p *MetadataParser) parseEnumType(enumType EnumType, namespace string) {
 	p.Enums[enumType.Name] = enumInfo // Also store by short name
 }
 
+// parseComplexType processes a complex type definition
+func (p *MetadataParser) parseComplexType(complexType ComplexType, namespace string) {
+	fullName := complexType.Name
+	if namespace != "" && !strings.Contains(complexType.Name, ".") {
+		fullName = namespace + "." + complexType.Name
+	}
+
+	complexInfo := &ComplexTypeInfo{
+		Name:       complexType.Name,
+		Properties: make(map[string]*PropertyInfo),
+	}
+
+	for _, property := range complexType.Properties {
+		complexInfo.Properties[property.Name] = &PropertyInfo{
+			Name:         property.Name,
+			Type:         property.Type,
+			IsRequired:   property.Nullable == "false",
+			IsCollection: strings.HasPrefix(property.Type, "Collection("),
+		}
+	}
+
+	p.ComplexTypes[fullName] = complexInfo
+	p.ComplexTypes[complexType.Name] = complexInfo // Also store by short name
+}
+
 // parseEntityType processes an entity type definition
 func (p *MetadataParser) parseEntityType(entityType EntityType, namespace string) {
 	entityInfo := &EntityInfo{
-		Name:       entityType.Name,
-		Properties: make(map[string]*PropertyInfo),
-		BaseType:   entityType.BaseType,
-		IsBaseType: entityType.BaseType != "",
+		Name:                 entityType.Name,
+		Properties:           make(map[string]*PropertyInfo),
+		NavigationProperties: make(map[string]*NavigationPropertyInfo),
+		BaseType:             entityType.BaseType,
+		IsBaseType:           entityType.BaseType != "",
+	}
+
+	// Primary key, from <Key><PropertyRef Name="..."/></Key>
+	for _, key := range entityType.Keys {
+		for _, ref := range key.PropertyRefs {
+			entityInfo.KeyFields = append(entityInfo.KeyFields, ref.Name)
+		}
 	}
 
 	// Process properties
@@ -218,33 +342,65 @@ func (p *MetadataParser) parseEntityType(entityType EntityType, namespace string
 			IsCollection: strings.HasPrefix(property.Type, "Collection("),
 		}
 
-		// Determine if this is an enum type
-		if enumType := p.extractEnumType(property.Type); enumType != "" {
-			propInfo.EnumType = enumType
+		entityInfo.Properties[property.Name] = propInfo
+	}
+
+	// Process navigation properties (valid $expand targets)
+	for _, navProperty := range entityType.NavigationProperties {
+		isCollection := strings.HasPrefix(navProperty.Type, "Collection(")
+		target := navProperty.Type
+		if isCollection && strings.HasSuffix(target, ")") {
+			target = target[11 : len(target)-1]
+		}
+		if idx := strings.LastIndex(target, "."); idx >= 0 {
+			target = target[idx+1:]
 		}
 
-		entityInfo.Properties[property.Name] = propInfo
+		entityInfo.NavigationProperties[navProperty.Name] = &NavigationPropertyInfo{
+			Name:         navProperty.Name,
+			TargetType:   target,
+			IsCollection: isCollection,
+		}
 	}
 
 	p.Entities[entityType.Name] = entityInfo
 }
 
-// extractEnumType extracts enum type name from a property type
+// enumNamespace is the schema namespace RESO metadata declares its enum types in
+const enumNamespace = "org.reso.metadata.enums."
+
+// extractEnumType returns the name of the enum a property type refers to, directly or
+// as Collection(...) of it. Only types in the RESO enums namespace count; Edm.String,
+// Collection(Edm.String) and other non-enum types return "".
 func (p *MetadataParser) extractEnumType(propType string) string {
-	// Handle Collection(EnumType) format
-	if strings.HasPrefix(propType, "Collection(") && strings.HasSuffix(propType, ")") {
-		inner := propType[11 : len(propType)-1] // Remove "Collection(" and ")"
-		if strings.Contains(inner, "org.reso.metadata.enums.") {
-			return strings.TrimPrefix(inner, "org.reso.metadata.enums.")
+	propType = strings.TrimSpace(propType)
+	if inner, ok := strings.CutPrefix(propType, "Collection("); ok && strings.HasSuffix(inner, ")") {
+		propType = strings.TrimSpace(strings.TrimSuffix(inner, ")"))
+	}
+
+	if name, ok := strings.CutPrefix(propType, enumNamespace); ok {
+		if name == "" || strings.Contains(name, ".") {
+			return ""
 		}
-		return inner
+		return name
 	}
+	return ""
+}
 
-	// Handle direct enum type
-	if strings.Contains(propType, "org.reso.metadata.enums.") {
-		return strings.TrimPrefix(propType, "org.reso.metadata.enums.")
+// extractComplexType returns the name of the complex type a property type refers to,
+// directly or as Collection(...) of it, or "" for any other type
+func (p *MetadataParser) extractComplexType(propType string) string {
+	propType = strings.TrimSpace(propType)
+	if inner, ok := strings.CutPrefix(propType, "Collection("); ok && strings.HasSuffix(inner, ")") {
+		propType = strings.TrimSpace(strings.TrimSuffix(inner, ")"))
 	}
 
+	if strings.HasPrefix(propType, "Edm.") || !strings.Contains(propType, ".") {
+		return ""
+	}
+	if complexType, ok := p.ComplexTypes[propType]; ok {
+		return complexType.Name
+	}
 	return ""
 }
 
@@ -260,6 +416,26 @@ func (p *MetadataParser) GetEnumInfo(enumName string) (*EnumInfo, bool) {
 	return enum, exists
 }
 
+// GetNavigationProperties returns the expandable relationships of an entity, sorted by name
+func (p *MetadataParser) GetNavigationProperties(entityName string) []*NavigationPropertyInfo {
+	entity, exists := p.Entities[entityName]
+	if !exists {
+		return nil
+	}
+
+	var names []string
+	for name := range entity.NavigationProperties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	navProperties := make([]*NavigationPropertyInfo, 0, len(names))
+	for _, name := range names {
+		navProperties = append(navProperties, entity.NavigationProperties[name])
+	}
+	return navProperties
+}
+
 // GetEntityNames returns sorted list of all entity names
 func (p *MetadataParser) GetEntityNames() []string {
 	var names []string
@@ -295,7 +471,7 @@ func (p *MetadataParser) GetFieldsByCategory(entityName string) map[string][]str
 	categories := make(map[string][]string)
 
 	for fieldName := range entity.Properties {
-		category := p.categorizeField(fieldName)
+		category := p.categorizeField(entityName, fieldName)
 		categories[category] = append(categories[category], fieldName)
 	}
 
@@ -307,72 +483,6 @@ func (p *MetadataParser) GetFieldsByCategory(entityName string) map[string][]str
 	return categories
 }
 
-// categorizeField categorizes a field based on its name patterns
-func (p *MetadataParser) categorizeField(fieldName string) string {
-	lower := strings.ToLower(fieldName)
-
-	// Identification fields
-	if strings.Contains(lower, "key") || strings.Contains(lower, "id") ||
-		strings.Contains(lower, "mlsid") || fieldName == "ListingKey" || fieldName == "ListingId" {
-		return "Identification"
-	}
-
-	// Address and location fields
-	if strings.Contains(lower, "street") || strings.Contains(lower, "city") ||
-		strings.Contains(lower, "state") || strings.Contains(lower, "postal") ||
-		strings.Contains(lower, "address") || strings.Contains(lower, "latitude") ||
-		strings.Contains(lower, "longitude") || strings.Contains(lower, "area") {
-		return "Address & Location"
-	}
-
-	// Pricing fields
-	if strings.Contains(lower, "price") || strings.Contains(lower, "tax") ||
-		strings.Contains(lower, "cost") || strings.Contains(lower, "expense") ||
-		strings.Contains(lower, "fee") || strings.Contains(lower, "income") {
-		return "Pricing & Financial"
-	}
-
-	// Property characteristics
-	if strings.Contains(lower, "bedroom") || strings.Contains(lower, "bathroom") ||
-		strings.Contains(lower, "room") || strings.Contains(lower, "area") ||
-		strings.Contains(lower, "year") || strings.Contains(lower, "built") ||
-		strings.Contains(lower, "stories") || strings.Contains(lower, "lot") {
-		return "Property Details"
-	}
-
-	// Agent/Office information
-	if strings.Contains(lower, "agent") || strings.Contains(lower, "office") ||
-		strings.Contains(lower, "member") || strings.Contains(lower, "broker") {
-		return "Agent & Office Info"
-	}
-
-	// Status and dates
-	if strings.Contains(lower, "status") || strings.Contains(lower, "timestamp") ||
-		strings.Contains(lower, "date") || strings.Contains(lower, "time") ||
-		strings.Contains(lower, "market") || strings.Contains(lower, "modification") {
-		return "Status & Dates"
-	}
-
-	// Features and amenities
-	if strings.Contains(lower, "feature") || strings.Contains(lower, "amenity") ||
-		strings.Contains(lower, "appliance") || strings.Contains(lower, "heating") ||
-		strings.Contains(lower, "cooling") || strings.Contains(lower, "parking") ||
-		strings.Contains(lower, "pool") || strings.Contains(lower, "garage") ||
-		strings.Contains(lower, "fireplace") || strings.HasSuffix(lower, "yn") {
-		return "Features & Amenities"
-	}
-
-	// Media related
-	if strings.Contains(lower, "media") || strings.Contains(lower, "photo") ||
-		strings.Contains(lower, "video") || strings.Contains(lower, "image") ||
-		strings.Contains(lower, "virtual") || strings.Contains(lower, "url") {
-		return "Media & Marketing"
-	}
-
-	// Default category
-	return "Other"
-}
-
 // GenerateEntityGuide generates dynamic entity documentation
 func (p *MetadataParser) GenerateEntityGuide() string {
 	var guide strings.Builder
@@ -398,9 +508,17 @@ func (p *MetadataParser) GenerateEntityGuide() string {
 			guide.WriteString("\n\n")
 		}
 
+		// Show valid expansions
+		if navProperties := p.GetNavigationProperties(entityName); len(navProperties) > 0 {
+			guide.WriteString("**Expandable Relationships**: ")
+			guide.WriteString(p.formatNavigationProperties(navProperties))
+			guide.WriteString("\n\n")
+		}
+
 		// Show field categories
 		categories := p.GetFieldsByCategory(entityName)
-		for category, fields := range categories {
+		for _, category := range CategoryOrder(categories) {
+			fields := categories[category]
 			if len(fields) > 0 {
 				guide.WriteString(fmt.Sprintf("**%s**: ", category))
 				if len(fields) > 10 {
@@ -429,7 +547,8 @@ func (p *MetadataParser) GenerateFieldsGuide(entityName string) string {
 
 	categories := p.GetFieldsByCategory(entityName)
 
-	for category, fields := range categories {
+	for _, category := range CategoryOrder(categories) {
+		fields := categories[category]
 		if len(fields) == 0 {
 			continue
 		}
@@ -448,7 +567,15 @@ func (p *MetadataParser) GenerateFieldsGuide(entityName string) string {
 				guide.WriteString(fmt.Sprintf(" - Enum: %s", prop.EnumType))
 			}
 
+			if prop.ComplexType != "" {
+				guide.WriteString(fmt.Sprintf(" - Complex: %s", prop.ComplexType))
+			}
+
 			guide.WriteString("\n")
+
+			if prop.ComplexType != "" {
+				p.writeComplexFields(&guide, prop.ComplexType, "  ", map[string]bool{})
+			}
 		}
 		guide.WriteString("\n")
 	}
@@ -456,6 +583,47 @@ func (p *MetadataParser) GenerateFieldsGuide(entityName string) string {
 	return guide.String()
 }
 
+// writeComplexFields lists a complex type's sub-fields, indented under the field that
+// uses it. Nested complex types are expanded in turn, except ones already being listed
+// further up, so self-referencing types do not recurse forever.
+func (p *MetadataParser) writeComplexFields(guide *strings.Builder, typeName, indent string, listing map[string]bool) {
+	complexType, exists := p.ComplexTypes[typeName]
+	if !exists || listing[complexType.Name] {
+		return
+	}
+	listing[complexType.Name] = true
+	defer delete(listing, complexType.Name)
+
+	var names []string
+	for name := range complexType.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := complexType.Properties[name]
+		guide.WriteString(fmt.Sprintf("%s- **%s** (%s)", indent, name, p.formatType(prop.Type)))
+
+		if prop.IsRequired {
+			guide.WriteString(" *Required*")
+		}
+
+		if prop.EnumType != "" {
+			guide.WriteString(fmt.Sprintf(" - Enum: %s", prop.EnumType))
+		}
+
+		if prop.ComplexType != "" {
+			guide.WriteString(fmt.Sprintf(" - Complex: %s", prop.ComplexType))
+		}
+
+		guide.WriteString("\n")
+
+		if prop.ComplexType != "" {
+			p.writeComplexFields(guide, prop.ComplexType, indent+"  ", listing)
+		}
+	}
+}
+
 // GenerateEnumsGuide generates dynamic enums documentation
 func (p *MetadataParser) GenerateEnumsGuide() string {
 	var guide strings.Builder
@@ -522,6 +690,10 @@ func (p *MetadataParser) formatEnumSection(enumInfo *EnumInfo) string {
 			section.WriteString(fmt.Sprintf(" - Value: %s", member.Value))
 		}
 
+		if member.Description != "" {
+			section.WriteString(fmt.Sprintf(" - %s", member.Description))
+		}
+
 		section.WriteString("\n")
 	}
 	section.WriteString("\n")
@@ -529,20 +701,159 @@ func (p *MetadataParser) formatEnumSection(enumInfo *EnumInfo) string {
 	return section.String()
 }
 
-// getKeyFields extracts key field names from entity
+// getKeyFields returns the entity's primary key fields as declared in the metadata
 func (p *MetadataParser) getKeyFields(entity *EntityInfo) []string {
-	var keyFields []string
+	return entity.KeyFields
+}
 
-	// Look for common key patterns
-	for fieldName := range entity.Properties {
-		if strings.Contains(strings.ToLower(fieldName), "key") ||
-			strings.Contains(strings.ToLower(fieldName), "id") {
-			keyFields = append(keyFields, fieldName)
+// formatNavigationProperties formats navigation properties as a comma-separated list
+func (p *MetadataParser) formatNavigationProperties(navProperties []*NavigationPropertyInfo) string {
+	var parts []string
+	for _, nav := range navProperties {
+		if nav.IsCollection {
+			parts = append(parts, fmt.Sprintf("%s (collection of %s)", nav.Name, nav.TargetType))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (%s)", nav.Name, nav.TargetType))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GenerateExpandGuide generates the list of valid expansions for every entity
+func (p *MetadataParser) GenerateExpandGuide() string {
+	var guide strings.Builder
+	guide.WriteString("## Valid Expansions by Entity (Generated from Metadata)\n\n")
+
+	for _, entityName := range p.GetEntityNames() {
+		navProperties := p.GetNavigationProperties(entityName)
+		if len(navProperties) == 0 {
+			continue
+		}
+		guide.WriteString(fmt.Sprintf("- **%s**: %s\n", entityName, p.formatNavigationProperties(navProperties)))
+	}
+	guide.WriteString("\n")
+
+	return guide.String()
+}
+
+// GenerateEntityExpandGuide lists the navigation properties one entity can expand,
+// with their target entities and example expand clauses built from them. It returns
+// "" when the entity has no navigation properties.
+func (p *MetadataParser) GenerateEntityExpandGuide(entityName string) string {
+	navProperties := p.GetNavigationProperties(entityName)
+	if len(navProperties) == 0 {
+		return ""
+	}
+
+	var guide strings.Builder
+	guide.WriteString(fmt.Sprintf("# %s Expansions (Generated from Metadata)\n\n", entityName))
+	guide.WriteString(fmt.Sprintf("%s can expand exactly these %d relationships. Any other name in `expand` is rejected.\n\n", entityName, len(navProperties)))
+	guide.WriteString("| Expand | Target Entity | Returns |\n")
+	guide.WriteString("|--------|---------------|---------|\n")
+	for _, nav := range navProperties {
+		returns := "single record"
+		if nav.IsCollection {
+			returns = "collection"
+		}
+		guide.WriteString(fmt.Sprintf("| %s | %s | %s |\n", nav.Name, nav.TargetType, returns))
+	}
+
+	guide.WriteString("\n## Examples\n")
+	for _, nav := range navProperties {
+		guide.WriteString(fmt.Sprintf("\n### %s\n", nav.Name))
+		guide.WriteString(fmt.Sprintf("```\nexpand: \"%s\"\n```\n", nav.Name))
+		if clause := p.exampleExpandOptions(nav); clause != "" {
+			guide.WriteString(fmt.Sprintf("```\nexpand: \"%s(%s)\"\n```\n", nav.Name, clause))
+		}
+	}
+
+	if len(navProperties) > 1 {
+		var names []string
+		for _, nav := range navProperties {
+			if len(names) == 3 {
+				break
+			}
+			names = append(names, nav.Name)
 		}
+		guide.WriteString("\n### Several at Once\n")
+		guide.WriteString(fmt.Sprintf("```\nexpand: \"%s\"\n```\n", strings.Join(names, ",")))
 	}
 
-	sort.Strings(keyFields)
-	return keyFields
+	return guide.String()
+}
+
+// exampleExpandOptions builds nested query options for an example expand of nav,
+// selecting the target's key fields and capping collections. It returns "" when the
+// target entity is not in the metadata.
+func (p *MetadataParser) exampleExpandOptions(nav *NavigationPropertyInfo) string {
+	target, exists := p.Entities[nav.TargetType]
+	if !exists {
+		return ""
+	}
+
+	fields := append([]string(nil), target.KeyFields...)
+	if _, ok := target.Properties["ModificationTimestamp"]; ok {
+		fields = append(fields, "ModificationTimestamp")
+	}
+
+	var options []string
+	if len(fields) > 0 {
+		options = append(options, "$select="+strings.Join(fields, ","))
+	}
+	if nav.IsCollection {
+		if _, ok := target.Properties["ModificationTimestamp"]; ok {
+			options = append(options, "$orderby=ModificationTimestamp desc")
+		}
+		options = append(options, "$top=5")
+	}
+	return strings.Join(options, ";")
+}
+
+// filterFieldGroups are the Property fields most often filtered on, by purpose
+var filterFieldGroups = []struct {
+	Name   string
+	Fields []string
+}{
+	{"Status", []string{"StandardStatus", "MlsStatus"}},
+	{"Price", []string{"ListPrice", "ClosePrice", "OriginalListPrice", "PreviousListPrice"}},
+	{"Location", []string{"City", "StateOrProvince", "PostalCode", "CountyOrParish", "MLSAreaMajor", "MLSAreaMinor", "SubdivisionName"}},
+	{"Size & Rooms", []string{"BedroomsTotal", "BathroomsTotal", "BathroomsTotalInteger", "BathroomsFull", "LivingArea", "LotSizeAcres", "LotSizeSquareFeet", "Stories", "GarageSpaces"}},
+	{"Type", []string{"PropertyType", "PropertySubType"}},
+	{"Features", []string{"PoolPrivateYN", "GarageYN", "BasementYN", "WaterfrontYN", "ViewYN", "FireplaceYN", "NewConstructionYN", "Appliances", "Heating", "Cooling", "ParkingFeatures"}},
+	{"Dates & Age", []string{"YearBuilt", "OnMarketTimestamp", "ModificationTimestamp", "CloseDate", "DaysOnMarket"}},
+}
+
+// GenerateFilterFieldsGuide lists the commonly filtered Property fields that the
+// metadata actually defines, with their types, and the ones it does not
+func (p *MetadataParser) GenerateFilterFieldsGuide() string {
+	entity, exists := p.Entities["Property"]
+	if !exists {
+		return ""
+	}
+
+	var guide strings.Builder
+	var missing []string
+	guide.WriteString("## Filterable Property Fields (Generated from Metadata)\n\n")
+	for _, group := range filterFieldGroups {
+		var parts []string
+		for _, name := range group.Fields {
+			prop, ok := entity.Properties[name]
+			if !ok {
+				missing = append(missing, name)
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s (%s)", name, p.formatType(prop.Type)))
+		}
+		if len(parts) > 0 {
+			guide.WriteString(fmt.Sprintf("- **%s**: %s\n", group.Name, strings.Join(parts, ", ")))
+		}
+	}
+	if len(missing) > 0 {
+		guide.WriteString(fmt.Sprintf("\n*Not exposed by this MLS, so filters on them will fail: %s*\n", strings.Join(missing, ", ")))
+	}
+	guide.WriteString("\nUse 'has' for Collection fields, true/false for YN fields and unquoted dates for Date and DateTimeOffset fields.\n\n")
+
+	return guide.String()
 }
 
 // formatType formats a property type for display
@@ -561,6 +872,10 @@ func (p *MetadataParser) formatType(propType string) string {
 		return strings.TrimPrefix(propType, "org.reso.metadata.enums.")
 	}
 
+	if complexType, ok := p.ComplexTypes[propType]; ok {
+		return complexType.Name
+	}
+
 	return propType
 }
 
@@ -582,15 +897,15 @@ func (p *MetadataParser) GetCommonFields(entityName string) []string {
 	case "Media":
 		return p.getCommonMediaFields(entity)
 	default:
-		// Return first 20 fields as common fields
+		// Return the first 20 fields by name, so the set is stable between calls
 		var fields []string
 		for fieldName := range entity.Properties {
 			fields = append(fields, fieldName)
-			if len(fields) >= 20 {
-				break
-			}
 		}
 		sort.Strings(fields)
+		if len(fields) > 20 {
+			fields = fields[:20]
+		}
 		return fields
 	}
 }
@@ -674,3 +989,276 @@ func (p *MetadataParser) getCommonMediaFields(entity *EntityInfo) []string {
 
 	return existing
 }
+
+// HasField reports whether an entity defines the given field
+func (p *MetadataParser) HasField(entityName, fieldName string) bool {
+	entity, exists := p.Entities[entityName]
+	if !exists {
+		return false
+	}
+	_, exists = entity.Properties[fieldName]
+	return exists
+}
+
+// HasNavigationProperty reports whether an entity defines the given navigation property
+func (p *MetadataParser) HasNavigationProperty(entityName, navName string) bool {
+	entity, exists := p.Entities[entityName]
+	if !exists {
+		return false
+	}
+	_, exists = entity.NavigationProperties[navName]
+	return exists
+}
+
+// SuggestNavigationProperties returns up to limit navigation properties of an entity closest to the given name
+func (p *MetadataParser) SuggestNavigationProperties(entityName, navName string, limit int) []string {
+	entity, exists := p.Entities[entityName]
+	if !exists {
+		return nil
+	}
+
+	names := make([]string, 0, len(entity.NavigationProperties))
+	for name := range entity.NavigationProperties {
+		names = append(names, name)
+	}
+	return closestMatches(names, navName, limit)
+}
+
+// SuggestFields returns up to limit fields of an entity closest to the given name
+func (p *MetadataParser) SuggestFields(entityName, fieldName string, limit int) []string {
+	entity, exists := p.Entities[entityName]
+	if !exists {
+		return nil
+	}
+
+	names := make([]string, 0, len(entity.Properties))
+	for name := range entity.Properties {
+		names = append(names, name)
+	}
+	return closestMatches(names, fieldName, limit)
+}
+
+// IsEnumMember reports whether value is a member name or standard name of the enum
+func (p *MetadataParser) IsEnumMember(enumName, value string) bool {
+	enumInfo, exists := p.Enums[enumName]
+	if !exists {
+		return false
+	}
+	for _, member := range enumInfo.Members {
+		if member.Name == value || member.StandardName == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEnumMemberNames returns the sorted member names of an enum
+func (p *MetadataParser) GetEnumMemberNames(enumName string) []string {
+	enumInfo, exists := p.Enums[enumName]
+	if !exists {
+		return nil
+	}
+
+	names := make([]string, 0, len(enumInfo.Members))
+	for name := range enumInfo.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SuggestEnumMembers returns up to limit enum members closest to the given value
+func (p *MetadataParser) SuggestEnumMembers(enumName, value string, limit int) []string {
+	return closestMatches(p.GetEnumMemberNames(enumName), value, limit)
+}
+
+// closestMatches returns up to limit candidates within a small edit distance of target
+func closestMatches(names []string, target string, limit int) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	lowerTarget := strings.ToLower(target)
+	maxDistance := len(lowerTarget) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var candidates []candidate
+	for _, name := range names {
+		distance := levenshtein(lowerTarget, strings.ToLower(name))
+		if distance <= maxDistance {
+			candidates = append(candidates, candidate{name: name, distance: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var matches []string
+	for _, c := range candidates {
+		if len(matches) >= limit {
+			break
+		}
+		matches = append(matches, c.name)
+	}
+	return matches
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// SchemaExport is a machine-readable view of the parsed metadata
+type SchemaExport struct {
+	Entities []EntitySchema `json:"entities"`
+	Enums    []EnumSchema   `json:"enums,omitempty"`
+}
+
+// EntitySchema describes an entity and its fields
+type EntitySchema struct {
+	Name                 string             `json:"name"`
+	BaseType             string             `json:"baseType,omitempty"`
+	Keys                 []string           `json:"keys,omitempty"`
+	Fields               []FieldSchema      `json:"fields"`
+	NavigationProperties []NavigationSchema `json:"navigationProperties,omitempty"`
+}
+
+// FieldSchema describes a single entity field
+type FieldSchema struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Nullable     bool   `json:"nullable"`
+	IsCollection bool   `json:"isCollection"`
+	EnumType     string `json:"enumType,omitempty"`
+	ComplexType  string `json:"complexType,omitempty"`
+}
+
+// NavigationSchema describes an expandable relationship
+type NavigationSchema struct {
+	Name         string `json:"name"`
+	TargetType   string `json:"targetType"`
+	IsCollection bool   `json:"isCollection"`
+}
+
+// EnumSchema describes an enum and its members
+type EnumSchema struct {
+	Name    string             `json:"name"`
+	Members []EnumMemberSchema `json:"members"`
+}
+
+// EnumMemberSchema describes a single enum member
+type EnumMemberSchema struct {
+	Name         string `json:"name"`
+	Value        string `json:"value,omitempty"`
+	StandardName string `json:"standardName,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// ExportSchema builds a machine-readable schema, optionally scoped to one entity.
+// When includeEnums is set, enums referenced by the exported entities are included
+// (every enum when no entity is given).
+func (p *MetadataParser) ExportSchema(entityName string, includeEnums bool) (*SchemaExport, error) {
+	entityNames := p.GetEntityNames()
+	if entityName != "" {
+		if _, exists := p.Entities[entityName]; !exists {
+			return nil, fmt.Errorf("entity '%s' not found in metadata", entityName)
+		}
+		entityNames = []string{entityName}
+	}
+
+	export := &SchemaExport{Entities: []EntitySchema{}}
+	referencedEnums := make(map[string]bool)
+
+	for _, name := range entityNames {
+		entity := p.Entities[name]
+		entitySchema := EntitySchema{
+			Name:     entity.Name,
+			BaseType: entity.BaseType,
+			Keys:     entity.KeyFields,
+			Fields:   []FieldSchema{},
+		}
+
+		var fieldNames []string
+		for fieldName := range entity.Properties {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+
+		for _, fieldName := range fieldNames {
+			prop := entity.Properties[fieldName]
+			entitySchema.Fields = append(entitySchema.Fields, FieldSchema{
+				Name:         prop.Name,
+				Type:         prop.Type,
+				Nullable:     !prop.IsRequired,
+				IsCollection: prop.IsCollection,
+				EnumType:     prop.EnumType,
+				ComplexType:  prop.ComplexType,
+			})
+			if prop.EnumType != "" {
+				referencedEnums[prop.EnumType] = true
+			}
+		}
+
+		for _, nav := range p.GetNavigationProperties(name) {
+			entitySchema.NavigationProperties = append(entitySchema.NavigationProperties, NavigationSchema{
+				Name:         nav.Name,
+				TargetType:   nav.TargetType,
+				IsCollection: nav.IsCollection,
+			})
+		}
+
+		export.Entities = append(export.Entities, entitySchema)
+	}
+
+	if includeEnums {
+		// Enums are stored under both short and qualified names; export each once
+		seen := make(map[*EnumInfo]bool)
+		for _, enumName := range p.GetEnumNames() {
+			enumInfo := p.Enums[enumName]
+			if seen[enumInfo] || (entityName != "" && !referencedEnums[enumInfo.Name]) {
+				continue
+			}
+			seen[enumInfo] = true
+
+			enumSchema := EnumSchema{Name: enumInfo.Name, Members: []EnumMemberSchema{}}
+			for _, memberName := range p.GetEnumMemberNames(enumName) {
+				member := enumInfo.Members[memberName]
+				enumSchema.Members = append(enumSchema.Members, EnumMemberSchema{
+					Name:         member.Name,
+					Value:        member.Value,
+					StandardName: member.StandardName,
+					Description:  member.Description,
+				})
+			}
+			export.Enums = append(export.Enums, enumSchema)
+		}
+	}
+
+	return export, nil
+}
@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractEnumType(t *testing.T) {
+	p := NewMetadataParser()
+	tests := []struct {
+		name     string
+		propType string
+		want     string
+	}{
+		{"collection of enum", "Collection(org.reso.metadata.enums.Appliances)", "Appliances"},
+		{"single enum", "org.reso.metadata.enums.StandardStatus", "StandardStatus"},
+		{"collection of primitive", "Collection(Edm.String)", ""},
+		{"plain primitive", "Edm.Decimal", ""},
+		{"other namespace", "org.example.enums.Color", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.extractEnumType(tt.propType); got != tt.want {
+				t.Errorf("extractEnumType(%q) = %q, want %q", tt.propType, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseResolvesTypesAcrossSchemas declares the entity before the schemas holding
+// its enum and complex types, so types must be resolved after every schema is parsed
+func TestParseResolvesTypesAcrossSchemas(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="utf-8"?>
+<edmx:Edmx xmlns:edmx="http://docs.oasis-open.org/odata/ns/edmx" Version="4.0">
+  <edmx:DataServices>
+    <Schema Namespace="org.reso.metadata">
+      <EntityType Name="Property">
+        <Key><PropertyRef Name="ListingKey"/></Key>
+        <Property Name="ListingKey" Type="Edm.String"/>
+        <Property Name="StandardStatus" Type="org.reso.metadata.enums.StandardStatus"/>
+        <Property Name="Appliances" Type="Collection(org.reso.metadata.enums.Appliances)"/>
+        <Property Name="Tags" Type="Collection(Edm.String)"/>
+        <Property Name="Location" Type="org.reso.metadata.GeoPoint"/>
+      </EntityType>
+      <ComplexType Name="GeoPoint">
+        <Property Name="Latitude" Type="Edm.Decimal"/>
+      </ComplexType>
+    </Schema>
+    <Schema Namespace="org.reso.metadata.enums">
+      <EnumType Name="StandardStatus"><Member Name="Active" Value="0"/></EnumType>
+      <EnumType Name="Appliances"><Member Name="Dishwasher" Value="0"/></EnumType>
+    </Schema>
+  </edmx:DataServices>
+</edmx:Edmx>`
+
+	p := NewMetadataParser()
+	if err := p.ParseFromReader(strings.NewReader(doc)); err != nil {
+		t.Fatalf("ParseFromReader: %v", err)
+	}
+	entity, ok := p.GetEntityInfo("Property")
+	if !ok {
+		t.Fatal("Property entity not parsed")
+	}
+
+	tests := []struct {
+		field          string
+		wantEnum       string
+		wantComplex    string
+		wantCollection bool
+	}{
+		{"ListingKey", "", "", false},
+		{"StandardStatus", "StandardStatus", "", false},
+		{"Appliances", "Appliances", "", true},
+		{"Tags", "", "", true},
+		{"Location", "", "GeoPoint", false},
+	}
+	for _, tt := range tests {
+		prop := entity.Properties[tt.field]
+		if prop == nil {
+			t.Errorf("%s: property not parsed", tt.field)
+			continue
+		}
+		if prop.EnumType != tt.wantEnum || prop.ComplexType != tt.wantComplex || prop.IsCollection != tt.wantCollection {
+			t.Errorf("%s: EnumType=%q ComplexType=%q IsCollection=%t, want %q %q %t",
+				tt.field, prop.EnumType, prop.ComplexType, prop.IsCollection, tt.wantEnum, tt.wantComplex, tt.wantCollection)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package metrics keeps process-wide counters of API, cache and authentication
+// activity for operators. Counters are shared by every session and only ever grow.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a monotonically increasing count that is safe for concurrent use
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Int64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the current count
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Process-wide counters
+var (
+	Queries        = &Counter{name: "reso_queries_total", help: "Queries run through the API client, including cache hits"}
+	QueryErrors    = &Counter{name: "reso_query_errors_total", help: "Queries that returned an error"}
+	APIRequests    = &Counter{name: "reso_api_requests_total", help: "HTTP requests sent to the RESO API, including pages and retries"}
+	APIErrors      = &Counter{name: "reso_api_errors_total", help: "RESO API requests that failed or answered with a status other than 200"}
+	CacheHits      = &Counter{name: "reso_cache_hits_total", help: "Queries answered from the query cache"}
+	CacheMisses    = &Counter{name: "reso_cache_misses_total", help: "Cacheable queries that were not in the cache or had expired"}
+	CacheEvictions = &Counter{name: "reso_cache_evictions_total", help: "Cached queries evicted to stay within the cache size"}
+	TokenRequests  = &Counter{name: "reso_token_requests_total", help: "OAuth token requests, blocking or in the background"}
+	TokenRefreshes = &Counter{name: "reso_token_background_refreshes_total", help: "Token requests made in the background before the current token expired"}
+	TokenErrors    = &Counter{name: "reso_token_errors_total", help: "OAuth token requests that failed"}
+)
+
+// counters lists every counter in output order
+var counters = []*Counter{
+	Queries, QueryErrors, APIRequests, APIErrors,
+	CacheHits, CacheMisses, CacheEvictions,
+	TokenRequests, TokenRefreshes, TokenErrors,
+}
+
+// started is when the process began counting
+var started = time.Now()
+
+// Snapshot is a point-in-time copy of every counter
+type Snapshot struct {
+	UptimeSeconds  int64   `json:"uptime_seconds"`
+	Queries        int64   `json:"queries"`
+	QueryErrors    int64   `json:"query_errors"`
+	APIRequests    int64   `json:"api_requests"`
+	APIErrors      int64   `json:"api_errors"`
+	CacheHits      int64   `json:"cache_hits"`
+	CacheMisses    int64   `json:"cache_misses"`
+	CacheEvictions int64   `json:"cache_evictions"`
+	CacheHitRate   float64 `json:"cache_hit_rate"`
+	TokenRequests  int64   `json:"token_requests"`
+	TokenRefreshes int64   `json:"token_background_refreshes"`
+	TokenErrors    int64   `json:"token_errors"`
+}
+
+// Take returns the current value of every counter. The cache hit rate is the share
+// of cacheable queries answered from the cache, or 0 before any were run.
+func Take() *Snapshot {
+	snapshot := &Snapshot{
+		UptimeSeconds:  int64(time.Since(started).Seconds()),
+		Queries:        Queries.Value(),
+		QueryErrors:    QueryErrors.Value(),
+		APIRequests:    APIRequests.Value(),
+		APIErrors:      APIErrors.Value(),
+		CacheHits:      CacheHits.Value(),
+		CacheMisses:    CacheMisses.Value(),
+		CacheEvictions: CacheEvictions.Value(),
+		TokenRequests:  TokenRequests.Value(),
+		TokenRefreshes: TokenRefreshes.Value(),
+		TokenErrors:    TokenErrors.Value(),
+	}
+	if lookups := snapshot.CacheHits + snapshot.CacheMisses; lookups > 0 {
+		snapshot.CacheHitRate = float64(snapshot.CacheHits) / float64(lookups)
+	}
+	return snapshot
+}
+
+// WriteText writes every counter in the Prometheus text exposition format
+func WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP reso_uptime_seconds Seconds since the server started\n# TYPE reso_uptime_seconds gauge\nreso_uptime_seconds %d\n", int64(time.Since(started).Seconds())); err != nil {
+		return err
+	}
+	for _, c := range counters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
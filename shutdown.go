@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout is how long in-flight requests may keep running once shutdown starts
+const shutdownTimeout = 10 * time.Second
+
+// cancelGrace is how long cancelled requests get to return their errors and replies
+const cancelGrace = 2 * time.Second
+
+// shutdownSignals returns a channel that receives SIGINT and SIGTERM
+func shutdownSignals() <-chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	return signals
+}
+
+// beginRequest registers a message as in flight, refusing it once shutdown has started
+func (s *MCPServer) beginRequest() bool {
+	s.shutdownMutex.Lock()
+	defer s.shutdownMutex.Unlock()
+	if s.closing {
+		return false
+	}
+	s.active++
+	s.inflight.Add(1)
+	return true
+}
+
+// endRequest marks a message registered by beginRequest as finished
+func (s *MCPServer) endRequest() {
+	s.shutdownMutex.Lock()
+	s.active--
+	s.shutdownMutex.Unlock()
+	s.inflight.Done()
+}
+
+// Shutdown stops accepting messages and waits up to timeout for in-flight ones to
+// finish and reply. Requests still running after that are cancelled through the API
+// client and given cancelGrace to return. The metadata cache file is written while
+// metadata loads, inside a request, so draining requests also flushes it.
+func (s *MCPServer) Shutdown(timeout time.Duration) {
+	s.shutdownMutex.Lock()
+	s.closing = true
+	active := s.active
+	s.shutdownMutex.Unlock()
+
+	if active > 0 {
+		log.Printf("Shutdown: waiting up to %s for %d in-flight requests", timeout, active)
+	}
+	if active > 0 && !waitTimeout(&s.inflight, timeout) {
+		log.Printf("Shutdown: cancelling requests still running after %s", timeout)
+		if s.apiClient != nil {
+			s.apiClient.Close()
+		}
+		if !waitTimeout(&s.inflight, cancelGrace) {
+			log.Printf("Shutdown: abandoning requests that did not stop within %s of cancellation", cancelGrace)
+		}
+	}
+
+	// Any later API request fails immediately
+	if s.apiClient != nil {
+		s.apiClient.Close()
+	}
+}
+
+// waitTimeout waits for wg, reporting false if timeout passes first
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// defaultFieldAliases maps friendly names, lower-cased, to the RESO field they stand for
+var defaultFieldAliases = map[string]string{
+	"bedrooms":   "BedroomsTotal",
+	"beds":       "BedroomsTotal",
+	"bathrooms":  "BathroomsTotalInteger",
+	"baths":      "BathroomsTotalInteger",
+	"price":      "ListPrice",
+	"soldprice":  "ClosePrice",
+	"sqft":       "LivingArea",
+	"squarefeet": "LivingArea",
+	"acres":      "LotSizeAcres",
+	"status":     "StandardStatus",
+	"type":       "PropertyType",
+	"subtype":    "PropertySubType",
+	"zip":        "PostalCode",
+	"zipcode":    "PostalCode",
+	"state":      "StateOrProvince",
+	"county":     "CountyOrParish",
+	"address":    "UnparsedAddress",
+	"remarks":    "PublicRemarks",
+	"photos":     "PhotosCount",
+	"pool":       "PoolPrivateYN",
+	"garage":     "GarageSpaces",
+	"dom":        "DaysOnMarket",
+	"listdate":   "ListingContractDate",
+	"modified":   "ModificationTimestamp",
+	"updated":    "ModificationTimestamp",
+	"lat":        "Latitude",
+	"lon":        "Longitude",
+	"lng":        "Longitude",
+}
+
+// mergeFieldAliases returns the built-in aliases with the configured ones layered on
+// top. Keys are lower-cased, and an override with an empty field removes a built-in.
+func mergeFieldAliases(overrides map[string]string) map[string]string {
+	aliases := make(map[string]string, len(defaultFieldAliases)+len(overrides))
+	for alias, field := range defaultFieldAliases {
+		aliases[alias] = field
+	}
+	for alias, field := range overrides {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if field = strings.TrimSpace(field); field == "" {
+			delete(aliases, alias)
+		} else {
+			aliases[alias] = field
+		}
+	}
+	return aliases
+}
+
+// fieldAliaser translates friendly field names for one entity
+type fieldAliaser struct {
+	aliases map[string]string
+	entity  string
+	parser  *metadata.MetadataParser
+	applied map[string]string
+}
+
+// resolve returns the field an alias stands for. Names that are real fields, unknown
+// aliases and aliases whose field the entity lacks are returned unchanged.
+func (a *fieldAliaser) resolve(name string) string {
+	field, ok := a.aliases[strings.ToLower(name)]
+	if !ok || field == name {
+		return name
+	}
+	if a.parser != nil && (a.parser.HasField(a.entity, name) || !a.parser.HasField(a.entity, field)) {
+		return name
+	}
+	a.applied[name] = field
+	return field
+}
+
+// translateList translates each field of a comma-separated list such as select
+func (a *fieldAliaser) translateList(list string) string {
+	fields := splitFieldList(list)
+	for i, field := range fields {
+		fields[i] = a.resolve(field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// translateOrderBy translates the field of each orderby clause, keeping its direction
+func (a *fieldAliaser) translateOrderBy(orderBy string) string {
+	clauses := strings.Split(orderBy, ",")
+	for i, clause := range clauses {
+		parts := strings.Fields(clause)
+		if len(parts) == 0 {
+			continue
+		}
+		parts[0] = a.resolve(parts[0])
+		clauses[i] = strings.Join(parts, " ")
+	}
+	return strings.Join(clauses, ",")
+}
+
+// translateFilter translates the field names of a filter. Keywords, function names,
+// paths and bare words compared against a field are left alone.
+func (a *fieldAliaser) translateFilter(filter string) string {
+	runes := []rune(filter)
+	tokens := tokenizeFilter(runes)
+
+	var out strings.Builder
+	last := 0
+	for i, token := range tokens {
+		if token.kind != 'i' || strings.Contains(token.text, "/") || odataKeywords[strings.ToLower(token.text)] {
+			continue
+		}
+		if i+1 < len(tokens) && tokens[i+1].text == "(" {
+			continue
+		}
+		if i > 0 && bareValueOperators[strings.ToLower(tokens[i-1].text)] {
+			continue
+		}
+		if field := a.resolve(token.text); field != token.text {
+			out.WriteString(string(runes[last:token.start]))
+			out.WriteString(field)
+			last = token.end
+		}
+	}
+	out.WriteString(string(runes[last:]))
+	return out.String()
+}
+
+// translateFilters rewrites the fields of structured filter entries, descending into
+// nested groups and {not: ...} negations. Entries are copied, never modified in place.
+func (a *fieldAliaser) translateFilters(filters []interface{}) []interface{} {
+	translated := make([]interface{}, len(filters))
+	for i, raw := range filters {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			translated[i] = raw
+			continue
+		}
+		copied := make(map[string]interface{}, len(item))
+		for key, value := range item {
+			copied[key] = value
+		}
+		if not, ok := item["not"].(map[string]interface{}); ok {
+			copied["not"] = a.translateFilters([]interface{}{not})[0]
+		}
+		if nested, ok := item["filters"].([]interface{}); ok {
+			copied["filters"] = a.translateFilters(nested)
+		}
+		if field, ok := item["field"].(string); ok {
+			copied["field"] = a.resolve(field)
+		}
+		translated[i] = copied
+	}
+	return translated
+}
+
+// applyFieldAliases rewrites the select, filter and orderby arguments, and the fields
+// of structured filters, from friendly aliases to RESO field names. It returns the
+// rewritten arguments and the translations made, formatted as "alias → Field".
+func applyFieldAliases(args map[string]interface{}, aliases map[string]string, parser *metadata.MetadataParser) (map[string]interface{}, []string) {
+	entity, _ := args["entity"].(string)
+	a := &fieldAliaser{aliases: aliases, entity: entity, parser: parser, applied: make(map[string]string)}
+
+	rewritten := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		rewritten[key] = value
+	}
+	if selectFields, ok := args["select"].(string); ok {
+		rewritten["select"] = a.translateList(selectFields)
+	}
+	if filter, ok := args["filter"].(string); ok {
+		rewritten["filter"] = a.translateFilter(filter)
+	}
+	if orderBy, ok := args["orderby"].(string); ok {
+		rewritten["orderby"] = a.translateOrderBy(orderBy)
+	}
+	if filters, ok := args["filters"].([]interface{}); ok {
+		rewritten["filters"] = a.translateFilters(filters)
+	}
+
+	var applied []string
+	for alias, field := range a.applied {
+		applied = append(applied, fmt.Sprintf("%s → %s", alias, field))
+	}
+	sort.Strings(applied)
+	return rewritten, applied
+}
+
+// formatFieldAliases renders the alias table for reso_help, marking configured entries
+func formatFieldAliases(aliases, overrides map[string]string) string {
+	configured := make(map[string]bool, len(overrides))
+	for alias := range overrides {
+		configured[strings.ToLower(strings.TrimSpace(alias))] = true
+	}
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	out.WriteString("| Alias | RESO Field | Source |\n")
+	out.WriteString("|-------|------------|--------|\n")
+	for _, alias := range names {
+		source := "built-in"
+		if configured[alias] {
+			source = "configured"
+		}
+		out.WriteString(fmt.Sprintf("| %s | %s | %s |\n", alias, aliases[alias], source))
+	}
+	return out.String()
+}
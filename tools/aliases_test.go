@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyFieldAliasesNestedFilters(t *testing.T) {
+	args := map[string]interface{}{
+		"entity": "Property",
+		"filters": []interface{}{
+			map[string]interface{}{"field": "city", "op": "eq", "value": "Seattle"},
+			map[string]interface{}{
+				"logic": "or",
+				"filters": []interface{}{
+					map[string]interface{}{"field": "beds", "op": "ge", "value": 3},
+					map[string]interface{}{"not": map[string]interface{}{"field": "status", "op": "eq", "value": "Closed"}},
+				},
+			},
+			map[string]interface{}{"not": map[string]interface{}{
+				"filters": []interface{}{
+					map[string]interface{}{"field": "price", "op": "gt", "value": 900000},
+				},
+			}},
+		},
+	}
+
+	rewritten, applied := applyFieldAliases(args, mergeFieldAliases(nil), nil)
+
+	want := []interface{}{
+		map[string]interface{}{"field": "city", "op": "eq", "value": "Seattle"},
+		map[string]interface{}{
+			"logic": "or",
+			"filters": []interface{}{
+				map[string]interface{}{"field": "BedroomsTotal", "op": "ge", "value": 3},
+				map[string]interface{}{"not": map[string]interface{}{"field": "StandardStatus", "op": "eq", "value": "Closed"}},
+			},
+		},
+		map[string]interface{}{"not": map[string]interface{}{
+			"filters": []interface{}{
+				map[string]interface{}{"field": "ListPrice", "op": "gt", "value": 900000},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(rewritten["filters"], want) {
+		t.Errorf("filters = %#v, want %#v", rewritten["filters"], want)
+	}
+	wantApplied := []string{"beds → BedroomsTotal", "price → ListPrice", "status → StandardStatus"}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("applied = %q, want %q", applied, wantApplied)
+	}
+
+	// The caller's arguments are left untouched
+	nested := args["filters"].([]interface{})[1].(map[string]interface{})["filters"].([]interface{})
+	if field := nested[0].(map[string]interface{})["field"]; field != "beds" {
+		t.Errorf("original nested field = %v, want it unchanged", field)
+	}
+}
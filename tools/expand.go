@@ -0,0 +1,390 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// expandOptions are the query options accepted inside an expand segment
+var expandOptions = map[string]bool{
+	"$select": true, "$filter": true, "$orderby": true, "$top": true,
+	"$skip": true, "$expand": true, "$count": true,
+}
+
+// expandSegment is one comma-separated entry of an expand clause, e.g.
+// Media($select=MediaURL;$orderby=Order asc)
+type expandSegment struct {
+	Raw     string
+	Name    string
+	Options map[string]string
+}
+
+// parseExpand splits an expand clause into segments and their nested options,
+// reporting unbalanced parentheses or quotes against the offending segment
+func parseExpand(expand string) ([]expandSegment, error) {
+	parts, err := splitTopLevel(expand, ',')
+	if err != nil {
+		return nil, fmt.Errorf("invalid expand %q: %s", expand, err.Error())
+	}
+
+	var segments []expandSegment
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid expand %q: empty segment", expand)
+		}
+
+		segment := expandSegment{Raw: part, Name: part}
+		if open := strings.Index(part, "("); open >= 0 {
+			if !strings.HasSuffix(part, ")") {
+				return nil, fmt.Errorf("invalid expand segment %q: unexpected text after the closing ')'", part)
+			}
+			segment.Name = strings.TrimSpace(part[:open])
+			options, err := parseExpandOptions(part[open+1 : len(part)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid expand segment %q: %s", part, err.Error())
+			}
+			segment.Options = options
+		}
+		if !fieldNamePattern.MatchString(segment.Name) {
+			return nil, fmt.Errorf("invalid expand segment %q: %q is not a valid navigation property name", part, segment.Name)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// parseExpandOptions parses the ';'-separated $option=value list inside an expand segment
+func parseExpandOptions(inner string) (map[string]string, error) {
+	parts, err := splitTopLevel(inner, ';')
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]string)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, found := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !found || !expandOptions[name] {
+			return nil, fmt.Errorf("%q is not a valid expand option (use $select, $filter, $orderby, $top, $skip, $expand or $count separated by ';')", part)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("%s has no value", name)
+		}
+		options[name] = value
+	}
+	return options, nil
+}
+
+// splitTopLevel splits s on sep outside parentheses and single-quoted literals,
+// failing when either is left unbalanced
+func splitTopLevel(s string, sep rune) ([]string, error) {
+	var parts []string
+	depth := 0
+	inQuote := false
+	start := 0
+
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			// A doubled '' escape toggles twice and leaves the state unchanged
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unmatched ')' at position %d", i+1)
+			}
+		case r == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("unterminated string literal (check for a missing quote, and escape quotes inside values as '')")
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("%d unclosed '('", depth)
+	}
+	return append(parts, s[start:]), nil
+}
+
+// expandChains lists every path of an expand clause from the top level down, e.g.
+// ["Media", "ListAgent", "ListAgent/Office"] for Media,ListAgent($expand=Office).
+// Malformed nested clauses are left to parseExpand to report.
+func expandChains(segments []expandSegment, prefix string) []string {
+	var chains []string
+	for _, segment := range segments {
+		chain := segment.Name
+		if prefix != "" {
+			chain = prefix + "/" + segment.Name
+		}
+		chains = append(chains, chain)
+		if nested := segment.Options["$expand"]; nested != "" {
+			if children, err := parseExpand(nested); err == nil {
+				chains = append(chains, expandChains(children, chain)...)
+			}
+		}
+	}
+	return chains
+}
+
+// capExpandTop adds $top=top to every expanded collection of entity that sets no $top
+// of its own, including nested expands, and returns the rewritten clause and the
+// paths capped. Other options keep their text and order. With metadata, single-valued
+// navigation properties are left alone; without it every expand is capped.
+func capExpandTop(expand, entity string, top int, parser *metadata.MetadataParser) (string, []string, error) {
+	if expand == "" || top <= 0 {
+		return expand, nil, nil
+	}
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var capped []string
+	parts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		target, isCollection := "", true
+		if parser != nil {
+			if entityInfo, ok := parser.GetEntityInfo(entity); ok {
+				if nav, ok := entityInfo.NavigationProperties[segment.Name]; ok {
+					target, isCollection = nav.TargetType, nav.IsCollection
+				}
+			}
+		}
+
+		var options []string
+		if open := strings.Index(segment.Raw, "("); open >= 0 {
+			inner, _ := splitTopLevel(segment.Raw[open+1:len(segment.Raw)-1], ';')
+			for _, option := range inner {
+				if option = strings.TrimSpace(option); option != "" {
+					options = append(options, option)
+				}
+			}
+		}
+
+		_, hasTop := segment.Options["$top"]
+		capSegment := isCollection && !hasTop
+		if capSegment {
+			capped = append(capped, segment.Name)
+		}
+
+		// Rewrite a nested $expand against the navigation property's target entity
+		for i, option := range options {
+			name, value, _ := strings.Cut(option, "=")
+			if strings.ToLower(strings.TrimSpace(name)) != "$expand" {
+				continue
+			}
+			nested, nestedCapped, err := capExpandTop(strings.TrimSpace(value), target, top, parser)
+			if err != nil {
+				return "", nil, err
+			}
+			options[i] = strings.TrimSpace(name) + "=" + nested
+			for _, path := range nestedCapped {
+				capped = append(capped, segment.Name+"/"+path)
+			}
+		}
+
+		if capSegment {
+			options = append(options, fmt.Sprintf("$top=%d", top))
+		}
+
+		if len(options) == 0 {
+			parts = append(parts, segment.Name)
+		} else {
+			parts = append(parts, segment.Name+"("+strings.Join(options, ";")+")")
+		}
+	}
+	return strings.Join(parts, ","), capped, nil
+}
+
+// nestedExpandChains returns the expand paths more than one level deep, and the
+// deepest level reached
+func nestedExpandChains(expand string) ([]string, int) {
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return nil, 0
+	}
+	var nested []string
+	depth := 0
+	for _, chain := range expandChains(segments, "") {
+		levels := strings.Count(chain, "/") + 1
+		if levels > depth {
+			depth = levels
+		}
+		if levels > 1 {
+			nested = append(nested, chain)
+		}
+	}
+	return nested, depth
+}
+
+// checkExpandDepth rejects an expand clause nested deeper than maxDepth levels,
+// naming the offending chains; maxDepth 0 allows any depth
+func checkExpandDepth(expand string, maxDepth int) error {
+	if maxDepth <= 0 {
+		return nil
+	}
+	nested, depth := nestedExpandChains(expand)
+	if depth <= maxDepth {
+		return nil
+	}
+	return fmt.Errorf("expand nests %d levels deep (%s) but this provider supports %d (max_expand_depth). Expand fewer levels and query the related entity separately, e.g. with reso_query keys", depth, strings.Join(nested, ", "), maxDepth)
+}
+
+// nestedExpandHint explains a rejected query that used a multi-level expand, since
+// providers without nested $expand support answer with a generic 400
+func nestedExpandHint(expand string, err error) string {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+		return ""
+	}
+	nested, _ := nestedExpandChains(expand)
+	if len(nested) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nThe query uses a nested expand (%s), which some providers reject. Try expanding one level and querying the related entity separately. Set RESO_MAX_EXPAND_DEPTH=1 to catch this before the query is sent.", strings.Join(nested, ", "))
+}
+
+// expandFailure counts the records whose expansion of one path carried an error
+type expandFailure struct {
+	Records int
+	Message string
+}
+
+// expandErrors finds errors a provider embedded in expanded collections instead of
+// failing the whole request: an <Nav>@...error annotation, an error object in place of
+// the collection, or error objects among its items. It returns one warning per failed
+// expand path, in expand order, so an empty collection is not mistaken for no data.
+func expandErrors(records []map[string]interface{}, expand string) []string {
+	if expand == "" || len(records) == 0 {
+		return nil
+	}
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return nil
+	}
+
+	failures := make(map[string]*expandFailure)
+	for _, record := range records {
+		scanExpandErrors(record, segments, "", failures)
+	}
+
+	var warnings []string
+	for _, chain := range expandChains(segments, "") {
+		failure, ok := failures[chain]
+		if !ok {
+			continue
+		}
+		warning := fmt.Sprintf("expand %s failed on the server for %d record(s)", chain, failure.Records)
+		if failure.Message != "" {
+			warning += ": " + failure.Message
+		}
+		warnings = append(warnings, warning+fmt.Sprintf(". An empty or missing %s there means the expand errored, not that there is no data", chain))
+	}
+	return warnings
+}
+
+// scanExpandErrors records the expand paths of one record that carry embedded errors,
+// descending into nested expands of the items that did load
+func scanExpandErrors(record map[string]interface{}, segments []expandSegment, prefix string, failures map[string]*expandFailure) {
+	for _, segment := range segments {
+		chain := segment.Name
+		if prefix != "" {
+			chain = prefix + "/" + segment.Name
+		}
+		var nested []expandSegment
+		if clause := segment.Options["$expand"]; clause != "" {
+			nested, _ = parseExpand(clause)
+		}
+
+		message, failed := "", false
+		for key, value := range record {
+			annotation, isAnnotation := strings.CutPrefix(key, segment.Name+"@")
+			if isAnnotation && strings.HasSuffix(strings.ToLower(annotation), "error") {
+				message, failed = embeddedErrorMessage(value), true
+			}
+		}
+
+		switch value := record[segment.Name].(type) {
+		case map[string]interface{}:
+			if isEmbeddedError(value) {
+				message, failed = embeddedErrorMessage(value), true
+			} else if nested != nil {
+				scanExpandErrors(value, nested, chain, failures)
+			}
+		case []interface{}:
+			for _, item := range value {
+				entry, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if isEmbeddedError(entry) {
+					message, failed = embeddedErrorMessage(entry), true
+				} else if nested != nil {
+					scanExpandErrors(entry, nested, chain, failures)
+				}
+			}
+		}
+
+		if !failed {
+			continue
+		}
+		failure, ok := failures[chain]
+		if !ok {
+			failure = &expandFailure{}
+			failures[chain] = failure
+		}
+		failure.Records++
+		if failure.Message == "" {
+			failure.Message = message
+		}
+	}
+}
+
+// isEmbeddedError reports whether an expanded object is an OData error rather than an
+// entity: {"error": {...}} or {"@odata.error": {...}}
+func isEmbeddedError(value map[string]interface{}) bool {
+	for _, key := range []string{"error", "@odata.error"} {
+		if _, ok := value[key].(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedErrorMessage extracts the message of an embedded OData error, which may be
+// the error object itself, wrapped in "error", or a plain string
+func embeddedErrorMessage(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if message, ok := v["message"].(string); ok {
+			if code, ok := v["code"].(string); ok && code != "" {
+				return fmt.Sprintf("%s (%s)", message, code)
+			}
+			return message
+		}
+		for _, key := range []string{"error", "@odata.error"} {
+			if inner, ok := v[key]; ok {
+				return embeddedErrorMessage(inner)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,578 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// explainModes are the values of reso_query's explain argument
+var explainModes = []string{"only", "with_results"}
+
+// explainFieldLabels are plain-English names for fields whose split CamelCase name
+// reads poorly
+var explainFieldLabels = map[string]string{
+	"StandardStatus":        "status",
+	"MlsStatus":             "MLS status",
+	"BedroomsTotal":         "bedrooms",
+	"BathroomsTotalInteger": "bathrooms",
+	"LotSizeAcres":          "lot size",
+	"PostalCode":            "ZIP code",
+	"StateOrProvince":       "state",
+	"ModificationTimestamp": "last modified time",
+	"OnMarketDate":          "on-market date",
+	"ListAgentMlsId":        "listing agent MLS ID",
+	"ListOfficeMlsId":       "listing office MLS ID",
+}
+
+// explainOperators phrase each comparison operator, for ordinary values and for dates
+var explainOperators = map[string][2]string{
+	"eq": {"is", "is"},
+	"ne": {"is not", "is not"},
+	"gt": {"is more than", "is after"},
+	"ge": {"is at least", "is on or after"},
+	"lt": {"is less than", "is before"},
+	"le": {"is at most", "is on or before"},
+}
+
+// explainStringFunctions phrase the boolean string functions, as is and negated
+var explainStringFunctions = map[string][2]string{
+	"contains":   {"contains", "does not contain"},
+	"startswith": {"starts with", "does not start with"},
+	"endswith":   {"ends with", "does not end with"},
+}
+
+// queryExplainer turns parsed query parameters into a plain-English description,
+// using the metadata (when loaded) to show enum display names and sort directions
+type queryExplainer struct {
+	parser *metadata.MetadataParser
+	entity string
+}
+
+// explainQuery describes what a reso_query call asks for. filter is the filter as the
+// user wrote it, before near adds its bounding box.
+func explainQuery(params *api.QueryParams, filter string, near *nearSearch, lookup *keyLookup, parser *metadata.MetadataParser) string {
+	e := &queryExplainer{parser: parser, entity: params.Entity}
+
+	var out strings.Builder
+	out.WriteString("Query Explanation\n")
+	out.WriteString("=================\n\n")
+	out.WriteString(fmt.Sprintf("Searches %s records", params.Entity))
+	if params.Search != "" {
+		out.WriteString(fmt.Sprintf(" matching the text %q", params.Search))
+	}
+	out.WriteString(".\n")
+
+	if lookup != nil {
+		out.WriteString(fmt.Sprintf("- Looks up %d specific records by %s.\n", len(lookup.Keys), e.fieldLabel(lookup.Field)))
+	}
+	if params.DeltaLink != "" {
+		out.WriteString("- Only the records added, changed or removed since the delta link was issued, using the query it carries.\n")
+	}
+	if filter != "" {
+		out.WriteString(fmt.Sprintf("- Only records where %s.\n", e.explainFilter(filter)))
+	}
+	if near != nil {
+		out.WriteString(fmt.Sprintf("- Within %s miles of %s, %s.\n", strconv.FormatFloat(near.RadiusMiles, 'f', -1, 64),
+			strconv.FormatFloat(near.Lat, 'f', -1, 64), strconv.FormatFloat(near.Lon, 'f', -1, 64)))
+	}
+	if params.Apply != "" {
+		out.WriteString(fmt.Sprintf("- Aggregates the matching records with `%s` instead of listing them.\n", params.Apply))
+	}
+	if params.OrderBy != "" {
+		out.WriteString(fmt.Sprintf("- Sorted by %s.\n", e.explainOrderBy(params.OrderBy)))
+	}
+	if params.Select != "" {
+		fields := splitFieldList(params.Select)
+		out.WriteString(fmt.Sprintf("- Returns %d fields: %s.\n", len(fields), strings.Join(fields, ", ")))
+	} else if params.Apply == "" && params.DeltaLink == "" {
+		out.WriteString("- Returns every field.\n")
+	}
+	if params.Expand != "" {
+		out.WriteString(e.explainExpand(params.Expand))
+	}
+
+	switch {
+	case params.FetchAll:
+		maxRecords := params.MaxRecords
+		if maxRecords <= 0 {
+			maxRecords = api.DefaultMaxRecords
+		}
+		out.WriteString(fmt.Sprintf("- Collects every matching record across pages, up to %d.\n", maxRecords))
+	case lookup != nil:
+	case params.Top > 0 && params.Skip > 0:
+		out.WriteString(fmt.Sprintf("- Returns up to %d records, skipping the first %d.\n", params.Top, params.Skip))
+	case params.Top > 0:
+		out.WriteString(fmt.Sprintf("- Returns up to %d records.\n", params.Top))
+	case params.Skip > 0:
+		out.WriteString(fmt.Sprintf("- Skips the first %d records.\n", params.Skip))
+	}
+	return out.String()
+}
+
+// explainOrderBy describes each orderby clause with a direction suited to the field
+func (e *queryExplainer) explainOrderBy(orderBy string) string {
+	var parts []string
+	for _, clause := range strings.Split(orderBy, ",") {
+		fields := strings.Fields(clause)
+		if len(fields) == 0 {
+			continue
+		}
+		desc := len(fields) > 1 && strings.EqualFold(fields[1], "desc")
+		var direction string
+		switch kind := e.fieldKind(fields[0]); {
+		case kind == "date" && desc:
+			direction = "newest first"
+		case kind == "date":
+			direction = "oldest first"
+		case kind == "number" && desc:
+			direction = "highest first"
+		case kind == "number":
+			direction = "lowest first"
+		case desc:
+			direction = "Z to A"
+		default:
+			direction = "A to Z"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", e.fieldLabel(fields[0]), direction))
+	}
+	return strings.Join(parts, ", then ")
+}
+
+// explainExpand describes each expanded relationship and its options
+func (e *queryExplainer) explainExpand(expand string) string {
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return fmt.Sprintf("- Includes related records: %s.\n", expand)
+	}
+
+	var out strings.Builder
+	for _, segment := range segments {
+		out.WriteString(fmt.Sprintf("- Includes related %s records", segment.Name))
+		nested := &queryExplainer{parser: e.parser, entity: e.navigationTarget(segment.Name)}
+		var details []string
+		if filter := segment.Options["$filter"]; filter != "" {
+			details = append(details, "only where "+nested.explainFilter(filter))
+		}
+		if orderBy := segment.Options["$orderby"]; orderBy != "" {
+			details = append(details, "sorted by "+nested.explainOrderBy(orderBy))
+		}
+		if top := segment.Options["$top"]; top != "" {
+			details = append(details, "at most "+top)
+		}
+		if selected := segment.Options["$select"]; selected != "" {
+			details = append(details, "with fields "+strings.Join(splitFieldList(selected), ", "))
+		}
+		if inner := segment.Options["$expand"]; inner != "" {
+			details = append(details, "each with related "+inner)
+		}
+		if len(details) > 0 {
+			out.WriteString(", " + strings.Join(details, "; "))
+		}
+		out.WriteString(".\n")
+	}
+	return out.String()
+}
+
+// navigationTarget returns the entity a navigation property of e.entity points to, or
+// the property name itself when the metadata does not say
+func (e *queryExplainer) navigationTarget(name string) string {
+	if e.parser != nil {
+		for _, nav := range e.parser.GetNavigationProperties(e.entity) {
+			if nav.Name == name {
+				return nav.TargetType
+			}
+		}
+	}
+	return name
+}
+
+// explainFilter describes an OData filter expression. Parts it cannot phrase are
+// quoted as written, so the explanation never drops a condition.
+func (e *queryExplainer) explainFilter(filter string) string {
+	runes := []rune(filter)
+	p := &filterExplainer{e: e, runes: runes, tokens: tokenizeFilter(runes)}
+	text := p.or()
+	if p.pos < len(p.tokens) {
+		return fmt.Sprintf("`%s`", filter)
+	}
+	return text
+}
+
+// filterExplainer is a recursive descent reader over filter tokens that phrases
+// each condition as it goes
+type filterExplainer struct {
+	e      *queryExplainer
+	runes  []rune
+	tokens []filterToken
+	pos    int
+}
+
+// explainedCondition is one phrased condition, keeping the comparison parts so range
+// pairs on the same field can be merged
+type explainedCondition struct {
+	text     string
+	field    string
+	operator string
+	value    string
+}
+
+// peek returns the current token's text, or "" at the end
+func (p *filterExplainer) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// peekKeyword reports whether the current token is the given keyword
+func (p *filterExplainer) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == 'i' && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+// or phrases a chain of conditions joined with 'or'
+func (p *filterExplainer) or() string {
+	parts := []string{p.and()}
+	for p.peekKeyword("or") {
+		p.pos++
+		parts = append(parts, p.and())
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts, " or ")
+}
+
+// and phrases a chain of conditions joined with 'and', merging a lower and an upper
+// bound on the same field into one "between" condition
+func (p *filterExplainer) and() string {
+	conditions := []explainedCondition{p.unary()}
+	for p.peekKeyword("and") {
+		p.pos++
+		conditions = append(conditions, p.unary())
+	}
+
+	var parts []string
+	merged := make(map[int]bool)
+	for i, condition := range conditions {
+		if merged[i] {
+			continue
+		}
+		if condition.operator == "ge" || condition.operator == "gt" {
+			for j := i + 1; j < len(conditions); j++ {
+				upper := conditions[j]
+				if !merged[j] && upper.field == condition.field && (upper.operator == "le" || upper.operator == "lt") {
+					merged[j] = true
+					condition.text = fmt.Sprintf("%s is between %s and %s", p.e.fieldLabel(condition.field), condition.value, upper.value)
+					break
+				}
+			}
+		}
+		parts = append(parts, condition.text)
+	}
+	if len(parts) <= 1 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// unary phrases a condition, negated when it starts with 'not'
+func (p *filterExplainer) unary() explainedCondition {
+	if p.peekKeyword("not") {
+		p.pos++
+		inner := p.unary()
+		return explainedCondition{text: "not (" + inner.text + ")"}
+	}
+	return p.primary()
+}
+
+// primary phrases a parenthesised group, a string function call, an 'in' list or a
+// comparison, falling back to the raw text of anything else
+func (p *filterExplainer) primary() explainedCondition {
+	start := p.pos
+	if p.peek() == "(" {
+		p.pos++
+		inner := p.or()
+		if p.peek() == ")" {
+			p.pos++
+			return explainedCondition{text: "(" + inner + ")"}
+		}
+		return p.raw(start)
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'i' {
+		return p.raw(start)
+	}
+
+	name := p.tokens[p.pos].text
+	if phrase, ok := explainStringFunctions[strings.ToLower(name)]; ok && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+		p.pos += 2
+		field, ignoreCase := p.operand()
+		if p.peek() != "," {
+			return p.raw(start)
+		}
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 's' {
+			return p.raw(start)
+		}
+		value := p.stringValue(p.tokens[p.pos].text)
+		p.pos++
+		if p.peek() != ")" || field == "" {
+			return p.raw(start)
+		}
+		p.pos++
+		verb := phrase[0]
+		if p.peekKeyword("eq") && p.pos+1 < len(p.tokens) && (strings.EqualFold(p.tokens[p.pos+1].text, "true") || strings.EqualFold(p.tokens[p.pos+1].text, "false")) {
+			if strings.EqualFold(p.tokens[p.pos+1].text, "false") {
+				verb = phrase[1]
+			}
+			p.pos += 2
+		}
+		text := fmt.Sprintf("%s %s %q", p.e.fieldLabel(field), verb, value)
+		if ignoreCase {
+			text += " (ignoring case)"
+		}
+		return explainedCondition{text: text}
+	}
+
+	field, ignoreCase := p.operand()
+	if field == "" {
+		return p.raw(start)
+	}
+	suffix := ""
+	if ignoreCase {
+		suffix = " (ignoring case)"
+	}
+
+	if p.peekKeyword("in") {
+		p.pos++
+		if p.peek() != "(" {
+			return p.raw(start)
+		}
+		p.pos++
+		var values []string
+		for p.pos < len(p.tokens) && p.peek() != ")" {
+			if p.peek() != "," {
+				values = append(values, p.value(field))
+			} else {
+				p.pos++
+			}
+		}
+		if p.peek() != ")" {
+			return p.raw(start)
+		}
+		p.pos++
+		return explainedCondition{text: fmt.Sprintf("%s is %s%s", p.e.fieldLabel(field), strings.Join(values, " or "), suffix)}
+	}
+
+	if p.peekKeyword("has") {
+		p.pos++
+		return explainedCondition{text: fmt.Sprintf("%s includes %s%s", p.e.fieldLabel(field), p.value(field), suffix)}
+	}
+
+	operator := strings.ToLower(p.peek())
+	phrases, ok := explainOperators[operator]
+	if !ok || p.tokens[p.pos].kind != 'i' {
+		return p.raw(start)
+	}
+	p.pos++
+	if p.pos >= len(p.tokens) {
+		return p.raw(start)
+	}
+
+	if p.peekKeyword("null") {
+		p.pos++
+		if operator == "eq" {
+			return explainedCondition{text: fmt.Sprintf("%s is empty", p.e.fieldLabel(field))}
+		}
+		return explainedCondition{text: fmt.Sprintf("%s is filled in", p.e.fieldLabel(field))}
+	}
+
+	dateToken := p.tokens[p.pos].kind == 'n' && strings.Contains(p.tokens[p.pos].text, "-")
+	value := p.value(field)
+	phrase := phrases[0]
+	if dateToken || p.e.fieldKind(field) == "date" {
+		phrase = phrases[1]
+	}
+	return explainedCondition{
+		text:     fmt.Sprintf("%s %s %s%s", p.e.fieldLabel(field), phrase, value, suffix),
+		field:    field,
+		operator: operator,
+		value:    value,
+	}
+}
+
+// operand reads a field name, possibly wrapped in tolower() or toupper(), reporting
+// whether case is ignored. It returns "" for anything else.
+func (p *filterExplainer) operand() (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'i' {
+		return "", false
+	}
+	name := p.tokens[p.pos].text
+	lower := strings.ToLower(name)
+	if (lower == "tolower" || lower == "toupper") && p.pos+3 < len(p.tokens) && p.tokens[p.pos+1].text == "(" &&
+		p.tokens[p.pos+2].kind == 'i' && p.tokens[p.pos+3].text == ")" {
+		field := p.tokens[p.pos+2].text
+		p.pos += 4
+		return field, true
+	}
+	if odataKeywords[name] || strings.ContainsAny(name, "/$") || (p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(") {
+		return "", false
+	}
+	p.pos++
+	return name, false
+}
+
+// value phrases the literal compared with field: enum display names, currency and
+// areas for numbers, dates as written
+func (p *filterExplainer) value(field string) string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case 's':
+		return p.e.enumDisplay(field, p.stringValue(token.text))
+	case 'n':
+		if number, err := strconv.ParseFloat(token.text, 64); err == nil {
+			return humanizeValue(field, number)
+		}
+		return token.text
+	case 'i':
+		// Enum literal such as org.reso.metadata.enums.StandardStatus'Active'
+		if p.pos < len(p.tokens) && p.tokens[p.pos].kind == 's' && p.tokens[p.pos].start == token.end {
+			literal := p.tokens[p.pos].text
+			p.pos++
+			return p.e.enumDisplay(field, p.stringValue(literal))
+		}
+		if strings.EqualFold(token.text, "now") && p.peek() == "(" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == ")" {
+			p.pos += 2
+			return "now"
+		}
+		if strings.EqualFold(token.text, "true") {
+			return "yes"
+		}
+		if strings.EqualFold(token.text, "false") {
+			return "no"
+		}
+		return token.text
+	}
+	return token.text
+}
+
+// stringValue unquotes a string literal token
+func (p *filterExplainer) stringValue(literal string) string {
+	literal = strings.TrimPrefix(literal, "'")
+	literal = strings.TrimSuffix(literal, "'")
+	return strings.ReplaceAll(literal, "''", "'")
+}
+
+// raw skips from start to the end of the current condition and returns its text as
+// written: up to the next top-level 'and' or 'or', or an unmatched ')'
+func (p *filterExplainer) raw(start int) explainedCondition {
+	p.pos = start
+	depth := 0
+	for p.pos < len(p.tokens) {
+		text := p.peek()
+		if depth == 0 && (p.peekKeyword("and") || p.peekKeyword("or") || text == ")") && p.pos > start {
+			break
+		}
+		switch text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		p.pos++
+		if depth < 0 {
+			break
+		}
+	}
+	if p.pos == start {
+		if p.pos < len(p.tokens) {
+			p.pos++
+		}
+		if start >= len(p.tokens) {
+			return explainedCondition{text: "(incomplete condition)"}
+		}
+	}
+	from := p.tokens[start].start
+	to := p.tokens[p.pos-1].end
+	return explainedCondition{text: fmt.Sprintf("`%s`", strings.TrimSpace(string(p.runes[from:to])))}
+}
+
+// fieldLabel returns a readable name for a field: a known label, or the CamelCase name
+// split into lower-case words, keeping acronyms such as MLS and YN intact
+func (e *queryExplainer) fieldLabel(field string) string {
+	if label, ok := explainFieldLabels[field]; ok {
+		return label
+	}
+	runes := []rune(field)
+	var words []string
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes)
+		if !boundary && unicode.IsUpper(runes[i]) {
+			prevLower := !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			boundary = prevLower || (nextLower && i-start > 1)
+		}
+		if boundary {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	for i, word := range words {
+		if len(word) > 1 && strings.ToUpper(word) == word {
+			continue
+		}
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// fieldKind classifies a field as "date", "number" or "text", from its metadata type
+// when available and otherwise from its name
+func (e *queryExplainer) fieldKind(field string) string {
+	if e.parser != nil {
+		if entity, ok := e.parser.GetEntityInfo(e.entity); ok {
+			if prop, ok := entity.Properties[field]; ok {
+				switch prop.Type {
+				case "Edm.DateTimeOffset", "Edm.Date":
+					return "date"
+				case "Edm.Decimal", "Edm.Double", "Edm.Int16", "Edm.Int32", "Edm.Int64", "Edm.Single", "Edm.Byte":
+					return "number"
+				}
+				return "text"
+			}
+		}
+	}
+	switch {
+	case strings.HasSuffix(field, "Timestamp") || strings.HasSuffix(field, "Date"):
+		return "date"
+	case isPriceField(field) || isAreaField(field) || humanizeAcreFields[field] || strings.HasSuffix(field, "Total") || strings.HasSuffix(field, "Integer"):
+		return "number"
+	}
+	return "text"
+}
+
+// enumDisplay returns an enum member's standard display name when it differs from the
+// value, e.g. "ActiveUnderContract" becomes "Active Under Contract"
+func (e *queryExplainer) enumDisplay(field, value string) string {
+	if e.parser != nil {
+		if entity, ok := e.parser.GetEntityInfo(e.entity); ok {
+			if prop, ok := entity.Properties[field]; ok && prop.EnumType != "" {
+				if enum, ok := e.parser.GetEnumInfo(prop.EnumType); ok {
+					if member, ok := enum.Members[value]; ok && member.StandardName != "" {
+						return member.StandardName
+					}
+				}
+			}
+		}
+	}
+	return value
+}
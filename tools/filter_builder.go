@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterOperators maps structured filter operators to their OData form
+var filterOperators = map[string]string{
+	"eq": "eq", "ne": "ne", "gt": "gt", "ge": "ge", "lt": "lt", "le": "le",
+	"has": "has", "in": "in",
+	"contains": "contains", "startswith": "startswith", "endswith": "endswith",
+}
+
+// datePattern matches OData date literals (YYYY-MM-DD)
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// fieldNamePattern matches a plain or path-qualified field name
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(/[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// buildFilter compiles the structured filters argument into an OData $filter string.
+//
+// Each entry is a condition {field, op, value}, a nested group {logic, filters}
+// or a negation {not: <entry>}. Entries are joined with logic ("and" by default).
+func buildFilter(filters []interface{}, logic string) (string, error) {
+	joiner, err := filterLogic(logic)
+	if err != nil {
+		return "", err
+	}
+	if len(filters) == 0 {
+		return "", fmt.Errorf("filters must contain at least one condition")
+	}
+
+	var clauses []string
+	for i, entry := range filters {
+		item, ok := entry.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("filters[%d] must be an object", i)
+		}
+
+		clause, err := buildFilterEntry(item)
+		if err != nil {
+			return "", fmt.Errorf("filters[%d]: %w", i, err)
+		}
+		clauses = append(clauses, clause)
+	}
+
+	if len(clauses) == 1 {
+		return clauses[0], nil
+	}
+	return strings.Join(clauses, " "+joiner+" "), nil
+}
+
+// buildFilterEntry compiles a single condition, nested group or negation into an
+// expression that can be joined with and/or as is
+func buildFilterEntry(item map[string]interface{}) (string, error) {
+	expr, compound, err := compileFilterEntry(item)
+	if err != nil {
+		return "", err
+	}
+	if compound {
+		return "(" + expr + ")", nil
+	}
+	return expr, nil
+}
+
+// compileFilterEntry compiles an entry without outer parentheses, reporting whether
+// the expression is compound and needs them before it is joined with others.
+//
+// {not: <entry>} negates a subexpression, and "not": true on a condition or group
+// negates that entry. Both emit not (...), which binds tighter than and/or.
+func compileFilterEntry(item map[string]interface{}) (string, bool, error) {
+	negate := false
+	switch node := item["not"].(type) {
+	case nil:
+	case bool:
+		negate = node
+	case map[string]interface{}:
+		if len(item) > 1 {
+			return "", false, fmt.Errorf("a {not: ...} node must not have other keys")
+		}
+		inner, _, err := compileFilterEntry(node)
+		if err != nil {
+			return "", false, fmt.Errorf("not: %w", err)
+		}
+		return negateFilter(inner), false, nil
+	default:
+		return "", false, fmt.Errorf("not must be a condition, a group or a boolean")
+	}
+
+	expr, compound, err := compileFilterTerm(item)
+	if err != nil {
+		return "", false, err
+	}
+	if negate {
+		return negateFilter(expr), false, nil
+	}
+	return expr, compound, nil
+}
+
+// negateFilter wraps an expression in not (...)
+func negateFilter(expr string) string {
+	return "not (" + expr + ")"
+}
+
+// compileFilterTerm compiles a condition or nested group, ignoring any not flag
+func compileFilterTerm(item map[string]interface{}) (string, bool, error) {
+	// Nested group
+	if nested, ok := item["filters"].([]interface{}); ok {
+		logic, _ := item["logic"].(string)
+		group, err := buildFilter(nested, logic)
+		if err != nil {
+			return "", false, err
+		}
+		return group, len(nested) > 1, nil
+	}
+
+	condition, err := buildFilterCondition(item)
+	return condition, false, err
+}
+
+// buildFilterCondition compiles a single {field, op, value} condition
+func buildFilterCondition(item map[string]interface{}) (string, error) {
+	field, _ := item["field"].(string)
+	field = strings.TrimSpace(field)
+	if !fieldNamePattern.MatchString(field) {
+		return "", fmt.Errorf("invalid field name %q", field)
+	}
+
+	opName, _ := item["op"].(string)
+	op, ok := filterOperators[strings.ToLower(strings.TrimSpace(opName))]
+	if !ok {
+		return "", fmt.Errorf("unsupported operator %q for field %s", opName, field)
+	}
+
+	value, hasValue := item["value"]
+	if !hasValue {
+		return "", fmt.Errorf("value is required for field %s", field)
+	}
+
+	switch op {
+	case "in":
+		values, ok := value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("operator 'in' requires a non-empty array value for field %s", field)
+		}
+		var literals []string
+		for _, v := range values {
+			literal, err := formatFilterValue(v)
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", field, err)
+			}
+			literals = append(literals, literal)
+		}
+		return fmt.Sprintf("%s in (%s)", field, strings.Join(literals, ",")), nil
+	case "contains", "startswith", "endswith":
+		text, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("operator '%s' requires a string value for field %s", op, field)
+		}
+		return fmt.Sprintf("%s(%s,%s)", op, field, QuoteODataString(text)), nil
+	default:
+		literal, err := formatFilterValue(value)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", field, err)
+		}
+		return fmt.Sprintf("%s %s %s", field, op, literal), nil
+	}
+}
+
+// formatFilterValue renders a JSON value as an OData literal. Strings are quoted
+// unless they are dates or timestamps, which OData expects unquoted.
+func formatFilterValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case string:
+		if datePattern.MatchString(v) {
+			return v, nil
+		}
+		if ts, err := time.Parse(time.RFC3339, v); err == nil {
+			return ts.UTC().Format(time.RFC3339Nano), nil
+		}
+		return QuoteODataString(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// filterLogic validates a group's logic operator, defaulting to "and"
+func filterLogic(logic string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(logic)) {
+	case "", "and":
+		return "and", nil
+	case "or":
+		return "or", nil
+	default:
+		return "", fmt.Errorf("logic must be 'and' or 'or', got %q", logic)
+	}
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+func TestBuildFilterNegation(t *testing.T) {
+	seattle := map[string]interface{}{"field": "City", "op": "eq", "value": "Seattle"}
+	bellevue := map[string]interface{}{"field": "City", "op": "eq", "value": "Bellevue"}
+	price := map[string]interface{}{"field": "ListPrice", "op": "gt", "value": 500000.0}
+
+	tests := []struct {
+		name    string
+		filters []interface{}
+		logic   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "not node",
+			filters: []interface{}{map[string]interface{}{"not": seattle}},
+			want:    "not (City eq 'Seattle')",
+		},
+		{
+			name:    "not flag on a condition",
+			filters: []interface{}{map[string]interface{}{"field": "City", "op": "eq", "value": "Seattle", "not": true}},
+			want:    "not (City eq 'Seattle')",
+		},
+		{
+			name:    "not false is ignored",
+			filters: []interface{}{map[string]interface{}{"field": "City", "op": "eq", "value": "Seattle", "not": false}},
+			want:    "City eq 'Seattle'",
+		},
+		{
+			name:    "nested not",
+			filters: []interface{}{map[string]interface{}{"not": map[string]interface{}{"not": seattle}}},
+			want:    "not (not (City eq 'Seattle'))",
+		},
+		{
+			name: "negated or group binds tighter than and",
+			filters: []interface{}{
+				map[string]interface{}{"not": map[string]interface{}{"logic": "or", "filters": []interface{}{seattle, bellevue}}},
+				price,
+			},
+			want: "not (City eq 'Seattle' or City eq 'Bellevue') and ListPrice gt 500000",
+		},
+		{
+			name: "not flag on a group",
+			filters: []interface{}{
+				map[string]interface{}{"not": true, "logic": "or", "filters": []interface{}{seattle, bellevue}},
+				price,
+			},
+			want: "not (City eq 'Seattle' or City eq 'Bellevue') and ListPrice gt 500000",
+		},
+		{
+			name: "negation inside an or group",
+			filters: []interface{}{
+				map[string]interface{}{"logic": "or", "filters": []interface{}{map[string]interface{}{"not": seattle}, bellevue}},
+				price,
+			},
+			want: "(not (City eq 'Seattle') or City eq 'Bellevue') and ListPrice gt 500000",
+		},
+		{
+			name:    "negations joined with or",
+			filters: []interface{}{map[string]interface{}{"not": seattle}, map[string]interface{}{"not": bellevue}},
+			logic:   "or",
+			want:    "not (City eq 'Seattle') or not (City eq 'Bellevue')",
+		},
+		{
+			name:    "not node with other keys",
+			filters: []interface{}{map[string]interface{}{"not": seattle, "field": "City"}},
+			wantErr: "must not have other keys",
+		},
+		{
+			name:    "not of the wrong type",
+			filters: []interface{}{map[string]interface{}{"not": "City eq 'Seattle'"}},
+			wantErr: "not must be a condition, a group or a boolean",
+		},
+		{
+			name:    "error inside a not node",
+			filters: []interface{}{map[string]interface{}{"not": map[string]interface{}{"field": "City", "op": "like", "value": "x"}}},
+			wantErr: "filters[0]: not:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildFilter(tt.filters, tt.logic)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildFilter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryNotNegatesAllFilters(t *testing.T) {
+	tool := NewResoQueryTool(nil, config.DefaultConfig())
+	params, err := tool.parseArguments(map[string]interface{}{
+		"entity": "Property",
+		"not":    true,
+		"filters": []interface{}{
+			map[string]interface{}{"field": "City", "op": "eq", "value": "Seattle"},
+			map[string]interface{}{"not": map[string]interface{}{"field": "StandardStatus", "op": "eq", "value": "Closed"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseArguments: %v", err)
+	}
+	want := "not (City eq 'Seattle' and not (StandardStatus eq 'Closed'))"
+	if params.Filter != want {
+		t.Errorf("Filter = %q, want %q", params.Filter, want)
+	}
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flattenResult describes how flattenExpanded reshaped the records
+type flattenResult struct {
+	Records    int
+	Rows       int
+	Collection string
+	Hoisted    []string
+}
+
+// flattenExpanded turns records with expanded entities into flat rows for table
+// consumers. Fields of single-valued expands are hoisted into the parent with the
+// navigation property as prefix (Office_OfficeName). One expanded collection is joined:
+// each child becomes its own row repeating the parent fields, and a parent without
+// children keeps a single row. Expands absent from every record are ignored.
+func flattenExpanded(records []map[string]interface{}, expand string) ([]map[string]interface{}, *flattenResult, error) {
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &flattenResult{Records: len(records)}
+	var collections []string
+	for _, segment := range segments {
+		isCollection, found := expandedShape(records, segment.Name)
+		switch {
+		case !found:
+			continue
+		case isCollection:
+			collections = append(collections, segment.Name)
+		default:
+			result.Hoisted = append(result.Hoisted, segment.Name)
+		}
+	}
+	if len(collections) > 1 {
+		return nil, nil, fmt.Errorf("flatten supports one expanded collection, but %s were all returned as collections; expand fewer of them or leave flatten off", strings.Join(collections, ", "))
+	}
+	if len(collections) == 1 {
+		result.Collection = collections[0]
+	}
+
+	var rows []map[string]interface{}
+	for _, record := range records {
+		parent := make(map[string]interface{}, len(record))
+		for field, value := range record {
+			if field != result.Collection {
+				parent[field] = value
+			}
+		}
+		for _, name := range result.Hoisted {
+			hoistFields(parent, name, parent[name])
+		}
+
+		children, _ := record[result.Collection].([]interface{})
+		if result.Collection == "" || len(children) == 0 {
+			rows = append(rows, parent)
+			continue
+		}
+		for _, child := range children {
+			row := make(map[string]interface{}, len(parent)+8)
+			for field, value := range parent {
+				row[field] = value
+			}
+			hoistFields(row, result.Collection, child)
+			rows = append(rows, row)
+		}
+	}
+	result.Rows = len(rows)
+	return rows, result, nil
+}
+
+// expandedShape reports whether the records hold name as an expanded collection, and
+// whether any record holds it at all
+func expandedShape(records []map[string]interface{}, name string) (isCollection, found bool) {
+	for _, record := range records {
+		switch record[name].(type) {
+		case []interface{}:
+			return true, true
+		case map[string]interface{}:
+			found = true
+		}
+	}
+	return false, found
+}
+
+// hoistFields replaces row[name] with the expanded record's fields, prefixed with
+// name_. An empty expand is dropped; other values that are not records are kept
+// under name unchanged.
+func hoistFields(row map[string]interface{}, name string, value interface{}) {
+	child, ok := value.(map[string]interface{})
+	delete(row, name)
+	if !ok {
+		if value != nil {
+			row[name] = value
+		}
+		return
+	}
+	for field, fieldValue := range child {
+		if strings.HasPrefix(field, "@") {
+			continue
+		}
+		row[name+"_"+field] = fieldValue
+	}
+}
+
+// format describes the flattening for the query summary
+func (r *flattenResult) format() string {
+	var parts []string
+	if r.Collection != "" {
+		parts = append(parts, fmt.Sprintf("one row per %s (fields prefixed %s_), %d records became %d rows", r.Collection, r.Collection, r.Records, r.Rows))
+	}
+	if len(r.Hoisted) > 0 {
+		hoisted := append([]string(nil), r.Hoisted...)
+		sort.Strings(hoisted)
+		for i, name := range hoisted {
+			hoisted[i] = name + "_*"
+		}
+		parts = append(parts, fmt.Sprintf("single-valued expands hoisted as %s", strings.Join(hoisted, ", ")))
+	}
+	if len(parts) == 0 {
+		return "\nFlattened: no expanded entities were returned, so records are unchanged.\n"
+	}
+	return fmt.Sprintf("\nFlattened: %s.\n", strings.Join(parts, "; "))
+}
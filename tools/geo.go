@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusMiles is the mean Earth radius used for great-circle distances
+const earthRadiusMiles = 3958.8
+
+// nearSearch describes a radius search around a point
+type nearSearch struct {
+	Lat         float64
+	Lon         float64
+	RadiusMiles float64
+	Exact       bool
+}
+
+// recordDistance pairs a record identifier with its distance from the search center
+type recordDistance struct {
+	ID       string
+	Distance float64
+}
+
+// parseNear parses the near argument ({lat, lon, radius_miles, exact}) if present
+func parseNear(args map[string]interface{}) (*nearSearch, error) {
+	raw, ok := args["near"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	nearArgs, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("near must be an object with lat, lon and radius_miles")
+	}
+
+	lat, err := numberArg(nearArgs, "lat")
+	if err != nil {
+		return nil, fmt.Errorf("near.%w", err)
+	}
+	lon, err := numberArg(nearArgs, "lon")
+	if err != nil {
+		return nil, fmt.Errorf("near.%w", err)
+	}
+	radius, err := numberArg(nearArgs, "radius_miles")
+	if err != nil {
+		return nil, fmt.Errorf("near.%w", err)
+	}
+
+	if lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("near.lat must be between -90 and 90")
+	}
+	if lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("near.lon must be between -180 and 180")
+	}
+	if radius <= 0 {
+		return nil, fmt.Errorf("near.radius_miles must be greater than 0")
+	}
+
+	near := &nearSearch{Lat: lat, Lon: lon, RadiusMiles: radius, Exact: true}
+	if exact, ok := nearArgs["exact"].(bool); ok {
+		near.Exact = exact
+	}
+	return near, nil
+}
+
+// numberArg reads a numeric argument that may arrive as a JSON number or string
+func numberArg(args map[string]interface{}, name string) (float64, error) {
+	switch v := args[name].(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("%s must be a number", name)
+	case nil:
+		return 0, fmt.Errorf("%s is required", name)
+	default:
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+}
+
+// BoundingBoxFilter returns an OData filter selecting Latitude/Longitude within the search box
+func (n *nearSearch) BoundingBoxFilter() string {
+	latDelta := n.RadiusMiles / 69.0
+	lonDelta := n.RadiusMiles / (69.0 * math.Cos(n.Lat*math.Pi/180))
+	if math.IsInf(lonDelta, 0) || lonDelta > 180 {
+		lonDelta = 180
+	}
+
+	return fmt.Sprintf("Latitude ge %s and Latitude le %s and Longitude ge %s and Longitude le %s",
+		formatCoordinate(n.Lat-latDelta), formatCoordinate(n.Lat+latDelta),
+		formatCoordinate(n.Lon-lonDelta), formatCoordinate(n.Lon+lonDelta))
+}
+
+// Apply drops records outside the radius (when Exact is set) and returns the distance of each kept record
+func (n *nearSearch) Apply(records []map[string]interface{}) ([]map[string]interface{}, []recordDistance) {
+	var kept []map[string]interface{}
+	var distances []recordDistance
+
+	for i, record := range records {
+		lat, latOK := toFloat(record["Latitude"])
+		lon, lonOK := toFloat(record["Longitude"])
+		if !latOK || !lonOK {
+			if !n.Exact {
+				kept = append(kept, record)
+			}
+			continue
+		}
+
+		distance := haversineMiles(n.Lat, n.Lon, lat, lon)
+		if n.Exact && distance > n.RadiusMiles {
+			continue
+		}
+
+		kept = append(kept, record)
+		distances = append(distances, recordDistance{ID: recordID(record, i), Distance: distance})
+	}
+
+	sort.SliceStable(distances, func(i, j int) bool {
+		return distances[i].Distance < distances[j].Distance
+	})
+
+	return kept, distances
+}
+
+// haversineMiles computes the great-circle distance between two points in miles
+func haversineMiles(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(a))
+}
+
+// formatCoordinate formats a coordinate for use in an OData filter
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
+
+// toFloat converts a decoded JSON value into a float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// recordID returns a human-readable identifier for a record
+func recordID(record map[string]interface{}, index int) string {
+	for _, key := range []string{"ListingKey", "ListingId", "MemberKey", "OfficeKey", "MediaKey", "OpenHouseKey"} {
+		if v, ok := record[key]; ok && v != nil {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return fmt.Sprintf("record %d", index+1)
+}
+
+// ensureSelected appends fields to a select list when a select list is in use
+func ensureSelected(selectList string, fields ...string) string {
+	if selectList == "" {
+		return selectList
+	}
+
+	present := make(map[string]bool)
+	for _, f := range splitFieldList(selectList) {
+		present[f] = true
+	}
+	for _, f := range fields {
+		if !present[f] {
+			selectList += "," + f
+		}
+	}
+	return selectList
+}
+
+// formatDistances renders the distance of each record from the search center
+func formatDistances(near *nearSearch, distances []recordDistance) string {
+	var section strings.Builder
+
+	section.WriteString(fmt.Sprintf("\nRadius Search: %.2f miles around (%.6f, %.6f)", near.RadiusMiles, near.Lat, near.Lon))
+	if near.Exact {
+		section.WriteString(" - exact great-circle radius applied\n")
+	} else {
+		section.WriteString(" - bounding box only\n")
+	}
+
+	if len(distances) == 0 {
+		section.WriteString("No records with coordinates within the search area\n")
+		return section.String()
+	}
+
+	section.WriteString("Distances:\n")
+	for _, d := range distances {
+		section.WriteString(fmt.Sprintf("- %s: %.2f mi\n", d.ID, d.Distance))
+	}
+	return section.String()
+}
@@ -0,0 +1,340 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHumanizeRows is the number of records tabulated when humanize_rows is not given
+const defaultHumanizeRows = 5
+
+// maxHumanizeRows caps the summary table so it stays readable
+const maxHumanizeRows = 50
+
+// maxHumanizeColumns caps the number of fields shown per table row
+const maxHumanizeColumns = 8
+
+// maxGroupRows caps the grouped results tabulated in the summary
+const maxGroupRows = 50
+
+// groupByPattern captures the grouping properties of a $apply groupby transformation
+var groupByPattern = regexp.MustCompile(`groupby\(\s*\(([^)]*)\)`)
+
+// humanizeColumns are the fields tabulated when present, in column order
+var humanizeColumns = []string{
+	"ListingId", "MemberMlsId", "OfficeMlsId",
+	"UnparsedAddress", "City",
+	"MemberFullName", "OfficeName",
+	"StandardStatus", "MemberStatus", "OfficeStatus",
+	"ListPrice", "ClosePrice",
+	"BedroomsTotal", "BathroomsTotalInteger", "LivingArea", "LotSizeAcres",
+	"OpenHouseDate", "OpenHouseStartTime",
+	"MediaCategory", "Order",
+}
+
+// humanizeAcreFields hold lot sizes in acres
+var humanizeAcreFields = map[string]bool{
+	"LotSizeAcres": true,
+}
+
+// parseHumanize reads the humanize and humanize_rows arguments, returning the number
+// of records to tabulate, or 0 when humanize is off
+func parseHumanize(args map[string]interface{}) (int, error) {
+	if humanize, _ := args["humanize"].(bool); !humanize {
+		return 0, nil
+	}
+
+	rows := defaultHumanizeRows
+	switch v := args["humanize_rows"].(type) {
+	case float64:
+		rows = int(v)
+	case int:
+		rows = v
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid humanize_rows %q (must be an integer)", v)
+		}
+		rows = parsed
+	}
+	if rows < 1 || rows > maxHumanizeRows {
+		return 0, fmt.Errorf("humanize_rows must be between 1 and %d, got %d", maxHumanizeRows, rows)
+	}
+	return rows, nil
+}
+
+// isPriceField reports whether a field holds a currency amount
+func isPriceField(name string) bool {
+	return strings.HasSuffix(name, "Price") || strings.HasSuffix(name, "Fee") || strings.HasSuffix(name, "Amount")
+}
+
+// isAreaField reports whether a field holds an area in square feet
+func isAreaField(name string) bool {
+	return strings.HasSuffix(name, "SquareFeet") || strings.HasSuffix(name, "Area") || strings.HasSuffix(name, "AreaTotal")
+}
+
+// humanizeValue formats a field value for display: currency for prices, square feet
+// or acres for areas, thousands separators for other numbers
+func humanizeValue(field string, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		switch {
+		case isPriceField(field):
+			return formatCurrency(v)
+		case humanizeAcreFields[field]:
+			return strconv.FormatFloat(v, 'f', -1, 64) + " acres"
+		case isAreaField(field):
+			return groupDigits(v, 0) + " sq ft"
+		case strings.HasSuffix(field, "Latitude") || strings.HasSuffix(field, "Longitude") || strings.Contains(field, "Year") || field == "Order":
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return groupDigits(v, -1)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// formatTimestamp renders an ISO 8601 timestamp value in location, reporting false
+// for anything else. Date fields such as OpenHouseDate keep their calendar day, since
+// shifting a midnight UTC value to another zone would change the date.
+func formatTimestamp(field string, value interface{}, location *time.Location) (string, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+	ts, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return "", false
+	}
+	if strings.HasSuffix(field, "Date") {
+		return ts.Format("2006-01-02"), true
+	}
+	return ts.In(location).Format("2006-01-02 15:04 MST"), true
+}
+
+// formatCurrency renders an amount in dollars, e.g. $1,250,000 or $99.50
+func formatCurrency(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	decimals := 0
+	if amount != math.Trunc(amount) {
+		decimals = 2
+	}
+	return sign + "$" + groupDigits(amount, decimals)
+}
+
+// groupDigits formats a number with comma thousands separators and the
+// given number of decimals (-1 for as many as needed)
+func groupDigits(number float64, decimals int) string {
+	text := strconv.FormatFloat(math.Abs(number), 'f', decimals, 64)
+	whole, fraction, hasFraction := strings.Cut(text, ".")
+
+	var grouped strings.Builder
+	if number < 0 {
+		grouped.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if hasFraction {
+		grouped.WriteString("." + fraction)
+	}
+	return grouped.String()
+}
+
+// formatRecordTable renders the first rows records as a Markdown table of their key
+// fields, with prices and areas formatted for reading and timestamps shown in location
+func formatRecordTable(records []map[string]interface{}, rows int, location *time.Location) string {
+	if len(records) == 0 {
+		return ""
+	}
+	if len(records) > rows {
+		records = records[:rows]
+	}
+
+	var columns []string
+	for _, field := range humanizeColumns {
+		for _, record := range records {
+			if record[field] != nil {
+				columns = append(columns, field)
+				break
+			}
+		}
+		if len(columns) == maxHumanizeColumns {
+			break
+		}
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("\nTop %d Records:\n", len(records)))
+	table.WriteString("| # | Record | " + strings.Join(columns, " | ") + " |\n")
+	table.WriteString("|---|---" + strings.Repeat("|---", len(columns)) + "|\n")
+	for i, record := range records {
+		cells := []string{strconv.Itoa(i + 1), recordID(record, i)}
+		for _, field := range columns {
+			if ts, ok := formatTimestamp(field, record[field], location); ok {
+				cells = append(cells, ts)
+				continue
+			}
+			cells = append(cells, humanizeValue(field, record[field]))
+		}
+		for j, cell := range cells {
+			cells[j] = strings.ReplaceAll(strings.ReplaceAll(cell, "|", `\|`), "\n", " ")
+		}
+		table.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return table.String()
+}
+
+// formatValueRanges renders the low and high of each price and area field across
+// records, e.g. "ListPrice: $450,000 to $1,250,000"
+func formatValueRanges(records []map[string]interface{}) string {
+	type valueRange struct{ low, high float64 }
+	ranges := make(map[string]*valueRange)
+	var fields []string
+
+	for _, record := range records {
+		for field, value := range record {
+			number, ok := value.(float64)
+			if !ok || !(isPriceField(field) || isAreaField(field) || humanizeAcreFields[field]) {
+				continue
+			}
+			r, seen := ranges[field]
+			if !seen {
+				ranges[field] = &valueRange{low: number, high: number}
+				fields = append(fields, field)
+				continue
+			}
+			r.low = math.Min(r.low, number)
+			r.high = math.Max(r.high, number)
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Prices first, then areas, each alphabetically
+	sort.Slice(fields, func(i, j int) bool {
+		if isPriceField(fields[i]) != isPriceField(fields[j]) {
+			return isPriceField(fields[i])
+		}
+		return fields[i] < fields[j]
+	})
+
+	var section strings.Builder
+	section.WriteString("\nValue Ranges:\n")
+	for _, field := range fields {
+		r := ranges[field]
+		if r.low == r.high {
+			section.WriteString(fmt.Sprintf("- %s: %s\n", field, humanizeValue(field, r.low)))
+			continue
+		}
+		section.WriteString(fmt.Sprintf("- %s: %s to %s\n", field, humanizeValue(field, r.low), humanizeValue(field, r.high)))
+	}
+	return section.String()
+}
+
+// formatGroupTable renders $apply grouped results as a Markdown table, with the groupby
+// properties first and the aggregates after them. Nested group keys such as
+// ListOffice/OfficeName become one column per path.
+func formatGroupTable(apply string, groups []map[string]interface{}, location *time.Location) string {
+	if len(groups) == 0 {
+		return ""
+	}
+
+	rows := make([]map[string]interface{}, len(groups))
+	seen := make(map[string]bool)
+	var others []string
+	for i, group := range groups {
+		rows[i] = flattenGroup("", group)
+		for column := range rows[i] {
+			if !seen[column] {
+				seen[column] = true
+				others = append(others, column)
+			}
+		}
+	}
+	sort.Strings(others)
+
+	var columns []string
+	if match := groupByPattern.FindStringSubmatch(apply); match != nil {
+		for _, key := range splitFieldList(match[1]) {
+			if seen[key] {
+				columns = append(columns, key)
+				delete(seen, key)
+			}
+		}
+	}
+	for _, column := range others {
+		if seen[column] {
+			columns = append(columns, column)
+		}
+	}
+
+	shown := rows
+	if len(shown) > maxGroupRows {
+		shown = shown[:maxGroupRows]
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("\nGrouped Results (%d groups):\n", len(groups)))
+	table.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	table.WriteString(strings.Repeat("|---", len(columns)) + "|\n")
+	for _, row := range shown {
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			field := column[strings.LastIndex(column, "/")+1:]
+			if ts, ok := formatTimestamp(field, row[column], location); ok {
+				cells[j] = ts
+			} else {
+				cells[j] = humanizeValue(field, row[column])
+			}
+			cells[j] = strings.ReplaceAll(strings.ReplaceAll(cells[j], "|", `\|`), "\n", " ")
+		}
+		table.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if len(rows) > len(shown) {
+		table.WriteString(fmt.Sprintf("... and %d more groups (see the full response)\n", len(rows)-len(shown)))
+	}
+	return table.String()
+}
+
+// flattenGroup turns nested group objects into slash-separated paths, skipping OData
+// annotations
+func flattenGroup(prefix string, group map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{}, len(group))
+	for key, value := range group {
+		if strings.Contains(key, "@") {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			for path, inner := range flattenGroup(prefix+key+"/", nested) {
+				flat[path] = inner
+			}
+			continue
+		}
+		flat[prefix+key] = value
+	}
+	return flat
+}
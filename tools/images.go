@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// imageSizePattern matches the ?d= sizes accepted by the media CDN: the t/s/l
+// presets, a custom width, or a custom WIDTHxHEIGHT
+var imageSizePattern = regexp.MustCompile(`^(t|s|l|[1-9][0-9]{0,4}|[1-9][0-9]{0,4}x[1-9][0-9]{0,4})$`)
+
+// imageSizeDescription documents the image_size argument for tool schemas
+const imageSizeDescription = "Resize image URLs with the CDN's ?d= parameter: 't' (thumbnail, 150px), 's' (small, 480px), 'l' (large, 1024px), a custom width such as '600', or a custom 'WIDTHxHEIGHT' such as '800x600'. Omit to keep the original URLs."
+
+// normalizeImageSize trims and lowercases an image size and reports whether it is valid
+func normalizeImageSize(size string) (string, bool) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	return size, imageSizePattern.MatchString(size)
+}
+
+// parseImageSize reads the optional image_size argument, returning "" when it is absent
+func parseImageSize(args map[string]interface{}) (string, error) {
+	raw, _ := args["image_size"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	size, ok := normalizeImageSize(raw)
+	if !ok {
+		return "", fmt.Errorf("invalid image_size %q (use t, s, l, a width such as 600, or WIDTHxHEIGHT such as 800x600)", raw)
+	}
+	return size, nil
+}
+
+// sizedImageURL sets the ?d= size parameter on an image URL, returning the URL
+// unchanged when it cannot be parsed or size is empty
+func sizedImageURL(rawURL, size string) string {
+	if rawURL == "" || size == "" {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	query := parsed.Query()
+	query.Set("d", size)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// sizeMediaURLs returns copies of records with every MediaURL, top-level or inside
+// expanded collections, resized to size. Records are copied rather than modified
+// because they may be shared with the query cache.
+func sizeMediaURLs(records []map[string]interface{}, size string) []map[string]interface{} {
+	if size == "" {
+		return records
+	}
+	sized := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		sized[i] = sizeMediaRecord(record, size)
+	}
+	return sized
+}
+
+// sizeMediaRecord copies record, resizing MediaURL and recursing into nested records
+func sizeMediaRecord(record map[string]interface{}, size string) map[string]interface{} {
+	sized := make(map[string]interface{}, len(record))
+	for field, value := range record {
+		sized[field] = sizeMediaValue(field, value, size)
+	}
+	return sized
+}
+
+// sizeMediaValue resizes a MediaURL string or recurses into an expanded record or collection
+func sizeMediaValue(field string, value interface{}, size string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if field == "MediaURL" {
+			return sizedImageURL(v, size)
+		}
+	case map[string]interface{}:
+		return sizeMediaRecord(v, size)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = sizeMediaValue(field, item, size)
+		}
+		return items
+	}
+	return value
+}
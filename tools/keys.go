@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// defaultKeyField is the field matched by the keys argument when key_field is not given
+const defaultKeyField = "ListingKey"
+
+// maxLookupKeys caps the number of values accepted by the keys argument
+const maxLookupKeys = 1000
+
+// maxKeysFilterLength is the longest 'in (...)' list sent in one request; longer key
+// lists are split into several requests so URLs stay well under MaxGETURLLength
+const maxKeysFilterLength = 2000
+
+// keyLookup is a parsed keys/key_field argument pair
+type keyLookup struct {
+	Field    string
+	Keys     []string
+	Literals []string
+}
+
+// parseKeys reads the optional keys and key_field arguments, returning nil when no
+// keys were given. Duplicate keys are dropped, keeping the first occurrence.
+func parseKeys(args map[string]interface{}) (*keyLookup, error) {
+	raw, ok := args["keys"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	values, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keys must be an array of values")
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("keys must contain at least one value")
+	}
+	if len(values) > maxLookupKeys {
+		return nil, fmt.Errorf("at most %d keys are allowed per query, got %d", maxLookupKeys, len(values))
+	}
+
+	lookup := &keyLookup{Field: defaultKeyField}
+	if field, ok := args["key_field"].(string); ok && strings.TrimSpace(field) != "" {
+		lookup.Field = strings.TrimSpace(field)
+	}
+	if !fieldNamePattern.MatchString(lookup.Field) {
+		return nil, fmt.Errorf("invalid key_field %q", lookup.Field)
+	}
+
+	seen := make(map[string]bool)
+	for i, value := range values {
+		var key, literal string
+		switch v := value.(type) {
+		case string:
+			key, literal = v, QuoteODataString(v)
+		case float64:
+			key = strconv.FormatFloat(v, 'f', -1, 64)
+			literal = key
+		default:
+			return nil, fmt.Errorf("keys[%d] must be a string or number", i)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		lookup.Keys = append(lookup.Keys, key)
+		lookup.Literals = append(lookup.Literals, literal)
+	}
+	return lookup, nil
+}
+
+// entityKeyField picks the field that identifies an entity's records: key_field when
+// given, otherwise the entity's single key field from the metadata, otherwise ListingKey
+func entityKeyField(parser *metadata.MetadataParser, entity string, args map[string]interface{}) (string, error) {
+	if field, ok := args["key_field"].(string); ok && strings.TrimSpace(field) != "" {
+		field = strings.TrimSpace(field)
+		if !fieldNamePattern.MatchString(field) {
+			return "", fmt.Errorf("invalid key_field %q", field)
+		}
+		return field, nil
+	}
+	if parser != nil {
+		if info, ok := parser.GetEntityInfo(entity); ok {
+			if len(info.KeyFields) > 1 {
+				return "", fmt.Errorf("%s has a composite key (%s); pass key_field", entity, strings.Join(info.KeyFields, ", "))
+			}
+			if len(info.KeyFields) == 1 {
+				return info.KeyFields[0], nil
+			}
+		}
+	}
+	return defaultKeyField, nil
+}
+
+// chunkFilters returns one 'Field in (...)' filter per chunk of keys, keeping each
+// list under maxKeysFilterLength
+func (k *keyLookup) chunkFilters() []string {
+	var filters []string
+	var chunk []string
+	length := 0
+	for _, literal := range k.Literals {
+		if len(chunk) > 0 && length+len(literal)+1 > maxKeysFilterLength {
+			filters = append(filters, k.inFilter(chunk))
+			chunk, length = nil, 0
+		}
+		chunk = append(chunk, literal)
+		length += len(literal) + 1
+	}
+	if len(chunk) > 0 {
+		filters = append(filters, k.inFilter(chunk))
+	}
+	return filters
+}
+
+// inFilter builds the OData 'in' clause for a list of literals
+func (k *keyLookup) inFilter(literals []string) string {
+	return fmt.Sprintf("%s in (%s)", k.Field, strings.Join(literals, ","))
+}
+
+// queryKeys runs one query per key chunk, following nextLinks within each chunk, and
+// merges the records in the order the keys were given. The reported filter names the
+// key count rather than repeating every key.
+func (t *ResoQueryTool) queryKeys(params api.QueryParams, lookup *keyLookup) (*api.APIResponse, error) {
+	baseFilter := params.Filter
+	params.Select = ensureSelected(params.Select, lookup.Field)
+	params.Skip = 0
+	params.FetchAll = true
+
+	var merged *api.APIResponse
+	for i, chunkFilter := range lookup.chunkFilters() {
+		chunk := params
+		chunk.Filter = combineFilters(baseFilter, chunkFilter)
+
+		response, err := t.client.Query(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("key chunk %d: %w", i+1, err)
+		}
+		if merged == nil {
+			merged = response
+			continue
+		}
+		merged.Value = append(merged.Value, response.Value...)
+		merged.TotalCount += response.TotalCount
+		merged.TotalCountExact = merged.TotalCountExact && response.TotalCountExact
+		merged.PagesFetched += response.PagesFetched
+		merged.ResponseTime += response.ResponseTime
+		if response.NextLink != "" {
+			merged.NextLink = response.NextLink
+		}
+		merged.Truncated = merged.Truncated || response.Truncated
+		merged.FromCache = merged.FromCache && response.FromCache
+	}
+
+	// Order records by the position of their key in the request
+	position := make(map[string]int, len(lookup.Keys))
+	for i, key := range lookup.Keys {
+		position[key] = i
+	}
+	sort.SliceStable(merged.Value, func(i, j int) bool {
+		return keyPosition(merged.Value[i], lookup.Field, position) < keyPosition(merged.Value[j], lookup.Field, position)
+	})
+
+	merged.Count = len(merged.Value)
+	merged.RequestParams = params
+	merged.RequestParams.Filter = combineFilters(baseFilter, fmt.Sprintf("%s in (%d keys)", lookup.Field, len(lookup.Keys)))
+	return merged, nil
+}
+
+// missingKeys returns the requested keys that matched no record
+func (k *keyLookup) missingKeys(records []map[string]interface{}) []string {
+	found := make(map[string]bool)
+	for _, record := range records {
+		found[keyString(record[k.Field])] = true
+	}
+
+	var missing []string
+	for _, key := range k.Keys {
+		if !found[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// keyPosition returns the request order of a record's key, placing unknown keys last
+func keyPosition(record map[string]interface{}, field string, position map[string]int) int {
+	if i, ok := position[keyString(record[field])]; ok {
+		return i
+	}
+	return len(position)
+}
+
+// keyString renders a key value the way parseKeys records it
+func keyString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// formatKeyLookup reports how many of the requested keys matched
+func formatKeyLookup(lookup *keyLookup, records []map[string]interface{}) string {
+	missing := lookup.missingKeys(records)
+	section := fmt.Sprintf("\nKeys: %d requested on %s, %d matched\n", len(lookup.Keys), lookup.Field, len(lookup.Keys)-len(missing))
+	if len(missing) > 0 {
+		section += fmt.Sprintf("Keys Not Found: %s\n", strings.Join(missing, ", "))
+	}
+	return section
+}
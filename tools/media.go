@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Media kinds group the many MediaType and MediaCategory spellings used by MLSes
+const (
+	mediaKindImage    = "image"
+	mediaKindVideo    = "video"
+	mediaKindTour     = "tour"
+	mediaKindDocument = "document"
+	mediaKindOther    = "other"
+)
+
+// mediaKinds are the values accepted by media_kind, in display order
+var mediaKinds = []string{mediaKindImage, mediaKindVideo, mediaKindTour, mediaKindDocument}
+
+// mediaKindDescription documents the media_kind argument for tool schemas
+const mediaKindDescription = "Only return media of this kind: 'image' (photos and floor plans), 'video', 'tour' (virtual tours) or 'document' (PDFs and other files). The kind is inferred from MediaCategory, MediaType (e.g. 'jpeg', 'image/jpeg' or 'Photo') and the MediaURL file extension, so it works across MLSes that spell these differently."
+
+// mediaCategoryKinds maps lowercased MediaCategory values to kinds
+var mediaCategoryKinds = map[string]string{
+	"photo":                mediaKindImage,
+	"floorplan":            mediaKindImage,
+	"agentphoto":           mediaKindImage,
+	"officephoto":          mediaKindImage,
+	"officelogo":           mediaKindImage,
+	"video":                mediaKindVideo,
+	"brandedvideo":         mediaKindVideo,
+	"unbrandedvideo":       mediaKindVideo,
+	"virtualtour":          mediaKindTour,
+	"brandedvirtualtour":   mediaKindTour,
+	"unbrandedvirtualtour": mediaKindTour,
+	"document":             mediaKindDocument,
+}
+
+// mediaTypeKinds maps lowercased MediaType values and file extensions to kinds
+var mediaTypeKinds = map[string]string{
+	"jpeg": mediaKindImage, "jpg": mediaKindImage, "png": mediaKindImage, "gif": mediaKindImage,
+	"webp": mediaKindImage, "tif": mediaKindImage, "tiff": mediaKindImage, "bmp": mediaKindImage,
+	"heic": mediaKindImage, "photo": mediaKindImage, "image": mediaKindImage,
+	"mp4": mediaKindVideo, "mov": mediaKindVideo, "avi": mediaKindVideo, "wmv": mediaKindVideo,
+	"m4v": mediaKindVideo, "webm": mediaKindVideo, "video": mediaKindVideo,
+	"tour": mediaKindTour, "virtualtour": mediaKindTour,
+	"pdf": mediaKindDocument, "doc": mediaKindDocument, "docx": mediaKindDocument,
+	"xls": mediaKindDocument, "xlsx": mediaKindDocument, "txt": mediaKindDocument,
+	"rtf": mediaKindDocument, "document": mediaKindDocument,
+}
+
+// parseMediaKind reads the optional media_kind argument, returning "" when it is absent
+func parseMediaKind(args map[string]interface{}) (string, error) {
+	raw, _ := args["media_kind"].(string)
+	kind := strings.ToLower(strings.TrimSpace(raw))
+	if kind == "" {
+		return "", nil
+	}
+	for _, valid := range mediaKinds {
+		if kind == valid {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("invalid media_kind %q (use %s)", raw, strings.Join(mediaKinds, ", "))
+}
+
+// mediaKind infers the coarse kind of a Media record. Tour and video categories win
+// over MediaType, since a tour is often typed as the image or page it links to; the
+// MediaURL extension is the last resort.
+func mediaKind(record map[string]interface{}) string {
+	category, _ := record["MediaCategory"].(string)
+	categoryKind := mediaCategoryKinds[strings.ToLower(strings.TrimSpace(category))]
+	if categoryKind == mediaKindTour || categoryKind == mediaKindVideo {
+		return categoryKind
+	}
+
+	mediaType, _ := record["MediaType"].(string)
+	if kind := mediaTypeKind(mediaType); kind != "" {
+		return kind
+	}
+	if categoryKind != "" {
+		return categoryKind
+	}
+
+	if mediaURL, _ := record["MediaURL"].(string); mediaURL != "" {
+		if parsed, err := url.Parse(mediaURL); err == nil {
+			extension := strings.TrimPrefix(strings.ToLower(path.Ext(parsed.Path)), ".")
+			if kind := mediaTypeKinds[extension]; kind != "" {
+				return kind
+			}
+		}
+	}
+	return mediaKindOther
+}
+
+// mediaTypeKind maps a MediaType such as "jpeg", ".JPG", "image/jpeg" or
+// "application/pdf" to a kind, returning "" when it is not recognised
+func mediaTypeKind(mediaType string) string {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return ""
+	}
+	if kind := mediaTypeKinds[strings.TrimPrefix(mediaType, ".")]; kind != "" {
+		return kind
+	}
+
+	major, minor, isMIME := strings.Cut(mediaType, "/")
+	if !isMIME {
+		return ""
+	}
+	switch major {
+	case "image":
+		return mediaKindImage
+	case "video":
+		return mediaKindVideo
+	case "application", "text":
+		if minor == "pdf" || strings.Contains(minor, "msword") || strings.Contains(minor, "officedocument") || minor == "plain" || minor == "rtf" {
+			return mediaKindDocument
+		}
+	}
+	return ""
+}
+
+// filterMediaKind keeps the records of the given kind, or all of them when kind is empty
+func filterMediaKind(records []map[string]interface{}, kind string) []map[string]interface{} {
+	if kind == "" {
+		return records
+	}
+	kept := []map[string]interface{}{}
+	for _, record := range records {
+		if mediaKind(record) == kind {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// formatMediaKindCounts renders how many records fall into each kind,
+// e.g. "image 24, tour 1"
+func formatMediaKindCounts(records []map[string]interface{}) string {
+	counts := make(map[string]int)
+	for _, record := range records {
+		counts[mediaKind(record)]++
+	}
+	var parts []string
+	for _, kind := range mediaKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", kind, counts[kind]))
+		}
+	}
+	if counts[mediaKindOther] > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d", mediaKindOther, counts[mediaKindOther]))
+	}
+	return strings.Join(parts, ", ")
+}
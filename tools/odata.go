@@ -0,0 +1,448 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// filterComparison is a field compared against a quoted string literal in a filter
+type filterComparison struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+// comparisonPattern matches Field op 'value' where op is eq, ne or has
+var comparisonPattern = regexp.MustCompile(`(?:^|[\s(])([A-Za-z_][A-Za-z0-9_]*)\s+(eq|ne|has)\s+'((?:[^']|'')*)'`)
+
+// QuoteODataString wraps a value in single quotes, escaping embedded quotes per OData
+// rules and replacing control characters such as raw newlines with spaces
+func QuoteODataString(value string) string {
+	return "'" + strings.ReplaceAll(stripControlChars(value), "'", "''") + "'"
+}
+
+// stripControlChars replaces control characters, including newlines and tabs, with spaces
+func stripControlChars(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// repairFilterLiterals replaces control characters in a filter with spaces and doubles
+// apostrophes inside string literals:
+//
+//	City eq 'O'Brien'  is sent as  City eq 'O''Brien'
+//
+// A quote followed by a letter or digit is taken to be an apostrophe, since a closing
+// quote is followed by whitespace, a parenthesis, a comma or the end of the filter.
+// It returns the literals it changed, as sent.
+func repairFilterLiterals(filter string) (string, []string) {
+	runes := []rune(filter)
+	var out strings.Builder
+	var repaired []string
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\'' {
+			if unicode.IsControl(runes[i]) {
+				out.WriteRune(' ')
+			} else {
+				out.WriteRune(runes[i])
+			}
+			continue
+		}
+
+		var literal strings.Builder
+		literal.WriteRune('\'')
+		changed := false
+		for i++; i < len(runes); i++ {
+			r := runes[i]
+			if r == '\'' {
+				next := rune(0)
+				if i+1 < len(runes) {
+					next = runes[i+1]
+				}
+				if next == '\'' {
+					literal.WriteString("''")
+					i++
+					continue
+				}
+				if unicode.IsLetter(next) || unicode.IsDigit(next) {
+					literal.WriteString("''")
+					changed = true
+					continue
+				}
+				literal.WriteRune('\'')
+				break
+			}
+			if unicode.IsControl(r) {
+				r = ' '
+				changed = true
+			}
+			literal.WriteRune(r)
+		}
+		out.WriteString(literal.String())
+		if changed {
+			repaired = append(repaired, literal.String())
+		}
+	}
+	return out.String(), repaired
+}
+
+// splitFieldList splits a comma-separated field list, trimming blanks
+func splitFieldList(list string) []string {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// odataKeywords are filter tokens that are operators or literals, never field names
+var odataKeywords = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true,
+	"and": true, "or": true, "not": true, "has": true, "in": true,
+	"add": true, "sub": true, "mul": true, "div": true, "mod": true,
+	"true": true, "false": true, "null": true, "asc": true, "desc": true,
+	"INF": true, "NaN": true,
+}
+
+// extractFilterFields returns the field names referenced by an OData filter expression.
+// String literals, numbers, dates, keywords, function names and lambda variables are skipped.
+func extractFilterFields(filter string) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	lambdaVars := make(map[string]bool)
+
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		// Skip quoted string literals, honouring '' escapes
+		if r == '\'' {
+			i++
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			continue
+		}
+
+		// Skip numbers, dates and times (e.g. 2024-01-01T00:00:00Z)
+		if r >= '0' && r <= '9' {
+			for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '-' || runes[i] == ':' || runes[i] == '.') {
+				i++
+			}
+			continue
+		}
+
+		// Skip $it, $root and other built-in identifiers
+		if r == '$' {
+			i++
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			continue
+		}
+
+		if !isIdentStart(r) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '/' || runes[i] == '.') {
+			i++
+		}
+		token := string(runes[start:i])
+
+		// Function calls and enum literal prefixes (Namespace.Enum'Value'),
+		// but not lambda paths such as Appliances/any(...)
+		if i < len(runes) && (runes[i] == '(' || runes[i] == '\'') && !strings.Contains(token, "/") {
+			continue
+		}
+
+		// Lambda variable declarations (any(a: a eq 'x'))
+		if i < len(runes) && runes[i] == ':' {
+			lambdaVars[token] = true
+			continue
+		}
+
+		// Path expressions: only the first segment is a field of the entity
+		root := token
+		if idx := strings.IndexAny(root, "/."); idx >= 0 {
+			root = root[:idx]
+		}
+
+		if root == "" || odataKeywords[root] || lambdaVars[root] || seen[root] {
+			continue
+		}
+		seen[root] = true
+		fields = append(fields, root)
+	}
+
+	return fields
+}
+
+// extractOrderByFields returns the field names referenced by an OData orderby clause
+func extractOrderByFields(orderBy string) []string {
+	var fields []string
+	for _, clause := range splitFieldList(orderBy) {
+		if parts := strings.Fields(clause); len(parts) > 0 {
+			fields = append(fields, parts[0])
+		}
+	}
+	return fields
+}
+
+// parseOrderBy checks that each orderby clause is 'Field [asc|desc]' and returns the fields
+func parseOrderBy(orderBy string) ([]string, error) {
+	var fields []string
+	for _, clause := range strings.Split(orderBy, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			return nil, fmt.Errorf("invalid orderby %q: empty clause", orderBy)
+		}
+
+		parts := strings.Fields(clause)
+		if !fieldNamePattern.MatchString(parts[0]) {
+			return nil, fmt.Errorf("invalid orderby clause %q: %q is not a valid field name", clause, parts[0])
+		}
+		switch {
+		case len(parts) == 1:
+		case len(parts) == 2 && (strings.EqualFold(parts[1], "asc") || strings.EqualFold(parts[1], "desc")):
+		case len(parts) == 2:
+			return nil, fmt.Errorf("invalid orderby clause %q: direction must be 'asc' or 'desc', got %q", clause, parts[1])
+		default:
+			return nil, fmt.Errorf("invalid orderby clause %q: expected 'Field [asc|desc]' (separate multiple fields with commas)", clause)
+		}
+		fields = append(fields, parts[0])
+	}
+	return fields, nil
+}
+
+// isIdentStart reports whether r can begin an OData identifier
+func isIdentStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isIdentRune reports whether r can appear inside an OData identifier
+func isIdentRune(r rune) bool {
+	return isIdentStart(r) || (r >= '0' && r <= '9')
+}
+
+// combineFilters joins two filter expressions with 'and', parenthesising each side
+func combineFilters(existing, extra string) string {
+	switch {
+	case strings.TrimSpace(existing) == "":
+		return extra
+	case strings.TrimSpace(extra) == "":
+		return existing
+	default:
+		return fmt.Sprintf("(%s) and (%s)", existing, extra)
+	}
+}
+
+// extractComparisons returns the string-literal comparisons in a filter expression
+func extractComparisons(filter string) []filterComparison {
+	var comparisons []filterComparison
+	for _, match := range comparisonPattern.FindAllStringSubmatch(filter, -1) {
+		comparisons = append(comparisons, filterComparison{
+			Field:    match[1],
+			Operator: match[2],
+			Value:    strings.ReplaceAll(match[3], "''", "'"),
+		})
+	}
+	return comparisons
+}
+
+// filterToken is a lexical token of a filter expression: an identifier or path
+// ('i'), a quoted string ('s'), a number, date or time ('n') or punctuation ('p').
+// Start and end are rune offsets.
+type filterToken struct {
+	text       string
+	kind       byte
+	start, end int
+}
+
+// tokenizeFilter splits a filter expression into tokens, skipping whitespace
+func tokenizeFilter(runes []rune) []filterToken {
+	var tokens []filterToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+			continue
+		case r == '\'':
+			i++
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			if i > len(runes) {
+				i = len(runes)
+			}
+			tokens = append(tokens, filterToken{text: string(runes[start:i]), kind: 's', start: start, end: i})
+		case r >= '0' && r <= '9':
+			for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '-' || runes[i] == ':' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[start:i]), kind: 'n', start: start, end: i})
+		case isIdentStart(r) || r == '$':
+			i++
+			for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '/' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[start:i]), kind: 'i', start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, filterToken{text: string(r), kind: 'p', start: start, end: i})
+		}
+	}
+	return tokens
+}
+
+// bareValueOperators are the comparisons whose right-hand side auto-quoting may fix
+var bareValueOperators = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true, "has": true,
+}
+
+// autoQuoteFilter wraps bare words compared against a field in single quotes, e.g.
+// City eq Seattle becomes City eq 'Seattle', and returns the corrected comparisons.
+// Only a single plain word that ends the comparison is touched: numbers, dates,
+// keywords, quoted and enum literals, paths and function calls are left alone, and
+// quotable decides whether the field and word look like a string comparison.
+func autoQuoteFilter(filter string, quotable func(field, value string) bool) (string, []string) {
+	runes := []rune(filter)
+	tokens := tokenizeFilter(runes)
+
+	var corrected []string
+	var quoted []filterToken
+	for k := 0; k+2 < len(tokens); k++ {
+		field, op, value := tokens[k], tokens[k+1], tokens[k+2]
+		if field.kind != 'i' || op.kind != 'i' || value.kind != 'i' || !bareValueOperators[op.text] {
+			continue
+		}
+		if !fieldNamePattern.MatchString(field.text) || odataKeywords[field.text] {
+			continue
+		}
+		if strings.ContainsAny(value.text, "/.$") || odataKeywords[value.text] || odataKeywords[strings.ToLower(value.text)] {
+			continue
+		}
+
+		// The word must end the comparison: the end of the filter, a closing
+		// parenthesis, or 'and'/'or' after whitespace
+		if k+3 < len(tokens) {
+			next := tokens[k+3]
+			endsComparison := (next.kind == 'p' && next.text == ")") ||
+				(next.kind == 'i' && (next.text == "and" || next.text == "or") && next.start > value.end)
+			if !endsComparison {
+				continue
+			}
+		}
+
+		if !quotable(field.text, value.text) {
+			continue
+		}
+		quoted = append(quoted, value)
+		corrected = append(corrected, fmt.Sprintf("%s %s %s", field.text, op.text, QuoteODataString(value.text)))
+	}
+	if len(quoted) == 0 {
+		return filter, nil
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, token := range quoted {
+		out.WriteString(string(runes[last:token.start]))
+		out.WriteString(QuoteODataString(token.text))
+		last = token.end
+	}
+	out.WriteString(string(runes[last:]))
+	return out.String(), corrected
+}
+
+// booleanWords maps the Y/N spellings common in raw MLS data to OData booleans
+var booleanWords = map[string]string{
+	"y": "true", "yes": "true", "true": "true",
+	"n": "false", "no": "false", "false": "false",
+}
+
+// normalizeBooleanFilter rewrites eq and ne comparisons of boolean fields against
+// 'Y', 'No', Yes and the like as OData booleans, e.g. PoolPrivateYN eq 'Y' becomes
+// PoolPrivateYN eq true, and returns the corrected comparisons. isBoolean decides
+// which fields hold booleans.
+func normalizeBooleanFilter(filter string, isBoolean func(field string) bool) (string, []string) {
+	runes := []rune(filter)
+	tokens := tokenizeFilter(runes)
+
+	type replacement struct {
+		token   filterToken
+		literal string
+	}
+	var corrected []string
+	var replacements []replacement
+	for k := 0; k+2 < len(tokens); k++ {
+		field, op, value := tokens[k], tokens[k+1], tokens[k+2]
+		if field.kind != 'i' || op.kind != 'i' || (op.text != "eq" && op.text != "ne") {
+			continue
+		}
+		if !fieldNamePattern.MatchString(field.text) || odataKeywords[field.text] {
+			continue
+		}
+
+		var word string
+		switch value.kind {
+		case 's':
+			word = strings.Trim(value.text, "'")
+		case 'i':
+			// Bare true and false are already valid
+			if value.text == "true" || value.text == "false" {
+				continue
+			}
+			word = value.text
+		default:
+			continue
+		}
+		literal, ok := booleanWords[strings.ToLower(strings.TrimSpace(word))]
+		if !ok || !isBoolean(field.text) {
+			continue
+		}
+		replacements = append(replacements, replacement{token: value, literal: literal})
+		corrected = append(corrected, fmt.Sprintf("%s %s %s", field.text, op.text, literal))
+	}
+	if len(replacements) == 0 {
+		return filter, nil
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, r := range replacements {
+		out.WriteString(string(runes[last:r.token.start]))
+		out.WriteString(r.literal)
+		last = r.token.end
+	}
+	out.WriteString(string(runes[last:]))
+	return out.String(), corrected
+}
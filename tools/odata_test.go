@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+func TestRepairFilterLiterals(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       string
+		want         string
+		wantRepaired []string
+	}{
+		{"apostrophe in a surname", "ListAgentLastName eq 'O'Brien'", "ListAgentLastName eq 'O''Brien'", []string{"'O''Brien'"}},
+		{"apostrophe mid-phrase", "City eq 'Coeur d'Alene' and StateOrProvince eq 'ID'", "City eq 'Coeur d''Alene' and StateOrProvince eq 'ID'", []string{"'Coeur d''Alene'"}},
+		{"already doubled", "City eq 'Coeur d''Alene'", "City eq 'Coeur d''Alene'", nil},
+		{"empty literal", "City eq ''", "City eq ''", nil},
+		{"in list", "City in ('O'Fallon','Seattle')", "City in ('O''Fallon','Seattle')", []string{"'O''Fallon'"}},
+		{"control characters inside a literal", "City eq 'Sea\nttle'", "City eq 'Sea ttle'", []string{"'Sea ttle'"}},
+		{"control characters outside literals", "City eq 'Seattle'\tand\r\nListPrice gt 5", "City eq 'Seattle' and  ListPrice gt 5", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repaired := repairFilterLiterals(tt.filter)
+			if got != tt.want {
+				t.Errorf("repairFilterLiterals(%q) = %q, want %q", tt.filter, got, tt.want)
+			}
+			if !reflect.DeepEqual(repaired, tt.wantRepaired) {
+				t.Errorf("repaired = %q, want %q", repaired, tt.wantRepaired)
+			}
+		})
+	}
+}
+
+func TestQuoteODataString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"O'Brien", "'O''Brien'"},
+		{"Coeur d'Alene", "'Coeur d''Alene'"},
+		{"''", "''''''"},
+		{"two\nlines\tand a tab", "'two lines and a tab'"},
+	}
+	for _, tt := range tests {
+		if got := QuoteODataString(tt.value); got != tt.want {
+			t.Errorf("QuoteODataString(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSearchStripsControlCharacters(t *testing.T) {
+	tool := NewResoQueryTool(nil, config.DefaultConfig())
+	params, err := tool.parseArguments(map[string]interface{}{
+		"entity": "Property",
+		"search": "waterfront\n\tview\x00",
+	})
+	if err != nil {
+		t.Fatalf("parseArguments: %v", err)
+	}
+	if params.Search != "waterfront  view" {
+		t.Errorf("Search = %q, want control characters replaced with spaces", params.Search)
+	}
+}
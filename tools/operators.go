@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// policyOperators are the filter operators and functions the allowed_operators
+// setting can name. The logical operators and, or and not are always allowed.
+var policyOperators = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true,
+	"has": true, "in": true,
+	"add": true, "sub": true, "mul": true, "div": true, "mod": true,
+	"contains": true, "startswith": true, "endswith": true, "matchespattern": true,
+	"length": true, "indexof": true, "substring": true, "tolower": true, "toupper": true,
+	"trim": true, "concat": true,
+	"year": true, "month": true, "day": true, "hour": true, "minute": true, "second": true,
+	"date": true, "time": true, "now": true,
+	"round": true, "floor": true, "ceiling": true,
+	"any": true, "all": true,
+	"geo.distance": true, "geo.intersects": true, "geo.length": true,
+}
+
+// logicalOperators combine conditions and are never restricted
+var logicalOperators = map[string]bool{"and": true, "or": true, "not": true}
+
+// UnknownFilterOperators returns the names in an allowed_operators list that are not
+// filter operators, so a typo does not silently block an operator
+func UnknownFilterOperators(names []string) []string {
+	var unknown []string
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !policyOperators[name] && !logicalOperators[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// extractFilterOperators returns the operators and functions used in a filter, in
+// order of first use and lower-cased. Lambda operators on a collection path
+// (Appliances/any(...)) are reported as any and all.
+func extractFilterOperators(filter string) []string {
+	var operators []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			operators = append(operators, name)
+		}
+	}
+
+	tokens := tokenizeFilter([]rune(filter))
+	for i, token := range tokens {
+		if token.kind != 'i' {
+			continue
+		}
+		name := strings.ToLower(token.text)
+		call := i+1 < len(tokens) && tokens[i+1].text == "("
+		switch {
+		case call && (strings.HasSuffix(name, "/any") || strings.HasSuffix(name, "/all")):
+			add(name[strings.LastIndex(name, "/")+1:])
+		case call && policyOperators[name]:
+			add(name)
+		case !call && policyOperators[name] && odataKeywords[name]:
+			add(name)
+		}
+	}
+	return operators
+}
+
+// checkFilterOperators rejects a filter, and the filters inside an expand clause, that
+// use an operator missing from allowed. An empty allowed list permits everything.
+func checkFilterOperators(filter, expand string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	permitted := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		permitted[strings.ToLower(name)] = true
+	}
+
+	var denied []string
+	seen := make(map[string]bool)
+	for _, f := range append([]string{filter}, expandFilters(expand)...) {
+		for _, operator := range extractFilterOperators(f) {
+			if !permitted[operator] && !seen[operator] {
+				seen[operator] = true
+				denied = append(denied, operator)
+			}
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+
+	allowedList := append([]string(nil), allowed...)
+	sort.Strings(allowedList)
+	return fmt.Errorf("filter operator(s) %s are not allowed on this server (allowed: %s; and, or and not are always allowed)",
+		strings.Join(denied, ", "), strings.Join(allowedList, ", "))
+}
+
+// expandFilters returns the $filter options of an expand clause, including those of
+// nested expands
+func expandFilters(expand string) []string {
+	if expand == "" {
+		return nil
+	}
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return nil
+	}
+	var filters []string
+	for _, segment := range segments {
+		if filter := segment.Options["$filter"]; filter != "" {
+			filters = append(filters, filter)
+		}
+		filters = append(filters, expandFilters(segment.Options["$expand"])...)
+	}
+	return filters
+}
+
+// operatorNote tells agents which filter operators the server allows, or "" when
+// every operator is allowed
+func operatorNote(allowed []string) string {
+	if len(allowed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n**Allowed operators on this server**: %s (and, or and not are always allowed). Filters using any other operator or function are rejected.", strings.Join(allowed, ", "))
+}
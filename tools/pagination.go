@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"github.com/rennietech/constellation1-mcp-server/api"
+)
+
+// Pagination describes where a reso_query page sits in the full result set so a
+// client can request the next page without parsing @odata.nextLink
+type Pagination struct {
+	Count           int    `json:"count"`
+	TotalCount      *int   `json:"totalCount"`
+	Skip            int    `json:"skip"`
+	HasMore         bool   `json:"hasMore"`
+	NextSkip        *int   `json:"nextSkip"`
+	EntitySkipLimit int    `json:"entitySkipLimit"`
+	NextLink        string `json:"nextLink,omitempty"`
+	DeltaLink       string `json:"deltaLink,omitempty"`
+	Truncated       bool   `json:"truncated,omitempty"`
+}
+
+// buildPagination computes the pagination block from a response as returned by the
+// server, before any client-side filtering. nextSkip is null when there are no more
+// records or the next page would pass the entity skip limit.
+func buildPagination(response *api.APIResponse) *Pagination {
+	params := response.RequestParams
+	pagination := &Pagination{
+		Count:           len(response.Value),
+		Skip:            params.Skip,
+		EntitySkipLimit: api.GetEntitySkipLimit(params.Entity),
+		NextLink:        response.NextLink,
+		DeltaLink:       response.DeltaLink,
+	}
+
+	// A total that only counts the records seen so far is not reported
+	if response.TotalCountExact {
+		total := response.TotalCount
+		pagination.TotalCount = &total
+	}
+
+	next := params.Skip + pagination.Count
+	pagination.HasMore = response.MoreAvailable() ||
+		(pagination.TotalCount != nil && next < *pagination.TotalCount)
+	withinLimit := next <= pagination.EntitySkipLimit &&
+		(params.Top <= 0 || next+params.Top <= pagination.EntitySkipLimit)
+	// Pages of a delta query are only reachable through nextLink
+	if pagination.HasMore && pagination.Count > 0 && withinLimit && params.DeltaLink == "" {
+		pagination.NextSkip = &next
+	}
+
+	return pagination
+}
+
+// applyTruncation points nextSkip at the first record dropped by the response size
+// limit. Only plain skip/top pages can resume that way; otherwise nextSkip is cleared.
+func (p *Pagination) applyTruncation(kept int, resumable bool) {
+	p.Truncated = true
+	p.HasMore = true
+	p.NextSkip = nil
+	next := p.Skip + kept
+	if resumable && next <= p.EntitySkipLimit {
+		p.NextSkip = &next
+	}
+}
+
+// countRemoved counts the delta records that mark a removal with @removed
+func countRemoved(records []map[string]interface{}) int {
+	removed := 0
+	for _, record := range records {
+		if _, ok := record["@removed"]; ok {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// checkEntityPolicy applies the allowed_entities policy to the queried entity and to
+// every entity reached through $expand, so a disallowed entity cannot be read as an
+// expansion of an allowed one
+func checkEntityPolicy(client *api.Client, parser *metadata.MetadataParser, entity, expand string) error {
+	if err := client.CheckEntityAllowed(entity); err != nil {
+		return err
+	}
+	if expand == "" {
+		return nil
+	}
+
+	segments, err := parseExpand(expand)
+	if err != nil {
+		// Malformed expands are reported by argument parsing and validation
+		return nil
+	}
+	for _, segment := range segments {
+		target := expandTarget(parser, entity, segment.Name)
+		if err := checkEntityPolicy(client, parser, target, segment.Options["$expand"]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandTarget resolves the entity a navigation property points to, using the
+// metadata target type when available. Role-prefixed names such as ListMember or
+// CoBuyerOffice map to the supported entity they end with.
+func expandTarget(parser *metadata.MetadataParser, entity, navName string) string {
+	target := navName
+	if parser != nil {
+		if entityInfo, exists := parser.GetEntityInfo(entity); exists {
+			if nav, exists := entityInfo.NavigationProperties[navName]; exists {
+				target = nav.TargetType
+			}
+		}
+	}
+
+	resolved := target
+	longest := 0
+	for _, name := range api.GetSupportedEntityNames() {
+		if strings.HasSuffix(target, name) && len(name) > longest {
+			resolved, longest = name, len(name)
+		}
+	}
+	return resolved
+}
+
+// allowedExpand drops the segments of a fixed expand clause whose entities the
+// allowed_entities policy excludes
+func allowedExpand(client *api.Client, entity, expand string) string {
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return expand
+	}
+
+	var allowed []string
+	for _, segment := range segments {
+		if checkEntityPolicy(client, nil, entity, segment.Raw) == nil {
+			allowed = append(allowed, segment.Raw)
+		}
+	}
+	return strings.Join(allowed, ",")
+}
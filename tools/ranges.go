@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rangeArg is a reso_query convenience argument that bounds one Property field
+type rangeArg struct {
+	Name     string
+	Field    string
+	Operator string
+	Integer  bool
+	Label    string
+}
+
+// rangeArgs are the convenience range arguments in schema order; each _min/_max pair
+// bounds the same field
+var rangeArgs = []rangeArg{
+	{Name: "price_min", Field: "ListPrice", Operator: "ge", Label: "Minimum list price in dollars"},
+	{Name: "price_max", Field: "ListPrice", Operator: "le", Label: "Maximum list price in dollars"},
+	{Name: "beds_min", Field: "BedroomsTotal", Operator: "ge", Integer: true, Label: "Minimum bedrooms"},
+	{Name: "beds_max", Field: "BedroomsTotal", Operator: "le", Integer: true, Label: "Maximum bedrooms"},
+	{Name: "baths_min", Field: "BathroomsTotalInteger", Operator: "ge", Integer: true, Label: "Minimum bathrooms"},
+	{Name: "baths_max", Field: "BathroomsTotalInteger", Operator: "le", Integer: true, Label: "Maximum bathrooms"},
+	{Name: "living_area_min", Field: "LivingArea", Operator: "ge", Label: "Minimum living area in square feet"},
+	{Name: "living_area_max", Field: "LivingArea", Operator: "le", Label: "Maximum living area in square feet"},
+	{Name: "lot_acres_min", Field: "LotSizeAcres", Operator: "ge", Label: "Minimum lot size in acres"},
+	{Name: "lot_acres_max", Field: "LotSizeAcres", Operator: "le", Label: "Maximum lot size in acres"},
+	{Name: "year_built_min", Field: "YearBuilt", Operator: "ge", Integer: true, Label: "Oldest year built"},
+	{Name: "year_built_max", Field: "YearBuilt", Operator: "le", Integer: true, Label: "Newest year built"},
+	{Name: "days_on_market_max", Field: "DaysOnMarket", Operator: "le", Integer: true, Label: "Maximum days on market"},
+}
+
+// withRangeProperties adds the range arguments to a tool's schema properties
+func withRangeProperties(properties map[string]interface{}) map[string]interface{} {
+	for _, arg := range rangeArgs {
+		schemaType := "number"
+		if arg.Integer {
+			schemaType = "integer"
+		}
+		properties[arg.Name] = map[string]interface{}{
+			"type":        schemaType,
+			"description": fmt.Sprintf("%s (Property only). Shorthand for \"%s %s <value>\", ANDed with filter or filters.", arg.Label, arg.Field, arg.Operator),
+			"minimum":     0,
+		}
+	}
+	return properties
+}
+
+// buildRangeFilter translates the range arguments given in args into an OData filter,
+// in rangeArgs order, rejecting non-numeric values and minimums above their maximums
+func buildRangeFilter(args map[string]interface{}) (string, error) {
+	var clauses []string
+	minimums := make(map[string]float64)
+	for _, arg := range rangeArgs {
+		raw, ok := args[arg.Name]
+		if !ok || raw == nil {
+			continue
+		}
+
+		var value float64
+		switch v := raw.(type) {
+		case float64:
+			value = v
+		case int:
+			value = float64(v)
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", fmt.Errorf("%s must be a number, got %q", arg.Name, v)
+			}
+			value = parsed
+		default:
+			return "", fmt.Errorf("%s must be a number", arg.Name)
+		}
+		if value < 0 {
+			return "", fmt.Errorf("%s must not be negative, got %v", arg.Name, value)
+		}
+		if arg.Integer && value != float64(int64(value)) {
+			return "", fmt.Errorf("%s must be a whole number, got %v", arg.Name, value)
+		}
+
+		if arg.Operator == "ge" {
+			minimums[arg.Field] = value
+		} else if minimum, ok := minimums[arg.Field]; ok && minimum > value {
+			prefix := strings.TrimSuffix(arg.Name, "_max")
+			return "", fmt.Errorf("%s_min (%v) is greater than %s (%v)", prefix, minimum, arg.Name, value)
+		}
+		clauses = append(clauses, fmt.Sprintf("%s %s %s", arg.Field, arg.Operator, strconv.FormatFloat(value, 'f', -1, 64)))
+	}
+	return strings.Join(clauses, " and "), nil
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// maxBatchQueries caps the number of sub-queries in one reso_batch call
+const maxBatchQueries = 10
+
+// ResoBatchTool implements the reso_batch MCP tool, which runs several queries
+// concurrently and returns their results keyed by label
+type ResoBatchTool struct {
+	client    *api.Client
+	config    *config.Config
+	queryTool *ResoQueryTool
+}
+
+// BatchQueryResult is the outcome of one sub-query in a batch
+type BatchQueryResult struct {
+	Label          string                   `json:"label"`
+	Entity         string                   `json:"entity"`
+	Count          int                      `json:"count"`
+	ResponseTimeMs int64                    `json:"response_time_ms"`
+	Error          string                   `json:"error,omitempty"`
+	Note           string                   `json:"note,omitempty"`
+	Value          []map[string]interface{} `json:"value,omitempty"`
+	NextLink       string                   `json:"next_link,omitempty"`
+	Truncated      bool                     `json:"truncated,omitempty"`
+}
+
+// BatchResult represents the combined results of a batch
+type BatchResult struct {
+	Queries          int                          `json:"queries"`
+	Succeeded        int                          `json:"succeeded"`
+	Failed           int                          `json:"failed"`
+	WallClockMs      int64                        `json:"wall_clock_ms"`
+	SummedResponseMs int64                        `json:"summed_response_ms"`
+	Results          map[string]*BatchQueryResult `json:"results"`
+}
+
+// NewResoBatchTool creates a new RESO batch tool
+func NewResoBatchTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoBatchTool {
+	return &ResoBatchTool{
+		client:    client,
+		config:    cfg,
+		queryTool: NewResoQueryToolWithMetadata(client, cfg, parser),
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoBatchTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_batch",
+		Description: fmt.Sprintf("Run up to %d RESO queries concurrently and return the combined results keyed by label. Use this for detail views that need several related entities at once (e.g. a Property plus its Media, OpenHouse and Dom records). Each query accepts the same arguments as reso_query plus an optional 'label'. A failing query reports its error without failing the rest of the batch.", maxBatchQueries),
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"queries": map[string]interface{}{
+					"type":        "array",
+					"description": "Query specs to run. Each is an object with 'entity' (required), optional 'label' (defaults to query_<n>) and any reso_query argument such as select, filter, top, orderby, expand, ignorenulls or ignorecase.\n\nExample: [{\"label\": \"listing\", \"entity\": \"Property\", \"filter\": \"ListingKey eq 'ABC'\"}, {\"label\": \"photos\", \"entity\": \"Media\", \"filter\": \"ResourceRecordKey eq 'ABC'\", \"orderby\": \"Order asc\"}]",
+					"items": map[string]interface{}{
+						"type": "object",
+					},
+					"minItems": 1,
+					"maxItems": maxBatchQueries,
+				},
+			},
+			"required": []string{"queries"},
+		},
+	}
+}
+
+// Execute executes the RESO batch tool
+func (t *ResoBatchTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	specs, ok := args["queries"].([]interface{})
+	if !ok || len(specs) == 0 {
+		return errorResult("Error parsing arguments: queries must be a non-empty array")
+	}
+	if len(specs) > maxBatchQueries {
+		return errorResult(fmt.Sprintf("Error parsing arguments: at most %d queries are allowed per batch, got %d", maxBatchQueries, len(specs)))
+	}
+
+	// Parse every spec up front so labels are unique and argument errors are reported per query
+	labels := batchLabels(specs)
+	results := make([]*BatchQueryResult, len(specs))
+	params := make([]*api.QueryParams, len(specs))
+	for i, raw := range specs {
+		spec, _ := raw.(map[string]interface{})
+		result := &BatchQueryResult{Label: labels[i]}
+		results[i] = result
+		if spec == nil {
+			result.Error = "query spec must be an object"
+			continue
+		}
+
+		p, err := t.queryTool.parseArguments(spec)
+		if err != nil {
+			result.Error = fmt.Sprintf("invalid arguments: %s", err.Error())
+			continue
+		}
+		result.Entity = p.Entity
+		if err := checkEntityPolicy(t.client, t.queryTool.metadataParser, p.Entity, p.Expand); err != nil {
+			result.Error = fmt.Sprintf("policy error: %s", err.Error())
+			continue
+		}
+		if err := checkFilterOperators(p.Filter, p.Expand, t.config.AllowedOperators); err != nil {
+			result.Error = fmt.Sprintf("policy error: %s", err.Error())
+			continue
+		}
+		result.Note = t.queryTool.applyTopLimits(p, !p.FetchAll)
+		if skipValidation, _ := spec["skip_validation"].(bool); !skipValidation {
+			if err := t.queryTool.validateFields(p); err != nil {
+				result.Error = fmt.Sprintf("validation error: %s", err.Error())
+				continue
+			}
+		}
+		params[i] = p
+	}
+
+	// Run the valid queries concurrently; the client's rate limiter paces them
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range params {
+		if params[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(result *BatchQueryResult, p api.QueryParams) {
+			defer wg.Done()
+			queryStart := time.Now()
+			response, err := t.client.Query(p)
+			result.ResponseTimeMs = time.Since(queryStart).Milliseconds()
+			if err != nil {
+				result.Error = err.Error() + nestedExpandHint(p.Expand, err)
+				return
+			}
+			result.Count = len(response.Value)
+			result.Value = response.Value
+			result.NextLink = response.NextLink
+			result.Truncated = response.Truncated
+		}(results[i], *params[i])
+	}
+	wg.Wait()
+
+	batch := &BatchResult{
+		Queries:     len(results),
+		WallClockMs: time.Since(start).Milliseconds(),
+		Results:     make(map[string]*BatchQueryResult, len(results)),
+	}
+	for _, result := range results {
+		batch.Results[result.Label] = result
+		batch.SummedResponseMs += result.ResponseTimeMs
+		if result.Error != "" {
+			batch.Failed++
+		} else {
+			batch.Succeeded++
+		}
+	}
+
+	batchJSON, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatBatch(batch, results),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Batch Results:\n```json\n%s\n```", string(batchJSON)),
+			},
+		},
+		IsError: batch.Succeeded == 0,
+	}
+}
+
+// batchLabels returns each query's label. The first query to claim a label keeps
+// it; unlabeled queries and later duplicates get query_<n>, counting up from their
+// position until the name is unused.
+func batchLabels(specs []interface{}) []string {
+	labels := make([]string, len(specs))
+	used := make(map[string]bool)
+	for i, raw := range specs {
+		spec, _ := raw.(map[string]interface{})
+		label, _ := spec["label"].(string)
+		if label = strings.TrimSpace(label); label != "" && !used[label] {
+			labels[i] = label
+			used[label] = true
+		}
+	}
+	for i := range labels {
+		if labels[i] != "" {
+			continue
+		}
+		for n := i + 1; ; n++ {
+			if label := fmt.Sprintf("query_%d", n); !used[label] {
+				labels[i] = label
+				used[label] = true
+				break
+			}
+		}
+	}
+	return labels
+}
+
+// formatBatch renders a per-query summary of the batch in submission order
+func formatBatch(batch *BatchResult, results []*BatchQueryResult) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Batch Results\n")
+	out.WriteString("==================\n\n")
+
+	out.WriteString(fmt.Sprintf("Queries: %d (%d succeeded, %d failed)\n", batch.Queries, batch.Succeeded, batch.Failed))
+	out.WriteString(fmt.Sprintf("Wall-Clock Time: %d ms\n", batch.WallClockMs))
+	out.WriteString(fmt.Sprintf("Summed Response Time: %d ms\n\n", batch.SummedResponseMs))
+
+	for _, result := range results {
+		if result.Error != "" {
+			out.WriteString(fmt.Sprintf("- %s: ❌ %s\n", result.Label, result.Error))
+			continue
+		}
+		out.WriteString(fmt.Sprintf("- %s: %s, %d record(s) in %d ms", result.Label, result.Entity, result.Count, result.ResponseTimeMs))
+		if result.NextLink != "" || result.Truncated {
+			out.WriteString(" (more available)")
+		}
+		if result.Note != "" {
+			out.WriteString(fmt.Sprintf(" - note: %s", result.Note))
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
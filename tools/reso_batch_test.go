@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBatchLabelsAreUnique(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []interface{}
+		want   []string
+	}{
+		{"defaults by position", []interface{}{nil, nil}, []string{"query_1", "query_2"}},
+		{"explicit label matching a later default", []interface{}{"query_2", nil}, []string{"query_2", "query_3"}},
+		{"explicit label matching an earlier default", []interface{}{nil, "query_1"}, []string{"query_2", "query_1"}},
+		{"duplicate labels", []interface{}{"homes", " homes ", "photos", nil}, []string{"homes", "query_2", "photos", "query_4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs := make([]interface{}, len(tt.labels))
+			for i, label := range tt.labels {
+				spec := map[string]interface{}{"entity": "Property"}
+				if label != nil {
+					spec["label"] = label
+				}
+				specs[i] = spec
+			}
+			if got := batchLabels(specs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batchLabels = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+// comparableFields are the Property fields requested for the subject and each comp
+const comparableFields = "ListingKey,UnparsedAddress,City,StateOrProvince,PostalCode,StandardStatus,PropertyType,PropertySubType,ListPrice,ClosePrice,CloseDate,BedroomsTotal,BathroomsTotalInteger,LivingArea,YearBuilt,Latitude,Longitude"
+
+// ResoComparablesTool implements the reso_comparables MCP tool for CMA-style comparable sales
+type ResoComparablesTool struct {
+	client *api.Client
+	config *config.Config
+}
+
+// comparableSubject describes the property that comps are measured against
+type comparableSubject struct {
+	ListingKey      string
+	Address         string
+	Lat             float64
+	Lon             float64
+	Beds            float64
+	Baths           float64
+	LivingArea      float64
+	Price           float64
+	PropertySubType string
+}
+
+// comparableOptions holds the search tolerances
+type comparableOptions struct {
+	RadiusMiles       float64
+	DaysBack          int
+	BedsTolerance     float64
+	BathsTolerance    float64
+	AreaTolerancePct  float64
+	PriceTolerancePct float64
+	MaxResults        int
+}
+
+// Comparable is a ranked comparable sale
+type Comparable struct {
+	ListingKey    string                 `json:"listing_key"`
+	Score         float64                `json:"similarity_score"`
+	DistanceMiles float64                `json:"distance_miles"`
+	Record        map[string]interface{} `json:"record"`
+}
+
+// NewResoComparablesTool creates a new RESO comparables tool
+func NewResoComparablesTool(client *api.Client, cfg *config.Config) *ResoComparablesTool {
+	return &ResoComparablesTool{
+		client: client,
+		config: cfg,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoComparablesTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_comparables",
+		Description: "Find comparable sales (comps) for a comparative market analysis. Given a subject listing (by ListingKey, or by address/coordinates plus beds, baths and living area), searches recently Closed Property listings nearby with similar bedrooms, bathrooms and living area, then ranks them by a 0-100 similarity score. Defaults to Closed sales within the last 180 days and a 1-mile radius. Returns a concise comps table plus the raw records.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"listing_key": map[string]interface{}{
+					"type":        "string",
+					"description": "ListingKey of the subject property. Its coordinates, beds, baths, living area and price are used unless overridden below.",
+				},
+				"address": map[string]interface{}{
+					"type":        "string",
+					"description": "Subject address, matched against UnparsedAddress to locate the subject when no listing_key is given.",
+				},
+				"lat": map[string]interface{}{
+					"type":        "number",
+					"description": "Subject latitude (use with lon when the subject is not in the MLS).",
+				},
+				"lon": map[string]interface{}{
+					"type":        "number",
+					"description": "Subject longitude (use with lat when the subject is not in the MLS).",
+				},
+				"beds": map[string]interface{}{
+					"type":        "number",
+					"description": "Subject bedrooms (overrides the subject listing value).",
+				},
+				"baths": map[string]interface{}{
+					"type":        "number",
+					"description": "Subject bathrooms (overrides the subject listing value).",
+				},
+				"living_area": map[string]interface{}{
+					"type":        "number",
+					"description": "Subject living area in square feet (overrides the subject listing value).",
+				},
+				"radius_miles": map[string]interface{}{
+					"type":        "number",
+					"description": "Search radius in miles. Default: 1.",
+					"default":     1,
+				},
+				"days_back": map[string]interface{}{
+					"type":        "integer",
+					"description": "Only include sales that closed within this many days. Default: 180.",
+					"default":     180,
+				},
+				"beds_tolerance": map[string]interface{}{
+					"type":        "number",
+					"description": "Allowed difference in bedrooms. Default: 1.",
+					"default":     1,
+				},
+				"baths_tolerance": map[string]interface{}{
+					"type":        "number",
+					"description": "Allowed difference in bathrooms. Default: 1.",
+					"default":     1,
+				},
+				"living_area_tolerance_pct": map[string]interface{}{
+					"type":        "number",
+					"description": "Allowed living area difference as a percentage of the subject. Default: 20.",
+					"default":     20,
+				},
+				"price_tolerance_pct": map[string]interface{}{
+					"type":        "number",
+					"description": "Optional allowed ClosePrice difference as a percentage of the subject's list price. Omit to ignore price.",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of ranked comps to return. Default: 10.",
+					"default":     10,
+				},
+			},
+		},
+	}
+}
+
+// Execute executes the RESO comparables tool
+func (t *ResoComparablesTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	opts := parseComparableOptions(args)
+
+	subject, err := t.resolveSubject(args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error resolving subject: %s", err.Error()))
+	}
+
+	near := &nearSearch{Lat: subject.Lat, Lon: subject.Lon, RadiusMiles: opts.RadiusMiles, Exact: true}
+
+	response, err := t.client.Query(api.QueryParams{
+		Entity:      "Property",
+		Select:      comparableFields,
+		Filter:      buildComparablesFilter(subject, opts, near),
+		Top:         200,
+		OrderBy:     "CloseDate desc",
+		IgnoreNulls: true,
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error querying comparables: %s", err.Error()))
+	}
+
+	records, _ := near.Apply(response.Value)
+	comps := rankComparables(subject, opts, records)
+	if len(comps) > opts.MaxResults {
+		comps = comps[:opts.MaxResults]
+	}
+
+	compsJSON, err := json.MarshalIndent(comps, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatComparables(subject, opts, comps),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Comparable Records:\n```json\n%s\n```", string(compsJSON)),
+			},
+		},
+	}
+}
+
+// parseComparableOptions reads tolerances from the arguments, applying defaults
+func parseComparableOptions(args map[string]interface{}) comparableOptions {
+	opts := comparableOptions{
+		RadiusMiles:      1,
+		DaysBack:         180,
+		BedsTolerance:    1,
+		BathsTolerance:   1,
+		AreaTolerancePct: 20,
+		MaxResults:       10,
+	}
+
+	if v, err := numberArg(args, "radius_miles"); err == nil && v > 0 {
+		opts.RadiusMiles = v
+	}
+	if v, err := numberArg(args, "days_back"); err == nil && v > 0 {
+		opts.DaysBack = int(v)
+	}
+	if v, err := numberArg(args, "beds_tolerance"); err == nil && v >= 0 {
+		opts.BedsTolerance = v
+	}
+	if v, err := numberArg(args, "baths_tolerance"); err == nil && v >= 0 {
+		opts.BathsTolerance = v
+	}
+	if v, err := numberArg(args, "living_area_tolerance_pct"); err == nil && v >= 0 {
+		opts.AreaTolerancePct = v
+	}
+	if v, err := numberArg(args, "price_tolerance_pct"); err == nil && v > 0 {
+		opts.PriceTolerancePct = v
+	}
+	if v, err := numberArg(args, "max_results"); err == nil && v > 0 {
+		opts.MaxResults = int(v)
+	}
+
+	return opts
+}
+
+// resolveSubject builds the subject from a listing, an address lookup or explicit values
+func (t *ResoComparablesTool) resolveSubject(args map[string]interface{}) (*comparableSubject, error) {
+	subject := &comparableSubject{}
+
+	var lookup string
+	if key, ok := args["listing_key"].(string); ok && strings.TrimSpace(key) != "" {
+		lookup = fmt.Sprintf("ListingKey eq %s", QuoteODataString(strings.TrimSpace(key)))
+	} else if address, ok := args["address"].(string); ok && strings.TrimSpace(address) != "" {
+		subject.Address = strings.TrimSpace(address)
+		lookup = fmt.Sprintf("UnparsedAddress eq %s", QuoteODataString(subject.Address))
+	}
+
+	hasCoordinates := false
+	if lookup != "" {
+		response, err := t.client.Query(api.QueryParams{
+			Entity:      "Property",
+			Select:      comparableFields,
+			Filter:      lookup,
+			Top:         1,
+			OrderBy:     "ModificationTimestamp desc",
+			IgnoreNulls: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("subject lookup failed: %w", err)
+		}
+		if len(response.Value) > 0 {
+			record := response.Value[0]
+			subject.ListingKey = recordID(record, 0)
+			if address, ok := record["UnparsedAddress"].(string); ok {
+				subject.Address = address
+			}
+			subject.Lat, hasCoordinates = toFloat(record["Latitude"])
+			if lon, ok := toFloat(record["Longitude"]); ok {
+				subject.Lon = lon
+			} else {
+				hasCoordinates = false
+			}
+			subject.Beds, _ = toFloat(record["BedroomsTotal"])
+			subject.Baths, _ = toFloat(record["BathroomsTotalInteger"])
+			subject.LivingArea, _ = toFloat(record["LivingArea"])
+			if price, ok := toFloat(record["ListPrice"]); ok {
+				subject.Price = price
+			}
+			if subType, ok := record["PropertySubType"].(string); ok {
+				subject.PropertySubType = subType
+			}
+		} else if _, hasLat := args["lat"]; !hasLat {
+			return nil, fmt.Errorf("no Property record matched the subject (%s)", lookup)
+		}
+	}
+
+	// Explicit values override the subject listing
+	if lat, err := numberArg(args, "lat"); err == nil {
+		lon, err := numberArg(args, "lon")
+		if err != nil {
+			return nil, fmt.Errorf("lon is required when lat is given")
+		}
+		subject.Lat, subject.Lon, hasCoordinates = lat, lon, true
+	}
+	if v, err := numberArg(args, "beds"); err == nil {
+		subject.Beds = v
+	}
+	if v, err := numberArg(args, "baths"); err == nil {
+		subject.Baths = v
+	}
+	if v, err := numberArg(args, "living_area"); err == nil {
+		subject.LivingArea = v
+	}
+
+	if !hasCoordinates {
+		return nil, fmt.Errorf("subject coordinates unknown; provide listing_key, an address with Latitude/Longitude, or lat and lon")
+	}
+
+	return subject, nil
+}
+
+// buildComparablesFilter builds the OData filter for closed sales similar to the subject
+func buildComparablesFilter(subject *comparableSubject, opts comparableOptions, near *nearSearch) string {
+	since := time.Now().AddDate(0, 0, -opts.DaysBack).Format("2006-01-02")
+	clauses := []string{
+		"StandardStatus eq 'Closed'",
+		fmt.Sprintf("CloseDate ge %s", since),
+		near.BoundingBoxFilter(),
+	}
+
+	if subject.ListingKey != "" {
+		clauses = append(clauses, fmt.Sprintf("ListingKey ne %s", QuoteODataString(subject.ListingKey)))
+	}
+	if subject.PropertySubType != "" {
+		clauses = append(clauses, fmt.Sprintf("PropertySubType eq %s", QuoteODataString(subject.PropertySubType)))
+	}
+	if subject.Beds > 0 {
+		clauses = append(clauses, fmt.Sprintf("BedroomsTotal ge %g and BedroomsTotal le %g",
+			math.Max(0, subject.Beds-opts.BedsTolerance), subject.Beds+opts.BedsTolerance))
+	}
+	if subject.Baths > 0 {
+		clauses = append(clauses, fmt.Sprintf("BathroomsTotalInteger ge %g and BathroomsTotalInteger le %g",
+			math.Max(0, subject.Baths-opts.BathsTolerance), subject.Baths+opts.BathsTolerance))
+	}
+	if subject.LivingArea > 0 {
+		delta := subject.LivingArea * opts.AreaTolerancePct / 100
+		clauses = append(clauses, fmt.Sprintf("LivingArea ge %.0f and LivingArea le %.0f",
+			subject.LivingArea-delta, subject.LivingArea+delta))
+	}
+	if opts.PriceTolerancePct > 0 && subject.Price > 0 {
+		delta := subject.Price * opts.PriceTolerancePct / 100
+		clauses = append(clauses, fmt.Sprintf("ClosePrice ge %.0f and ClosePrice le %.0f",
+			subject.Price-delta, subject.Price+delta))
+	}
+
+	return strings.Join(clauses, " and ")
+}
+
+// rankComparables scores each record against the subject and sorts by similarity
+func rankComparables(subject *comparableSubject, opts comparableOptions, records []map[string]interface{}) []Comparable {
+	comps := make([]Comparable, 0, len(records))
+
+	for i, record := range records {
+		lat, _ := toFloat(record["Latitude"])
+		lon, _ := toFloat(record["Longitude"])
+		distance := haversineMiles(subject.Lat, subject.Lon, lat, lon)
+
+		// Weighted similarity: location 30%, size 30%, beds 15%, baths 15%, recency 10%
+		score := 30 * (1 - math.Min(distance/opts.RadiusMiles, 1))
+		score += 30 * closeness(subject.LivingArea, record["LivingArea"], subject.LivingArea*opts.AreaTolerancePct/100)
+		score += 15 * closeness(subject.Beds, record["BedroomsTotal"], opts.BedsTolerance+1)
+		score += 15 * closeness(subject.Baths, record["BathroomsTotalInteger"], opts.BathsTolerance+1)
+		if closeDate, ok := record["CloseDate"].(string); ok {
+			if closed, err := time.Parse("2006-01-02", closeDate); err == nil {
+				age := time.Since(closed).Hours() / 24
+				score += 10 * (1 - math.Min(age/float64(opts.DaysBack), 1))
+			}
+		}
+
+		comps = append(comps, Comparable{
+			ListingKey:    recordID(record, i),
+			Score:         math.Round(score*10) / 10,
+			DistanceMiles: math.Round(distance*100) / 100,
+			Record:        record,
+		})
+	}
+
+	sort.SliceStable(comps, func(i, j int) bool {
+		return comps[i].Score > comps[j].Score
+	})
+
+	return comps
+}
+
+// closeness returns 1 for an exact match, falling linearly to 0 at the tolerance
+func closeness(subjectValue float64, compValue interface{}, tolerance float64) float64 {
+	value, ok := toFloat(compValue)
+	if !ok || subjectValue <= 0 {
+		return 0.5 // Unknown on either side: neutral
+	}
+	if tolerance <= 0 {
+		if value == subjectValue {
+			return 1
+		}
+		return 0
+	}
+	return 1 - math.Min(math.Abs(value-subjectValue)/tolerance, 1)
+}
+
+// formatComparables renders the subject and a concise comps table
+func formatComparables(subject *comparableSubject, opts comparableOptions, comps []Comparable) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Comparable Sales\n")
+	out.WriteString("=====================\n\n")
+
+	out.WriteString("Subject: ")
+	if subject.Address != "" {
+		out.WriteString(subject.Address)
+	} else {
+		out.WriteString(fmt.Sprintf("(%.6f, %.6f)", subject.Lat, subject.Lon))
+	}
+	if subject.ListingKey != "" {
+		out.WriteString(fmt.Sprintf(" [%s]", subject.ListingKey))
+	}
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf("Beds: %g | Baths: %g | Living Area: %.0f sqft\n", subject.Beds, subject.Baths, subject.LivingArea))
+	out.WriteString(fmt.Sprintf("Search: Closed within %d days, %.2f mile radius, ±%g beds, ±%g baths, ±%g%% living area\n\n",
+		opts.DaysBack, opts.RadiusMiles, opts.BedsTolerance, opts.BathsTolerance, opts.AreaTolerancePct))
+
+	if len(comps) == 0 {
+		out.WriteString("No comparable sales found. Try widening radius_miles, days_back or the tolerances.\n")
+		return out.String()
+	}
+
+	out.WriteString("| # | Score | Address | Close Price | Close Date | Beds | Baths | Sqft | Miles |\n")
+	out.WriteString("|---|-------|---------|-------------|------------|------|-------|------|-------|\n")
+	for i, comp := range comps {
+		r := comp.Record
+		out.WriteString(fmt.Sprintf("| %d | %.1f | %v | %v | %v | %v | %v | %v | %.2f |\n",
+			i+1, comp.Score, valueOr(r["UnparsedAddress"], comp.ListingKey), valueOr(r["ClosePrice"], "-"),
+			valueOr(r["CloseDate"], "-"), valueOr(r["BedroomsTotal"], "-"), valueOr(r["BathroomsTotalInteger"], "-"),
+			valueOr(r["LivingArea"], "-"), comp.DistanceMiles))
+	}
+
+	return out.String()
+}
+
+// valueOr returns value, or fallback when value is nil
+func valueOr(value interface{}, fallback interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,496 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// maxDiffSnapshots caps the snapshots kept by snapshot_key; the oldest is dropped first
+const maxDiffSnapshots = 32
+
+// maxDiffListed caps the added, removed and changed keys listed in the text summary
+const maxDiffListed = 50
+
+// ResoDiffTool implements the reso_diff MCP tool, which re-runs a query and reports
+// the records added, removed and changed since an earlier snapshot
+type ResoDiffTool struct {
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+
+	mutex     sync.Mutex
+	snapshots map[string]*diffSnapshot
+}
+
+// diffSnapshot is a query result stored under a snapshot_key
+type diffSnapshot struct {
+	Entity  string
+	Filter  string
+	TakenAt time.Time
+	Records []map[string]interface{}
+}
+
+// FieldChange is one field whose value differs between the snapshot and now
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ChangedRecord lists the field changes of one record present in both results
+type ChangedRecord struct {
+	Key     string        `json:"key"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffResult is the outcome of comparing a query result with a snapshot. Removed is
+// null when the current result is incomplete, since records past the cap cannot be
+// told apart from removed ones.
+type DiffResult struct {
+	Entity            string                   `json:"entity"`
+	KeyField          string                   `json:"key_field"`
+	SnapshotKey       string                   `json:"snapshot_key,omitempty"`
+	SnapshotTakenAt   string                   `json:"snapshot_taken_at,omitempty"`
+	Baseline          bool                     `json:"baseline,omitempty"`
+	PreviousRecords   int                      `json:"previous_records"`
+	CurrentRecords    int                      `json:"current_records"`
+	Added             []map[string]interface{} `json:"added"`
+	Removed           []string                 `json:"removed"`
+	Changed           []ChangedRecord          `json:"changed"`
+	Unchanged         int                      `json:"unchanged"`
+	IgnoredFields     []string                 `json:"ignored_fields,omitempty"`
+	MissingKey        int                      `json:"records_missing_key,omitempty"`
+	CurrentIncomplete bool                     `json:"current_incomplete,omitempty"`
+	Notes             []string                 `json:"notes,omitempty"`
+	Snapshot          []map[string]interface{} `json:"snapshot,omitempty"`
+}
+
+// NewResoDiffTool creates a new RESO diff tool
+func NewResoDiffTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoDiffTool {
+	return &ResoDiffTool{
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
+		snapshots:      make(map[string]*diffSnapshot),
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoDiffTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_diff",
+		Description: "Report what changed since an earlier run of the same query: records added, records removed (no longer matching) and records whose fields changed, with before and after values. Useful for price-drop and status-change alerts. Compare against a 'snapshot' array passed in (the 'snapshot' returned by a previous call) or against the result stored under 'snapshot_key' by the previous call with that key. The first call with a new key only captures a baseline. Records are matched on the entity's key field, and volatile *Timestamp fields are ignored unless include_timestamps is true.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity to query (default: 'Property')",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "OData filter selecting the records to monitor (e.g. \"StandardStatus eq 'Active' and City eq 'Austin'\")",
+				},
+				"select": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated fields to compare (e.g. 'ListPrice,StandardStatus'). The key field is always included. Leave empty to compare every field.",
+				},
+				"key_field": map[string]interface{}{
+					"type":        "string",
+					"description": "Field that identifies a record across runs (default: the entity's key from the metadata, or ListingKey)",
+				},
+				"snapshot_key": map[string]interface{}{
+					"type":        "string",
+					"description": "Name under which the server keeps this query's result (e.g. 'austin_active'). Each call compares with the previous result stored under the key, then replaces it. Snapshots last until the server restarts.",
+				},
+				"snapshot": map[string]interface{}{
+					"type":        "array",
+					"description": "Records from an earlier call to compare against, typically the 'snapshot' array that call returned",
+					"items": map[string]interface{}{
+						"type": "object",
+					},
+				},
+				"include_timestamps": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also report changes to *Timestamp fields such as ModificationTimestamp (default: false)",
+				},
+				"max_records": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum records fetched for the comparison. Default: %d.", api.DefaultMaxRecords),
+					"minimum":     1,
+				},
+			},
+			"required": []string{"filter"},
+		},
+	}
+}
+
+// Execute executes the RESO diff tool
+func (t *ResoDiffTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	entity, _ := args["entity"].(string)
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		entity = "Property"
+	}
+	filter, _ := args["filter"].(string)
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return errorResult("Error parsing arguments: filter is required")
+	}
+
+	keyField, err := entityKeyField(t.metadataParser, entity, args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+	if err := checkEntityPolicy(t.client, t.metadataParser, entity, ""); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+	if err := checkFilterOperators(filter, "", t.config.AllowedOperators); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+
+	snapshotKey, _ := args["snapshot_key"].(string)
+	snapshotKey = strings.TrimSpace(snapshotKey)
+	includeTimestamps, _ := args["include_timestamps"].(bool)
+
+	maxRecords := api.DefaultMaxRecords
+	switch v := args["max_records"].(type) {
+	case float64:
+		maxRecords = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			maxRecords = parsed
+		}
+	}
+	if maxRecords <= 0 {
+		maxRecords = api.DefaultMaxRecords
+	}
+
+	// Resolve what to compare against before querying, so a bad snapshot fails fast
+	result := &DiffResult{Entity: entity, KeyField: keyField, SnapshotKey: snapshotKey}
+	var previous []map[string]interface{}
+	if raw, ok := args["snapshot"]; ok && raw != nil {
+		previous, err = parseDiffSnapshot(raw)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+		}
+	} else if snapshotKey != "" {
+		stored := t.snapshot(snapshotKey)
+		switch {
+		case stored == nil:
+			result.Baseline = true
+		case stored.Entity != entity:
+			return errorResult(fmt.Sprintf("Snapshot %q holds %s records, not %s; use another snapshot_key", snapshotKey, stored.Entity, entity))
+		default:
+			previous = stored.Records
+			result.SnapshotTakenAt = stored.TakenAt.In(t.config.Location()).Format("2006-01-02 15:04:05 MST")
+			if stored.Filter != filter {
+				result.Notes = append(result.Notes, fmt.Sprintf("the snapshot was taken with a different filter (%s), so some additions and removals may come from the filter change", stored.Filter))
+			}
+		}
+	} else {
+		result.Baseline = true
+	}
+
+	// Ordering by the key keeps the records kept under the cap the same between runs
+	params := api.QueryParams{
+		Entity:     entity,
+		Filter:     filter,
+		OrderBy:    keyField + " asc",
+		FetchAll:   true,
+		MaxRecords: maxRecords,
+		NoCache:    true,
+	}
+	if sel, ok := args["select"].(string); ok {
+		params.Select = ensureSelected(strings.TrimSpace(sel), keyField)
+	}
+
+	response, err := t.client.Query(params)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error executing diff query: %s", err.Error()))
+	}
+
+	result.CurrentRecords = len(response.Value)
+	result.PreviousRecords = len(previous)
+	if response.MoreAvailable() {
+		result.CurrentIncomplete = true
+		result.Notes = append(result.Notes, fmt.Sprintf("more than %d records match, so removals cannot be told apart from records past the cap and are not reported; narrow the filter or raise max_records", maxRecords))
+	}
+	if !result.Baseline {
+		diffRecords(result, previous, response.Value, includeTimestamps)
+		if result.CurrentIncomplete {
+			result.Removed = nil
+		}
+	}
+
+	if snapshotKey != "" {
+		t.storeSnapshot(snapshotKey, &diffSnapshot{Entity: entity, Filter: filter, TakenAt: time.Now(), Records: response.Value})
+	} else {
+		result.Snapshot = response.Value
+	}
+
+	diffJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatDiff(result),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Diff Results:\n```json\n%s\n```", string(diffJSON)),
+			},
+		},
+	}
+}
+
+// snapshot returns the snapshot stored under key, or nil
+func (t *ResoDiffTool) snapshot(key string) *diffSnapshot {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.snapshots[key]
+}
+
+// storeSnapshot keeps a snapshot under key, dropping the oldest one when the store is full
+func (t *ResoDiffTool) storeSnapshot(key string, snapshot *diffSnapshot) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, exists := t.snapshots[key]; !exists && len(t.snapshots) >= maxDiffSnapshots {
+		oldest := ""
+		for name, stored := range t.snapshots {
+			if oldest == "" || stored.TakenAt.Before(t.snapshots[oldest].TakenAt) {
+				oldest = name
+			}
+		}
+		delete(t.snapshots, oldest)
+	}
+	t.snapshots[key] = snapshot
+}
+
+// parseDiffSnapshot reads the snapshot argument as a list of records
+func parseDiffSnapshot(raw interface{}) ([]map[string]interface{}, error) {
+	values, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("snapshot must be an array of records")
+	}
+	records := make([]map[string]interface{}, 0, len(values))
+	for i, value := range values {
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("snapshot[%d] must be an object", i)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// diffRecords fills in the added, removed and changed records of result. Records
+// without the key field cannot be matched and are only counted.
+func diffRecords(result *DiffResult, previous, current []map[string]interface{}, includeTimestamps bool) {
+	before := make(map[string]map[string]interface{}, len(previous))
+	for _, record := range previous {
+		if key, ok := diffKey(record, result.KeyField); ok {
+			before[key] = record
+		} else {
+			result.MissingKey++
+		}
+	}
+
+	ignored := make(map[string]bool)
+	seen := make(map[string]bool, len(current))
+	result.Added = []map[string]interface{}{}
+	result.Changed = []ChangedRecord{}
+	for _, record := range current {
+		key, ok := diffKey(record, result.KeyField)
+		if !ok {
+			result.MissingKey++
+			continue
+		}
+		seen[key] = true
+		old, existed := before[key]
+		if !existed {
+			result.Added = append(result.Added, record)
+			continue
+		}
+		changes := diffFields(old, record, includeTimestamps, ignored)
+		if len(changes) == 0 {
+			result.Unchanged++
+			continue
+		}
+		result.Changed = append(result.Changed, ChangedRecord{Key: key, Changes: changes})
+	}
+
+	result.Removed = []string{}
+	for _, record := range previous {
+		if key, ok := diffKey(record, result.KeyField); ok && !seen[key] {
+			result.Removed = append(result.Removed, key)
+			seen[key] = true
+		}
+	}
+
+	for field := range ignored {
+		result.IgnoredFields = append(result.IgnoredFields, field)
+	}
+	sort.Strings(result.IgnoredFields)
+}
+
+// diffKey returns a record's key value as a string
+func diffKey(record map[string]interface{}, keyField string) (string, bool) {
+	value, ok := record[keyField]
+	if !ok || value == nil {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// diffFields compares two versions of a record field by field, in field order. A field
+// missing from one side counts as null. OData annotations are skipped, and so are
+// *Timestamp fields unless includeTimestamps is set; skipped fields that changed are
+// recorded in ignored.
+func diffFields(before, after map[string]interface{}, includeTimestamps bool, ignored map[string]bool) []FieldChange {
+	fields := make(map[string]bool, len(after))
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if !strings.Contains(field, "@") {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, field := range names {
+		if reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		if !includeTimestamps && strings.HasSuffix(field, "Timestamp") {
+			ignored[field] = true
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Before: before[field], After: after[field]})
+	}
+	return changes
+}
+
+// formatDiff renders the diff as a readable summary, listing at most maxDiffListed
+// records per section
+func formatDiff(result *DiffResult) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Diff Results\n")
+	out.WriteString("=================\n\n")
+
+	out.WriteString(fmt.Sprintf("Entity: %s\n", result.Entity))
+	out.WriteString(fmt.Sprintf("Key Field: %s\n", result.KeyField))
+	if result.Baseline {
+		out.WriteString(fmt.Sprintf("Baseline Captured: %d record(s)\n\n", result.CurrentRecords))
+		if result.SnapshotKey != "" {
+			out.WriteString(fmt.Sprintf("Call again with snapshot_key %q to see what changed.\n", result.SnapshotKey))
+		} else {
+			out.WriteString("Pass the returned 'snapshot' array back as 'snapshot' to see what changed.\n")
+		}
+		for _, note := range result.Notes {
+			out.WriteString(fmt.Sprintf("Note: %s\n", note))
+		}
+		return out.String()
+	}
+
+	if result.SnapshotTakenAt != "" {
+		out.WriteString(fmt.Sprintf("Compared With: snapshot %q taken %s\n", result.SnapshotKey, result.SnapshotTakenAt))
+	}
+	out.WriteString(fmt.Sprintf("Records: %d before, %d now\n", result.PreviousRecords, result.CurrentRecords))
+	removed := strconv.Itoa(len(result.Removed))
+	if result.CurrentIncomplete {
+		removed = "unknown"
+	}
+	out.WriteString(fmt.Sprintf("Added: %d, Removed: %s, Changed: %d, Unchanged: %d\n", len(result.Added), removed, len(result.Changed), result.Unchanged))
+	if len(result.IgnoredFields) > 0 {
+		out.WriteString(fmt.Sprintf("Ignored Timestamp Changes: %s\n", strings.Join(result.IgnoredFields, ", ")))
+	}
+	if result.MissingKey > 0 {
+		out.WriteString(fmt.Sprintf("Records Without %s: %d (not compared)\n", result.KeyField, result.MissingKey))
+	}
+	for _, note := range result.Notes {
+		out.WriteString(fmt.Sprintf("Note: %s\n", note))
+	}
+
+	if len(result.Changed) > 0 {
+		out.WriteString("\nChanged:\n")
+		for i, record := range result.Changed {
+			if i == maxDiffListed {
+				out.WriteString(fmt.Sprintf("- ... and %d more\n", len(result.Changed)-maxDiffListed))
+				break
+			}
+			parts := make([]string, len(record.Changes))
+			for j, change := range record.Changes {
+				parts[j] = fmt.Sprintf("%s %s → %s", change.Field, formatDiffValue(change.Before), formatDiffValue(change.After))
+			}
+			out.WriteString(fmt.Sprintf("- %s: %s\n", record.Key, strings.Join(parts, "; ")))
+		}
+	}
+	if len(result.Added) > 0 {
+		out.WriteString("\nAdded:\n")
+		for i, record := range result.Added {
+			if i == maxDiffListed {
+				out.WriteString(fmt.Sprintf("- ... and %d more\n", len(result.Added)-maxDiffListed))
+				break
+			}
+			key, _ := diffKey(record, result.KeyField)
+			out.WriteString(fmt.Sprintf("- %s\n", key))
+		}
+	}
+	if len(result.Removed) > 0 {
+		out.WriteString("\nRemoved (no longer matching):\n")
+		for i, key := range result.Removed {
+			if i == maxDiffListed {
+				out.WriteString(fmt.Sprintf("- ... and %d more\n", len(result.Removed)-maxDiffListed))
+				break
+			}
+			out.WriteString(fmt.Sprintf("- %s\n", key))
+		}
+	}
+
+	return out.String()
+}
+
+// formatDiffValue renders a field value for the summary, showing nulls explicitly
+func formatDiffValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
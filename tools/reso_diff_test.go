@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/auth"
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+// newTestAPIClient starts a stub API that issues a token at /token and hands every
+// other request to handler, and returns a client and configuration pointed at it
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc) (*api.Client, *config.Config) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"test-token","expires_in":3600,"token_type":"Bearer"}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.ClientID = "id"
+	cfg.ClientSecret = "secret"
+	cfg.AuthURL = server.URL + "/token"
+	cfg.BaseURL = server.URL + "/odata"
+	oauthClient := auth.NewOAuthClient(cfg.ClientID, cfg.ClientSecret, cfg.AuthURL)
+	return api.NewClient(cfg.BaseURL, oauthClient), cfg
+}
+
+// toolResultJSON decodes the JSON block of a tool result's last content item into v
+func toolResultJSON(t *testing.T, result MCPToolResult, v interface{}) {
+	t.Helper()
+	if result.IsError {
+		t.Fatalf("tool error: %s", result.Content[0].Text)
+	}
+	text := result.Content[len(result.Content)-1].Text
+	start := strings.Index(text, "```json\n")
+	end := strings.LastIndex(text, "\n```")
+	if start < 0 || end < start {
+		t.Fatalf("no JSON block in %q", text)
+	}
+	if err := json.Unmarshal([]byte(text[start+len("```json\n"):end]), v); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+}
+
+func TestDiffIncompleteResultSkipsRemoved(t *testing.T) {
+	client, cfg := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("$orderby"); got != "ListingKey asc" {
+			t.Errorf("$orderby = %q, want the key field", got)
+		}
+		fmt.Fprint(w, `{"value":[{"ListingKey":"K1","ListPrice":100},{"ListingKey":"K2","ListPrice":200},{"ListingKey":"K3","ListPrice":300}]}`)
+	})
+	tool := NewResoDiffTool(client, cfg, nil)
+	snapshot := []interface{}{
+		map[string]interface{}{"ListingKey": "K1", "ListPrice": float64(150)},
+		map[string]interface{}{"ListingKey": "K9", "ListPrice": float64(900)},
+	}
+
+	tests := []struct {
+		name        string
+		maxRecords  float64
+		wantAdded   []string
+		wantRemoved []string
+		wantSummary string
+	}{
+		{"complete", 10, []string{"K2", "K3"}, []string{"K9"}, "Removed: 1"},
+		{"capped", 2, []string{"K2"}, nil, "Removed: unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tool.Execute(map[string]interface{}{
+				"filter":      "City eq 'Austin'",
+				"snapshot":    snapshot,
+				"max_records": tt.maxRecords,
+			})
+			var diff DiffResult
+			toolResultJSON(t, result, &diff)
+
+			var added []string
+			for _, record := range diff.Added {
+				added = append(added, record["ListingKey"].(string))
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %q, want %q", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(diff.Removed, tt.wantRemoved) {
+				t.Errorf("removed = %q, want %q", diff.Removed, tt.wantRemoved)
+			}
+			if len(diff.Changed) != 1 || diff.Changed[0].Key != "K1" {
+				t.Errorf("changed = %+v, want K1's price change", diff.Changed)
+			}
+			if !strings.Contains(result.Content[0].Text, tt.wantSummary) {
+				t.Errorf("summary does not contain %q:\n%s", tt.wantSummary, result.Content[0].Text)
+			}
+		})
+	}
+}
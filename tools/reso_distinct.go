@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// distinctCountAlias names the aggregated count column in $apply results
+const distinctCountAlias = "DistinctCount"
+
+// defaultDistinctTop caps the distinct values returned when top is not given
+const defaultDistinctTop = 100
+
+// ResoDistinctTool implements the reso_distinct MCP tool, which lists the
+// distinct values of a field using $apply=groupby
+type ResoDistinctTool struct {
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+}
+
+// DistinctValue is one distinct field value and how many records carry it
+type DistinctValue struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count,omitempty"`
+}
+
+// DistinctResult represents the distinct values of a field
+type DistinctResult struct {
+	Entity    string          `json:"entity"`
+	Field     string          `json:"field"`
+	Filter    string          `json:"filter,omitempty"`
+	Total     int             `json:"total_distinct"`
+	Truncated bool            `json:"truncated"`
+	Values    []DistinctValue `json:"values"`
+}
+
+// NewResoDistinctTool creates a new RESO distinct tool
+func NewResoDistinctTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoDistinctTool {
+	return &ResoDistinctTool{
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoDistinctTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_distinct",
+		Description: "List the distinct values of a field (e.g. every City, SubdivisionName or MLSAreaMajor present in the data), optionally with how many records carry each value. Use this to discover valid filter values before building a reso_query. Uses OData $apply=groupby on the server.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity to inspect (e.g. 'Property', 'Member', 'Office').",
+				},
+				"field": map[string]interface{}{
+					"type":        "string",
+					"description": "Field whose distinct values to list (e.g. 'City').",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "OData filter to scope the records before grouping (e.g. \"StandardStatus eq 'Active'\").",
+				},
+				"counts": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include the number of records for each value and sort by count (highest first). When false, values are sorted alphabetically. Default: true.",
+					"default":     true,
+				},
+				"top": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum distinct values to return. Default: %d.", defaultDistinctTop),
+					"minimum":     1,
+				},
+			},
+			"required": []string{"entity", "field"},
+		},
+	}
+}
+
+// Execute executes the RESO distinct tool
+func (t *ResoDistinctTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	entity, _ := args["entity"].(string)
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		return errorResult("Error parsing arguments: entity is required")
+	}
+
+	field, _ := args["field"].(string)
+	field = strings.TrimSpace(field)
+	if !fieldNamePattern.MatchString(field) {
+		return errorResult(fmt.Sprintf("Error parsing arguments: invalid field name %q", field))
+	}
+	if err := t.validateField(entity, field); err != nil {
+		return errorResult(fmt.Sprintf("Validation error: %s", err.Error()))
+	}
+
+	counts := true
+	if c, ok := args["counts"].(bool); ok {
+		counts = c
+	}
+
+	top := defaultDistinctTop
+	switch v := args["top"].(type) {
+	case float64:
+		top = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			top = parsed
+		}
+	}
+	if top <= 0 {
+		top = defaultDistinctTop
+	}
+
+	filter, _ := args["filter"].(string)
+	filter = strings.TrimSpace(filter)
+	if err := checkFilterOperators(filter, "", t.config.AllowedOperators); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+
+	// Fetch every group so the sort and truncation are over the full set
+	response, err := t.client.Query(api.QueryParams{
+		Entity:   entity,
+		Apply:    buildDistinctApply(field, filter, counts),
+		FetchAll: true,
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error executing distinct query: %s", err.Error()))
+	}
+
+	values := collectDistinctValues(response.Value, field, counts)
+	result := &DistinctResult{
+		Entity: entity,
+		Field:  field,
+		Filter: filter,
+		Total:  len(values),
+		Values: values,
+	}
+	if len(values) > top {
+		result.Values = values[:top]
+		result.Truncated = true
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatDistinct(result, counts),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Distinct Values:\n```json\n%s\n```", string(resultJSON)),
+			},
+		},
+	}
+}
+
+// validateField confirms the field exists on the entity when metadata is loaded
+func (t *ResoDistinctTool) validateField(entity, field string) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	if _, exists := t.metadataParser.GetEntityInfo(entity); !exists {
+		return nil
+	}
+	if t.metadataParser.HasField(entity, field) {
+		return nil
+	}
+
+	hint := ""
+	if suggestions := t.metadataParser.SuggestFields(entity, field, 3); len(suggestions) > 0 {
+		hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+	}
+	return fmt.Errorf("%s is not a field of %s%s", field, entity, hint)
+}
+
+// buildDistinctApply builds the $apply expression that groups by field
+func buildDistinctApply(field, filter string, counts bool) string {
+	groupBy := fmt.Sprintf("groupby((%s))", field)
+	if counts {
+		groupBy = fmt.Sprintf("groupby((%s),aggregate($count as %s))", field, distinctCountAlias)
+	}
+	if filter != "" {
+		return fmt.Sprintf("filter(%s)/%s", filter, groupBy)
+	}
+	return groupBy
+}
+
+// collectDistinctValues extracts and sorts the grouped values, by count when counts
+// are requested and alphabetically otherwise
+func collectDistinctValues(rows []map[string]interface{}, field string, counts bool) []DistinctValue {
+	values := make([]DistinctValue, 0, len(rows))
+	for _, row := range rows {
+		value := DistinctValue{Value: row[field]}
+		if counts {
+			if n, ok := toFloat(row[distinctCountAlias]); ok {
+				value.Count = int(n)
+			}
+		}
+		values = append(values, value)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		if counts && values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return fmt.Sprint(values[i].Value) < fmt.Sprint(values[j].Value)
+	})
+	return values
+}
+
+// formatDistinct renders the distinct values as a Markdown list
+func formatDistinct(result *DistinctResult, counts bool) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Distinct Values\n")
+	out.WriteString("====================\n\n")
+
+	out.WriteString(fmt.Sprintf("Entity: %s\n", result.Entity))
+	out.WriteString(fmt.Sprintf("Field: %s\n", result.Field))
+	if result.Filter != "" {
+		out.WriteString(fmt.Sprintf("Filter: %s\n", result.Filter))
+	}
+	out.WriteString(fmt.Sprintf("Distinct Values: %d\n", result.Total))
+	if result.Truncated {
+		out.WriteString(fmt.Sprintf("Showing the first %d (raise top to see more)\n", len(result.Values)))
+	}
+	out.WriteString("\n")
+
+	for _, v := range result.Values {
+		label := fmt.Sprint(valueOr(v.Value, "(null)"))
+		if counts {
+			out.WriteString(fmt.Sprintf("- %s (%d)\n", label, v.Count))
+		} else {
+			out.WriteString(fmt.Sprintf("- %s\n", label))
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// defaultEnumLookupLimit is the number of members returned when no limit is given
+const defaultEnumLookupLimit = 5
+
+// ResoEnumLookupTool implements the reso_enum_lookup MCP tool, which resolves display
+// strings to RESO enum members and back
+type ResoEnumLookupTool struct {
+	metadataParser *metadata.MetadataParser
+}
+
+// EnumLookupResult is the outcome of an enum lookup
+type EnumLookupResult struct {
+	Enum    string             `json:"enum"`
+	Field   string             `json:"field,omitempty"`
+	Value   string             `json:"value"`
+	Match   string             `json:"match,omitempty"`
+	Members []EnumLookupMember `json:"members"`
+}
+
+// EnumLookupMember is one matched enum member
+type EnumLookupMember struct {
+	Name         string `json:"name"`
+	StandardName string `json:"standard_name,omitempty"`
+	Value        string `json:"value,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Filter       string `json:"filter"`
+}
+
+// NewResoEnumLookupTool creates a new RESO enum lookup tool
+func NewResoEnumLookupTool(parser *metadata.MetadataParser) *ResoEnumLookupTool {
+	return &ResoEnumLookupTool{
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoEnumLookupTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_enum_lookup",
+		Description: "Resolve a display string to the RESO enum member to use in a filter, or a member name to its display string. For example, 'Single Family Residence' or 'single family' in PropertySubType resolves to SingleFamilyResidence. Matching ignores case, spacing and punctuation, accepts the leading words of a name and tolerates small typos. Returns each member's name, StandardName and a ready-to-use filter clause.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"enum": map[string]interface{}{
+					"type":        "string",
+					"description": "Enum type name (e.g. 'PropertySubType', 'StandardStatus') or the name of an enum field of the entity (e.g. 'InteriorFeatures'). Use reso_schema with include_enums for the full list.",
+				},
+				"value": map[string]interface{}{
+					"type":        "string",
+					"description": "Display string or member name to resolve, e.g. 'Single Family Residence', 'single family' or 'SingleFamilyResidence'.",
+				},
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity whose fields are searched when 'enum' is a field name. Default: Property.",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of matching members to return. Default: %d.", defaultEnumLookupLimit),
+					"minimum":     1,
+				},
+			},
+			"required": []string{"enum", "value"},
+		},
+	}
+}
+
+// Execute executes the RESO enum lookup tool
+func (t *ResoEnumLookupTool) Execute(args map[string]interface{}) MCPToolResult {
+	if t.metadataParser == nil {
+		return errorResult("Error: metadata is not loaded. Use reso_help topic 'metadata' for details on how metadata is located.")
+	}
+
+	enumName, _ := args["enum"].(string)
+	enumName = strings.TrimSpace(enumName)
+	value, _ := args["value"].(string)
+	value = strings.TrimSpace(value)
+	if enumName == "" || value == "" {
+		return errorResult("Error parsing arguments: enum and value are required")
+	}
+
+	entity, _ := args["entity"].(string)
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		entity = "Property"
+	}
+
+	limit := defaultEnumLookupLimit
+	switch v := args["limit"].(type) {
+	case float64:
+		limit = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			limit = parsed
+		}
+	}
+	if limit <= 0 {
+		return errorResult("Error parsing arguments: limit must be a positive integer")
+	}
+
+	enum, field, ok := t.metadataParser.ResolveEnum(entity, enumName)
+	if !ok {
+		message := fmt.Sprintf("Error: %s is neither an enum type nor an enum field of %s", enumName, entity)
+		if suggestions := t.metadataParser.SuggestEnumNames(enumName, 3); len(suggestions) > 0 {
+			message += fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+		}
+		return errorResult(message)
+	}
+
+	result := buildEnumLookup(enum, field, value, t.metadataParser.LookupEnumMembers(enum, value, limit))
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatEnumLookup(result),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Matches:\n```json\n%s\n```", string(resultJSON)),
+			},
+		},
+	}
+}
+
+// buildEnumLookup converts matches into the lookup result, with a filter clause for
+// each member using the field when known (has for collections) or the enum name
+func buildEnumLookup(enum *metadata.EnumInfo, field *metadata.PropertyInfo, value string, matches []metadata.EnumMatch) *EnumLookupResult {
+	result := &EnumLookupResult{
+		Enum:    enum.Name,
+		Value:   value,
+		Members: []EnumLookupMember{},
+	}
+
+	fieldName, operator := enum.Name, "eq"
+	if field != nil {
+		result.Field = field.Name
+		fieldName = field.Name
+		if field.IsCollection {
+			operator = "has"
+		}
+	}
+
+	for _, match := range matches {
+		result.Match = match.Kind
+		result.Members = append(result.Members, EnumLookupMember{
+			Name:         match.Member.Name,
+			StandardName: match.Member.StandardName,
+			Value:        match.Member.Value,
+			Description:  match.Member.Description,
+			Filter:       fmt.Sprintf("%s %s %s", fieldName, operator, QuoteODataString(match.Member.Name)),
+		})
+	}
+
+	return result
+}
+
+// formatEnumLookup renders the human-readable summary of a lookup
+func formatEnumLookup(result *EnumLookupResult) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Enum Lookup\n")
+	out.WriteString("================\n\n")
+
+	out.WriteString(fmt.Sprintf("Enum: %s\n", result.Enum))
+	if result.Field != "" {
+		out.WriteString(fmt.Sprintf("Field: %s\n", result.Field))
+	}
+	out.WriteString(fmt.Sprintf("Value: %s\n", result.Value))
+
+	if len(result.Members) == 0 {
+		out.WriteString(fmt.Sprintf("\nNo %s member matches this value. Use reso_schema with include_enums to list every member.\n", result.Enum))
+		return out.String()
+	}
+	out.WriteString(fmt.Sprintf("Match: %s\n\n", result.Match))
+
+	for i, member := range result.Members {
+		out.WriteString(fmt.Sprintf("%d. %s", i+1, member.Name))
+		if member.StandardName != "" && member.StandardName != member.Name {
+			out.WriteString(fmt.Sprintf(" (%s)", member.StandardName))
+		}
+		out.WriteString(fmt.Sprintf("\n   Filter: %s\n", member.Filter))
+	}
+	return out.String()
+}
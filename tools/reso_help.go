@@ -5,13 +5,17 @@ import (
 	"os"
 	"strings"
 
+	"github.com/rennietech/constellation1-mcp-server/config"
 	"github.com/rennietech/constellation1-mcp-server/metadata"
 )
 
 // ResoHelpTool implements the reso_help MCP tool for accessing RESO field reference and documentation
 type ResoHelpTool struct {
 	metadataParser *metadata.MetadataParser
+	metadataSource string
+	sources        []string
 	apiClient      APIClientInterface
+	fieldAliases   map[string]string
 }
 
 // APIClientInterface defines the interface for API metadata access
@@ -19,61 +23,97 @@ type APIClientInterface interface {
 	GetMetadata() (string, error)
 }
 
+// metadataCacheFile is where metadata fetched from the API is cached between runs
+const metadataCacheFile = "/tmp/constellation1_metadata.xml"
+
+// metadataLocations are the local files tried by the "file" metadata source
+var metadataLocations = []string{
+	"constellation1_metadata.xml",
+	"../constellation1_metadata.xml",
+	"../../constellation1_metadata.xml",
+}
+
 // NewResoHelpTool creates a new RESO help tool
 func NewResoHelpTool() *ResoHelpTool {
 	return NewResoHelpToolWithAPI(nil)
 }
 
-// NewResoHelpToolWithAPI creates a help tool with optional API client for live metadata fetching
+// NewResoHelpToolWithAPI creates a help tool with optional API client for live metadata
+// fetching, trying the cache file, then the API, then local files
 func NewResoHelpToolWithAPI(apiClient APIClientInterface) *ResoHelpTool {
+	return NewResoHelpToolWithSources(apiClient, config.DefaultMetadataSources)
+}
+
+// NewResoHelpToolWithSources creates a help tool that loads metadata from the first of
+// sources ("cache", "api", "file") that succeeds
+func NewResoHelpToolWithSources(apiClient APIClientInterface, sources []string) *ResoHelpTool {
 	tool := &ResoHelpTool{
 		apiClient: apiClient,
+		sources:   sources,
 	}
 
-	parser := metadata.NewMetadataParser()
-	cacheFile := "/tmp/constellation1_metadata.xml"
-
-	// First priority: Check cache file (avoid re-downloading)
-	if _, err := os.Stat(cacheFile); err == nil {
-		if err := parser.ParseFromFile(cacheFile); err == nil {
+	for _, source := range sources {
+		parser := metadata.NewMetadataParser()
+		var loaded string
+		switch source {
+		case "cache":
+			loaded = loadMetadataCache(parser)
+		case "api":
+			loaded = loadMetadataAPI(parser, apiClient)
+		case "file":
+			loaded = loadMetadataFile(parser)
+		}
+		if loaded != "" {
 			tool.metadataParser = parser
+			tool.metadataSource = loaded
 			return tool
 		}
 	}
 
-	// Second priority: Fetch from API if client is available
-	if apiClient != nil {
-		if metadataXML, err := apiClient.GetMetadata(); err == nil {
-			// Parse the metadata
-			if err := parser.ParseFromReader(strings.NewReader(metadataXML)); err == nil {
-				tool.metadataParser = parser
-				// Cache the metadata for future use
-				if err := os.WriteFile(cacheFile, []byte(metadataXML), 0644); err == nil {
-					// Successfully cached metadata
-				}
-				return tool
-			}
-		}
+	// If no metadata available, metadataParser will be nil and we'll use fallback content
+	return tool
+}
+
+// loadMetadataCache parses the cache file, avoiding a re-download. It returns a
+// description of the source, or "" when the cache is missing or unreadable.
+func loadMetadataCache(parser *metadata.MetadataParser) string {
+	if _, err := os.Stat(metadataCacheFile); err != nil {
+		return ""
 	}
+	if err := parser.ParseFromFile(metadataCacheFile); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("cache file %s", metadataCacheFile)
+}
 
-	// Third priority: Try local files as fallback
-	metadataLocations := []string{
-		"constellation1_metadata.xml",
-		"../constellation1_metadata.xml",
-		"../../constellation1_metadata.xml",
+// loadMetadataAPI fetches metadata from the API and caches it for future runs
+func loadMetadataAPI(parser *metadata.MetadataParser, apiClient APIClientInterface) string {
+	if apiClient == nil {
+		return ""
+	}
+	metadataXML, err := apiClient.GetMetadata()
+	if err != nil {
+		return ""
 	}
+	if err := parser.ParseFromReader(strings.NewReader(metadataXML)); err != nil {
+		return ""
+	}
+	// Caching is best effort; the parsed metadata is used either way
+	os.WriteFile(metadataCacheFile, []byte(metadataXML), 0644)
+	return "API"
+}
 
+// loadMetadataFile parses the first readable local metadata file
+func loadMetadataFile(parser *metadata.MetadataParser) string {
 	for _, location := range metadataLocations {
-		if _, err := os.Stat(location); err == nil {
-			if err := parser.ParseFromFile(location); err == nil {
-				tool.metadataParser = parser
-				return tool
-			}
+		if _, err := os.Stat(location); err != nil {
+			continue
+		}
+		if err := parser.ParseFromFile(location); err == nil {
+			return fmt.Sprintf("local file %s", location)
 		}
 	}
-
-	// If no metadata available, metadataParser will be nil and we'll use fallback content
-	return tool
+	return ""
 }
 
 // NewResoHelpToolWithMetadata creates a help tool with specific metadata file
@@ -93,6 +133,21 @@ func (t *ResoHelpTool) HasMetadata() bool {
 	return t.metadataParser != nil
 }
 
+// MetadataSource describes where the metadata was loaded from, or "" if it was not loaded
+func (t *ResoHelpTool) MetadataSource() string {
+	return t.metadataSource
+}
+
+// SetFieldAliases sets the configured field aliases listed by the aliases topic
+func (t *ResoHelpTool) SetFieldAliases(aliases map[string]string) {
+	t.fieldAliases = aliases
+}
+
+// GetMetadataParser returns the loaded metadata parser, or nil if metadata is unavailable
+func (t *ResoHelpTool) GetMetadataParser() *metadata.MetadataParser {
+	return t.metadataParser
+}
+
 // GetEntityGuide returns the dynamic entity guide if metadata is available
 func (t *ResoHelpTool) GetEntityGuide() string {
 	if t.metadataParser != nil {
@@ -119,32 +174,78 @@ func (t *ResoHelpTool) GetToolDefinition() MCPTool {
 			"properties": map[string]interface{}{
 				"topic": map[string]interface{}{
 					"type":        "string",
-					"description": "Help topic to retrieve. Choose from:\n\n• **entities** - Complete guide to all RESO entities with use cases and key fields (dynamic from metadata when available)\n• **fields** - Field reference organized by category (dynamic from metadata when available)\n• **filters** - Filter pattern examples for all common search scenarios\n• **enums** - Valid enum values for StandardStatus, PropertyType, etc. (dynamic from metadata when available)\n• **expand** - Entity expansion examples for fetching related data\n• **examples** - Complete query examples for common real estate use cases\n• **performance** - Best practices for optimal API performance and response times\n• **images** - Image handling, sizing, and privacy controls for Media entities\n• **metadata** - Shows metadata parsing status and available dynamic content\n• **overview** - Complete overview of all available help topics",
-					"enum": []string{
-						"entities", "fields", "filters", "enums", "expand",
-						"examples", "performance", "images", "metadata", "overview",
-					},
+					"description": "Help topic to retrieve. Choose from:\n\n• **entities** - Complete guide to all RESO entities with use cases and key fields (dynamic from metadata when available)\n• **fields** - Field reference organized by category (dynamic from metadata when available)\n• **filters** - Filter pattern examples for all common search scenarios\n• **enums** - Valid enum values for StandardStatus, PropertyType, etc. (dynamic from metadata when available)\n• **expand** - Entity expansion examples for fetching related data (pass 'entity' for the exact relationships that entity can expand)\n• **aliases** - Friendly field names (beds, price, zip, ...) that reso_query translates to RESO field names\n• **examples** - Complete query examples for common real estate use cases\n• **performance** - Best practices for optimal API performance and response times\n• **images** - Image handling, sizing, and privacy controls for Media entities\n• **metadata** - Shows metadata parsing status and available dynamic content\n• **overview** - Complete overview of all available help topics",
+					"enum":        helpTopics,
+				},
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity to document with the 'fields' topic (e.g. 'Member', 'Office', 'Media'; default: Property), or whose expandable relationships the 'expand' topic lists.",
+				},
+				"search": map[string]interface{}{
+					"type":        "string",
+					"description": "Search every help topic for this text (case-insensitive) and return the matching sections with their topic names and surrounding context. Use instead of 'topic' when you don't know which topic covers something, e.g. 'virtual tour'.",
 				},
 			},
-			"required": []string{"topic"},
 		},
 	}
 }
 
 // Execute executes the RESO help tool
 func (t *ResoHelpTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Search across all topics
+	if search, ok := args["search"].(string); ok && strings.TrimSpace(search) != "" {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: t.searchHelp(search),
+			}},
+		}
+	}
+
 	// Parse arguments
 	topic, ok := args["topic"].(string)
 	if !ok {
 		return MCPToolResult{
 			Content: []MCPContent{{
 				Type: "text",
-				Text: "Error: topic parameter is required",
+				Text: "Error: topic or search parameter is required",
 			}},
 			IsError: true,
 		}
 	}
 
+	// The fields topic can document any entity
+	if strings.EqualFold(topic, "fields") {
+		if entity, ok := args["entity"].(string); ok && strings.TrimSpace(entity) != "" {
+			resolved, err := t.resolveFieldsEntity(strings.TrimSpace(entity))
+			if err != nil {
+				return errorResult(fmt.Sprintf("Error: %s", err.Error()))
+			}
+			return MCPToolResult{
+				Content: []MCPContent{{
+					Type: "text",
+					Text: t.getFieldsContent(resolved),
+				}},
+			}
+		}
+	}
+
+	// The expand topic can list the relationships of one entity
+	if strings.EqualFold(topic, "expand") {
+		if entity, ok := args["entity"].(string); ok && strings.TrimSpace(entity) != "" {
+			content, err := t.getEntityExpandContent(strings.TrimSpace(entity))
+			if err != nil {
+				return errorResult(fmt.Sprintf("Error: %s", err.Error()))
+			}
+			return MCPToolResult{
+				Content: []MCPContent{{
+					Type: "text",
+					Text: content,
+				}},
+			}
+		}
+	}
+
 	// Get help content based on topic
 	content := t.getHelpContent(topic)
 	if content == "" {
@@ -173,13 +274,15 @@ func (t *ResoHelpTool) getHelpContent(topic string) string {
 	case "entities":
 		return t.getEntitiesContent()
 	case "fields":
-		return t.getFieldsContent()
+		return t.getFieldsContent("Property")
 	case "filters":
 		return t.getFiltersContent()
 	case "enums":
 		return t.getEnumsContent()
 	case "expand":
 		return t.getExpandContent()
+	case "aliases":
+		return t.getAliasesContent()
 	case "examples":
 		return t.getExamplesContent()
 	case "performance":
@@ -216,6 +319,9 @@ Complete list of valid values for StandardStatus, PropertyType, PropertySubType,
 ### 🔗 **expand** - Entity Expansion
 Advanced examples for fetching related entities in single queries (Property+Media, Property+OpenHouse, filtered expansions).
 
+### 🔤 **aliases** - Field Aliases
+Friendly field names such as beds, price and zip that reso_query translates to RESO field names in select, filter and orderby.
+
 ### 💡 **examples** - Query Examples
 Ready-to-use query examples for common real estate scenarios: property searches, agent lookup, market analysis, media retrieval.
 
@@ -281,11 +387,29 @@ func (t *ResoHelpTool) getEntitiesContent() string {
 *Note: For complete entity information with all fields from metadata, ensure constellation1_metadata.xml is available during server startup.*`
 }
 
-// getFieldsContent returns field reference content
-func (t *ResoHelpTool) getFieldsContent() string {
+// resolveFieldsEntity matches an entity name case-insensitively against the metadata
+func (t *ResoHelpTool) resolveFieldsEntity(entity string) (string, error) {
+	if t.metadataParser == nil {
+		if strings.EqualFold(entity, "Property") {
+			return "Property", nil
+		}
+		return "", fmt.Errorf("metadata is not loaded, so only the static Property field guide is available. Use topic 'metadata' for details")
+	}
+
+	names := t.metadataParser.GetEntityNames()
+	for _, name := range names {
+		if strings.EqualFold(name, entity) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown entity '%s'. Valid entities: %s", entity, strings.Join(names, ", "))
+}
+
+// getFieldsContent returns field reference content for an entity
+func (t *ResoHelpTool) getFieldsContent(entity string) string {
 	// Use dynamic content if metadata parser is available
 	if t.metadataParser != nil {
-		return t.metadataParser.GenerateFieldsGuide("Property")
+		return t.metadataParser.GenerateFieldsGuide(entity)
 	}
 
 	// Fallback to static content
@@ -322,6 +446,20 @@ Appliances, Heating, Cooling, ParkingFeatures, ExteriorFeatures, InteriorFeature
 
 // getFiltersContent returns filter pattern examples
 func (t *ResoHelpTool) getFiltersContent() string {
+	// Prepend the metadata-derived list of filterable fields when available
+	if t.metadataParser != nil {
+		content := t.getStaticFiltersContent()
+		if idx := strings.Index(content, "## Status Filters"); idx >= 0 {
+			return content[:idx] + t.metadataParser.GenerateFilterFieldsGuide() + content[idx:]
+		}
+		return content
+	}
+
+	return t.getStaticFiltersContent()
+}
+
+// getStaticFiltersContent returns the static filter pattern examples
+func (t *ResoHelpTool) getStaticFiltersContent() string {
 	return `# RESO Filter Patterns
 
 ## Basic Syntax
@@ -449,6 +587,37 @@ WA, CA, NY, TX, FL, IL, PA, OH, GA, NC, MI, NJ, VA, WI, AZ, MA, TN, IN, MD, MO,
 
 // getExpandContent returns expand functionality examples
 func (t *ResoHelpTool) getExpandContent() string {
+	// Prepend the metadata-derived list of valid expansions when available
+	if t.metadataParser != nil {
+		content := t.getStaticExpandContent()
+		if idx := strings.Index(content, "## Basic Property Expansions"); idx >= 0 {
+			return content[:idx] + t.metadataParser.GenerateExpandGuide() + content[idx:]
+		}
+		return content
+	}
+
+	return t.getStaticExpandContent()
+}
+
+// getStaticExpandContent returns the static expand examples
+// getEntityExpandContent returns the expansions one entity supports according to the
+// metadata, or the static examples with a note when the metadata has none
+func (t *ResoHelpTool) getEntityExpandContent(entity string) (string, error) {
+	if t.metadataParser == nil {
+		return "*Note: Metadata is not loaded, so the expansions " + entity + " supports are unknown. The examples below are generic Property expansions and may not match your provider.*\n\n" + t.getStaticExpandContent(), nil
+	}
+
+	resolved, err := t.resolveFieldsEntity(entity)
+	if err != nil {
+		return "", err
+	}
+	if guide := t.metadataParser.GenerateEntityExpandGuide(resolved); guide != "" {
+		return guide, nil
+	}
+	return "*Note: The metadata defines no navigation properties for " + resolved + ", so the expansions it supports are unknown. The examples below are generic Property expansions and may not match your provider.*\n\n" + t.getStaticExpandContent(), nil
+}
+
+func (t *ResoHelpTool) getStaticExpandContent() string {
 	return `# Entity Expansion Guide
 
 ## What is Expand?
@@ -530,6 +699,27 @@ expand: "Media($filter=Permission ne 'Private'),OpenHouse($select=OpenHouseStart
 - **Avoid expanding large datasets** without filters`
 }
 
+// getAliasesContent lists the field aliases reso_query translates
+func (t *ResoHelpTool) getAliasesContent() string {
+	return `# Field Aliases
+
+reso_query translates these friendly names to RESO field names in ` + "`select`" + `, ` + "`filter`" + `, ` + "`orderby`" + ` and the fields of ` + "`filters`" + ` before building the query. Aliases are case-insensitive. Unknown names pass through unchanged, as do aliases whose field the queried entity does not have. The summary lists the translations made.
+
+` + formatFieldAliases(mergeFieldAliases(t.fieldAliases), t.fieldAliases) + `
+## Example
+` + "```json" + `
+{
+  "entity": "Property",
+  "filter": "beds ge 3 and price le 500000 and zip eq '98101'",
+  "select": "ListingKey,price,beds,baths,sqft",
+  "orderby": "price asc"
+}
+` + "```" + `
+is sent as ` + "`BedroomsTotal ge 3 and ListPrice le 500000 and PostalCode eq '98101'`" + `, selecting ` + "`ListingKey,ListPrice,BedroomsTotal,BathroomsTotalInteger,LivingArea`" + ` and ordered by ` + "`ListPrice asc`" + `.
+
+Add or override aliases with ` + "`RESO_FIELD_ALIASES`" + ` (or ` + "`field_aliases`" + ` in MCP settings), e.g. ` + "`hoa=AssociationFee,beds=BedroomsTotal`" + `. An empty field (` + "`type=`" + `) removes a built-in alias.`
+}
+
 // getExamplesContent returns comprehensive query examples
 func (t *ResoHelpTool) getExamplesContent() string {
 	return `# RESO Query Examples
@@ -943,6 +1133,7 @@ func (t *ResoHelpTool) getMetadataContent() string {
 
 	if t.metadataParser != nil {
 		content.WriteString("✅ **Metadata Parser**: ACTIVE - Dynamic content available\n\n")
+		content.WriteString(fmt.Sprintf("📥 **Loaded From**: %s\n", t.metadataSource))
 
 		entityNames := t.metadataParser.GetEntityNames()
 		enumNames := t.metadataParser.GetEnumNames()
@@ -974,13 +1165,21 @@ func (t *ResoHelpTool) getMetadataContent() string {
 	} else {
 		content.WriteString("❌ **Metadata Parser**: NOT LOADED - Using static fallback content\n\n")
 		content.WriteString("## Metadata Loading Priority\n")
-		content.WriteString("The server attempts to load metadata in this order:\n")
-		content.WriteString("1. **Cache File**: `/tmp/constellation1_metadata.xml` (fastest, avoids re-download)\n")
-		content.WriteString("2. **API Endpoint**: `https://listings.constellation1apis.com/$metadata` (fetches and caches)\n")
-		content.WriteString("3. **Local Files** (fallback only):\n")
-		content.WriteString("   - Current directory: `./constellation1_metadata.xml`\n")
-		content.WriteString("   - Parent directory: `../constellation1_metadata.xml`\n")
-		content.WriteString("   - Grandparent directory: `../../constellation1_metadata.xml`\n\n")
+		content.WriteString("The server attempts to load metadata in this order (set with `RESO_METADATA_SOURCE`):\n")
+		for i, source := range t.sources {
+			switch source {
+			case "cache":
+				content.WriteString(fmt.Sprintf("%d. **Cache File**: `%s` (fastest, avoids re-download)\n", i+1, metadataCacheFile))
+			case "api":
+				content.WriteString(fmt.Sprintf("%d. **API Endpoint**: `https://listings.constellation1apis.com/$metadata` (fetches and caches)\n", i+1))
+			case "file":
+				content.WriteString(fmt.Sprintf("%d. **Local Files**:\n", i+1))
+				content.WriteString("   - Current directory: `./constellation1_metadata.xml`\n")
+				content.WriteString("   - Parent directory: `../constellation1_metadata.xml`\n")
+				content.WriteString("   - Grandparent directory: `../../constellation1_metadata.xml`\n")
+			}
+		}
+		content.WriteString("\n")
 
 		content.WriteString("## Impact of Missing Metadata\n")
 		content.WriteString("- ⚠️ `entities` - Using static fallback (may be incomplete)\n")
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// helpTopics lists the reso_help topics in the order they are searched
+var helpTopics = []string{
+	"entities", "fields", "filters", "enums", "expand", "aliases",
+	"examples", "performance", "images", "metadata", "overview",
+}
+
+// maxHelpSearchResults caps the number of sections returned by a help search
+const maxHelpSearchResults = 20
+
+// helpSearchContext is the number of lines shown before and after each match
+const helpSearchContext = 2
+
+// helpSearchHeadingContext is the number of lines shown after a matching heading
+const helpSearchHeadingContext = 8
+
+// helpSearchMatch is a help section containing the search query
+type helpSearchMatch struct {
+	Topic   string
+	Heading string
+	Excerpt string
+}
+
+// searchHelp scans every help topic for query (case-insensitive) and renders the
+// matching sections with surrounding context
+func (t *ResoHelpTool) searchHelp(query string) string {
+	needle := strings.ToLower(strings.TrimSpace(query))
+
+	var matches []helpSearchMatch
+	total := 0
+	for _, topic := range helpTopics {
+		for _, match := range searchHelpContent(topic, t.getHelpContent(topic), needle) {
+			total++
+			if len(matches) < maxHelpSearchResults {
+				matches = append(matches, match)
+			}
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("# Help Search: \"%s\"\n\n", strings.TrimSpace(query)))
+
+	if len(matches) == 0 {
+		out.WriteString("No help sections matched. Try a shorter or different term, or use topic 'overview' to browse all topics.\n")
+		return out.String()
+	}
+
+	out.WriteString(fmt.Sprintf("Found %d matching section(s)", total))
+	if total > len(matches) {
+		out.WriteString(fmt.Sprintf(", showing the first %d", len(matches)))
+	}
+	out.WriteString(". Use reso_help with the topic name to read a section in full.\n\n")
+
+	for _, match := range matches {
+		out.WriteString(fmt.Sprintf("## [%s] %s\n\n", match.Topic, match.Heading))
+		out.WriteString(match.Excerpt)
+		out.WriteString("\n\n---\n\n")
+	}
+
+	return out.String()
+}
+
+// searchHelpContent splits topic content into heading-delimited sections and returns
+// those containing needle, with matching lines shown in context
+func searchHelpContent(topic, content, needle string) []helpSearchMatch {
+	if content == "" || needle == "" {
+		return nil
+	}
+
+	var matches []helpSearchMatch
+	lines := strings.Split(content, "\n")
+	heading := topic
+	start := 0
+
+	flush := func(end int) {
+		if excerpt := excerptMatches(lines[start:end], needle); excerpt != "" {
+			matches = append(matches, helpSearchMatch{Topic: topic, Heading: heading, Excerpt: excerpt})
+		}
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			flush(i)
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			start = i
+		}
+	}
+	flush(len(lines))
+
+	return matches
+}
+
+// excerptMatches returns the lines containing needle plus surrounding context, with
+// "..." marking gaps, or "" when nothing matches. Code fence markers are dropped so a
+// partial excerpt cannot leave a fence open.
+func excerptMatches(lines []string, needle string) string {
+	keep := make([]bool, len(lines))
+	found := false
+	for i, line := range lines {
+		if !strings.Contains(strings.ToLower(line), needle) {
+			continue
+		}
+		found = true
+		after := helpSearchContext
+		if strings.HasPrefix(line, "#") {
+			after = helpSearchHeadingContext
+		}
+		for j := max(0, i-helpSearchContext); j <= min(len(lines)-1, i+after); j++ {
+			keep[j] = true
+		}
+	}
+	if !found {
+		return ""
+	}
+
+	var excerpt []string
+	gap := false
+	for i, line := range lines {
+		if !keep[i] {
+			gap = true
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		if gap && len(excerpt) > 0 {
+			excerpt = append(excerpt, "...")
+		}
+		gap = false
+		excerpt = append(excerpt, line)
+	}
+	return strings.TrimSpace(strings.Join(excerpt, "\n"))
+}
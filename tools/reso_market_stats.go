@@ -0,0 +1,364 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// defaultMarketStatsFields are summarized when no fields are given
+var defaultMarketStatsFields = []string{"ClosePrice", "DaysOnMarket"}
+
+// defaultMarketStatsSample is the number of records sampled when max_sample is not given
+const defaultMarketStatsSample = 1000
+
+// maxMarketStatsSample caps max_sample
+const maxMarketStatsSample = api.DefaultMaxRecords
+
+// numericEdmTypes are the metadata types market stats can summarize
+var numericEdmTypes = map[string]bool{
+	"Edm.Byte": true, "Edm.SByte": true, "Edm.Int16": true, "Edm.Int32": true, "Edm.Int64": true,
+	"Edm.Decimal": true, "Edm.Double": true, "Edm.Single": true,
+}
+
+// ResoMarketStatsTool implements the reso_market_stats MCP tool, which computes
+// distribution statistics such as medians and quartiles over a sample of records
+type ResoMarketStatsTool struct {
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+}
+
+// MarketStatsResult is the outcome of a market stats request
+type MarketStatsResult struct {
+	Entity     string       `json:"entity"`
+	Filter     string       `json:"filter"`
+	OrderBy    string       `json:"orderby,omitempty"`
+	SampleSize int          `json:"sample_size"`
+	SampleCap  int          `json:"sample_cap"`
+	Capped     bool         `json:"sample_capped"`
+	Matching   int          `json:"matching_records,omitempty"`
+	Fields     []FieldStats `json:"fields"`
+}
+
+// FieldStats summarizes one numeric field across the sample
+type FieldStats struct {
+	Field   string             `json:"field"`
+	Count   int                `json:"count"`
+	Missing int                `json:"missing"`
+	Stats   *DistributionStats `json:"stats,omitempty"`
+}
+
+// DistributionStats describes the distribution of a field's values
+type DistributionStats struct {
+	Min    float64 `json:"min"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+}
+
+// NewResoMarketStatsTool creates a new RESO market stats tool
+func NewResoMarketStatsTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoMarketStatsTool {
+	return &ResoMarketStatsTool{
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoMarketStatsTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_market_stats",
+		Description: fmt.Sprintf("Compute market statistics (min, 25th percentile, median, 75th percentile, max and mean) of numeric fields such as ClosePrice and DaysOnMarket for the records matching a filter. Medians and percentiles are not available through $apply, so matching records are fetched page by page up to a capped sample (default %d) and summarized here. The output states the sample size and whether the cap was reached.", defaultMarketStatsSample),
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "OData filter selecting the records to summarize, e.g. \"StandardStatus eq 'Closed' and City eq 'Austin' and CloseDate ge 2024-01-01\".",
+				},
+				"fields": map[string]interface{}{
+					"type":        []string{"array", "string"},
+					"items":       map[string]interface{}{"type": "string"},
+					"description": fmt.Sprintf("Numeric fields to summarize, as an array or a comma-separated string. Default: %s.", strings.Join(defaultMarketStatsFields, ", ")),
+				},
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity to query. Default: Property.",
+				},
+				"max_sample": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of records fetched for the sample. Default: %d.", defaultMarketStatsSample),
+					"minimum":     1,
+					"maximum":     maxMarketStatsSample,
+				},
+				"orderby": map[string]interface{}{
+					"type":        "string",
+					"description": "Order in which records are sampled when more match than max_sample, e.g. 'CloseDate desc' for the most recent sales. Default: server order.",
+				},
+			},
+			"required": []string{"filter"},
+		},
+	}
+}
+
+// Execute executes the RESO market stats tool
+func (t *ResoMarketStatsTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	entity, _ := args["entity"].(string)
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		entity = "Property"
+	}
+
+	filter, _ := args["filter"].(string)
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return errorResult("Error parsing arguments: filter is required")
+	}
+
+	fields := defaultMarketStatsFields
+	switch v := args["fields"].(type) {
+	case string:
+		if list := splitFieldList(v); len(list) > 0 {
+			fields = list
+		}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				list = append(list, strings.TrimSpace(s))
+			}
+		}
+		if len(list) > 0 {
+			fields = list
+		}
+	}
+	for _, field := range fields {
+		if !fieldNamePattern.MatchString(field) {
+			return errorResult(fmt.Sprintf("Error parsing arguments: invalid field name %q", field))
+		}
+	}
+	if err := t.validateFields(entity, fields); err != nil {
+		return errorResult(fmt.Sprintf("Validation error: %s", err.Error()))
+	}
+
+	sample := defaultMarketStatsSample
+	if v, err := numberArg(args, "max_sample"); err == nil {
+		sample = int(v)
+	}
+	if sample < 1 || sample > maxMarketStatsSample {
+		return errorResult(fmt.Sprintf("Error parsing arguments: max_sample must be between 1 and %d", maxMarketStatsSample))
+	}
+
+	orderBy, _ := args["orderby"].(string)
+	orderBy = strings.TrimSpace(orderBy)
+	if orderBy != "" {
+		if _, err := parseOrderBy(orderBy); err != nil {
+			return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+		}
+	}
+
+	if err := checkEntityPolicy(t.client, t.metadataParser, entity, ""); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+	if err := checkFilterOperators(filter, "", t.config.AllowedOperators); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+
+	response, err := t.client.Query(api.QueryParams{
+		Entity:      entity,
+		Select:      strings.Join(fields, ","),
+		Filter:      filter,
+		OrderBy:     orderBy,
+		IgnoreNulls: true,
+		FetchAll:    true,
+		MaxRecords:  sample,
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error executing market stats query: %s", err.Error()))
+	}
+
+	result := &MarketStatsResult{
+		Entity:     entity,
+		Filter:     filter,
+		OrderBy:    orderBy,
+		SampleSize: len(response.Value),
+		SampleCap:  sample,
+		Capped:     response.MoreAvailable(),
+	}
+
+	// Without an exact count, a full sample may have stopped short of the matches
+	if len(response.Value) == sample {
+		result.Capped = true
+	}
+	if response.TotalCountExact {
+		result.Matching = response.TotalCount
+		result.Capped = response.TotalCount > len(response.Value)
+	}
+	for _, field := range fields {
+		result.Fields = append(result.Fields, computeFieldStats(field, response.Value))
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatMarketStats(result),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Market Stats:\n```json\n%s\n```", string(resultJSON)),
+			},
+		},
+	}
+}
+
+// validateFields confirms each field exists on the entity and is numeric when
+// metadata is loaded
+func (t *ResoMarketStatsTool) validateFields(entity string, fields []string) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	info, exists := t.metadataParser.GetEntityInfo(entity)
+	if !exists {
+		return nil
+	}
+
+	for _, field := range fields {
+		prop, exists := info.Properties[field]
+		if !exists {
+			hint := ""
+			if suggestions := t.metadataParser.SuggestFields(entity, field, 3); len(suggestions) > 0 {
+				hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+			}
+			return fmt.Errorf("%s is not a field of %s%s", field, entity, hint)
+		}
+		if !numericEdmTypes[prop.Type] || prop.IsCollection {
+			return fmt.Errorf("%s is %s, not a numeric field", field, prop.Type)
+		}
+	}
+	return nil
+}
+
+// computeFieldStats summarizes the numeric values of field across records
+func computeFieldStats(field string, records []map[string]interface{}) FieldStats {
+	stats := FieldStats{Field: field}
+
+	var values []float64
+	for _, record := range records {
+		value, ok := toFloat(record[field])
+		if !ok || math.IsNaN(value) {
+			stats.Missing++
+			continue
+		}
+		values = append(values, value)
+	}
+	stats.Count = len(values)
+	if len(values) == 0 {
+		return stats
+	}
+
+	sort.Float64s(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	stats.Stats = &DistributionStats{
+		Min:    values[0],
+		P25:    roundStat(percentile(values, 25)),
+		Median: roundStat(percentile(values, 50)),
+		P75:    roundStat(percentile(values, 75)),
+		Max:    values[len(values)-1],
+		Mean:   roundStat(sum / float64(len(values))),
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating linearly
+// between the closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	fraction := rank - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
+
+// roundStat rounds a computed statistic to two decimals
+func roundStat(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// formatMarketStats renders the sample disclosure and a stats table
+func formatMarketStats(result *MarketStatsResult) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Market Stats\n")
+	out.WriteString("=================\n\n")
+
+	out.WriteString(fmt.Sprintf("Entity: %s\n", result.Entity))
+	out.WriteString(fmt.Sprintf("Filter: %s\n", result.Filter))
+	if result.OrderBy != "" {
+		out.WriteString(fmt.Sprintf("Order By: %s\n", result.OrderBy))
+	}
+
+	out.WriteString(fmt.Sprintf("Sample: %d records", result.SampleSize))
+	switch {
+	case result.Capped && result.Matching > 0:
+		out.WriteString(fmt.Sprintf(" of %d matching (capped at max_sample=%d; statistics describe the sample, not every match)\n", result.Matching, result.SampleCap))
+	case result.Capped:
+		out.WriteString(fmt.Sprintf(" (capped at max_sample=%d; more records may match and statistics describe the sample only)\n", result.SampleCap))
+	default:
+		out.WriteString(" (every matching record)\n")
+	}
+
+	if result.SampleSize == 0 {
+		out.WriteString("\nNo records matched the filter.\n")
+		return out.String()
+	}
+
+	out.WriteString("\n| Field | Count | Min | P25 | Median | P75 | Max | Mean |\n")
+	out.WriteString("|-------|-------|-----|-----|--------|-----|-----|------|\n")
+	for _, field := range result.Fields {
+		if field.Stats == nil {
+			out.WriteString(fmt.Sprintf("| %s | 0 | - | - | - | - | - | - |\n", field.Field))
+			continue
+		}
+		s := field.Stats
+		out.WriteString(fmt.Sprintf("| %s | %d | %s | %s | %s | %s | %s | %s |\n", field.Field, field.Count,
+			humanizeValue(field.Field, s.Min), humanizeValue(field.Field, s.P25), humanizeValue(field.Field, s.Median),
+			humanizeValue(field.Field, s.P75), humanizeValue(field.Field, s.Max), humanizeValue(field.Field, s.Mean)))
+	}
+
+	for _, field := range result.Fields {
+		if field.Missing > 0 {
+			out.WriteString(fmt.Sprintf("\nNote: %s is missing from %d of %d sampled records, which are left out of its statistics.", field.Field, field.Missing, result.SampleSize))
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+// rawBookkeepingFields are RawMlsProperty fields that duplicate the standardized
+// record or only describe the raw payload itself, so they are never merged
+var rawBookkeepingFields = map[string]bool{
+	"ListingKey":                  true,
+	"ListingId":                   true,
+	"OriginatingSystemName":       true,
+	"ModificationTimestamp":       true,
+	"EntityEventSequenceNumeric":  true,
+	"Metadata":                    true,
+	"RawMlsFieldsOrder":           true,
+	"RawMlsFieldsOrderCompressed": true,
+	"@odata.id":                   true,
+	"@odata.etag":                 true,
+}
+
+// ResoMergeTool implements the reso_merge_raw MCP tool, which enriches a
+// standardized Property record with fields from its RawMlsProperty counterpart
+type ResoMergeTool struct {
+	client *api.Client
+	config *config.Config
+}
+
+// MergedListing represents a Property record merged with raw MLS fields
+type MergedListing struct {
+	ListingKey   string                 `json:"listing_key"`
+	RawAvailable bool                   `json:"raw_available"`
+	MergedFields []string               `json:"merged_fields"`
+	MissingRaw   []string               `json:"missing_raw_fields,omitempty"`
+	Record       map[string]interface{} `json:"record"`
+}
+
+// NewResoMergeTool creates a new RESO merge tool
+func NewResoMergeTool(client *api.Client, cfg *config.Config) *ResoMergeTool {
+	return &ResoMergeTool{
+		client: client,
+		config: cfg,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoMergeTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_merge_raw",
+		Description: "Fetch a listing from both the standardized Property entity and the RawMlsProperty entity and merge selected raw MLS fields into the standardized record. Raw fields are prefixed to avoid collisions with standard RESO field names. Use this when you need MLS-specific fields that are not part of the standardized Property entity.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"listing_key": map[string]interface{}{
+					"type":        "string",
+					"description": "ListingKey of the listing to fetch from both Property and RawMlsProperty.",
+				},
+				"raw_fields": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated list of RawMlsProperty fields to merge. Leave empty to merge every raw field that is not already present in the standardized record.",
+				},
+				"select": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated list of Property fields to return. Leave empty to get all available fields.",
+				},
+				"prefix": map[string]interface{}{
+					"type":        "string",
+					"description": "Prefix applied to merged raw field names. Default: 'Raw_'.",
+					"default":     "Raw_",
+				},
+			},
+			"required": []string{"listing_key"},
+		},
+	}
+}
+
+// Execute executes the RESO merge tool
+func (t *ResoMergeTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	listingKey, ok := args["listing_key"].(string)
+	if !ok || strings.TrimSpace(listingKey) == "" {
+		return errorResult("Error parsing arguments: listing_key is required")
+	}
+	listingKey = strings.TrimSpace(listingKey)
+
+	prefix := "Raw_"
+	if p, ok := args["prefix"].(string); ok && p != "" {
+		prefix = p
+	}
+
+	var rawFields []string
+	if fields, ok := args["raw_fields"].(string); ok {
+		rawFields = splitFieldList(fields)
+	}
+
+	filter := fmt.Sprintf("ListingKey eq %s", QuoteODataString(listingKey))
+
+	// Fetch the standardized record
+	params := api.QueryParams{
+		Entity:      "Property",
+		Filter:      filter,
+		Top:         1,
+		IgnoreNulls: true,
+	}
+	if sel, ok := args["select"].(string); ok {
+		params.Select = strings.TrimSpace(sel)
+	}
+
+	propResp, err := t.client.Query(params)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error querying Property: %s", err.Error()))
+	}
+	if len(propResp.Value) == 0 {
+		return errorResult(fmt.Sprintf("No Property record found for ListingKey '%s'", listingKey))
+	}
+
+	// Fetch the raw record; absence is not an error
+	rawResp, err := t.client.Query(api.QueryParams{
+		Entity:      "RawMlsProperty",
+		Filter:      filter,
+		Top:         1,
+		IgnoreNulls: true,
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error querying RawMlsProperty: %s", err.Error()))
+	}
+
+	var rawRecord map[string]interface{}
+	if len(rawResp.Value) > 0 {
+		rawRecord = rawResp.Value[0]
+	}
+
+	merged := mergeRawFields(propResp.Value[0], rawRecord, rawFields, prefix)
+	merged.ListingKey = listingKey
+
+	mergedJSON, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: t.createSummary(merged, prefix),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Merged Record:\n```json\n%s\n```", string(mergedJSON)),
+			},
+		},
+	}
+}
+
+// mergeRawFields copies raw fields into a copy of the standardized record under prefixed names
+func mergeRawFields(standard, raw map[string]interface{}, fields []string, prefix string) *MergedListing {
+	record := make(map[string]interface{}, len(standard))
+	for k, v := range standard {
+		record[k] = v
+	}
+
+	merged := &MergedListing{
+		RawAvailable: raw != nil,
+		MergedFields: []string{},
+		Record:       record,
+	}
+
+	if raw == nil {
+		merged.MissingRaw = fields
+		return merged
+	}
+
+	// Default to every raw field not already covered by the standardized record
+	if len(fields) == 0 {
+		for name := range raw {
+			if rawBookkeepingFields[name] {
+				continue
+			}
+			if _, exists := standard[name]; exists {
+				continue
+			}
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+	}
+
+	for _, name := range fields {
+		value, exists := raw[name]
+		if !exists {
+			merged.MissingRaw = append(merged.MissingRaw, name)
+			continue
+		}
+		record[prefix+name] = value
+		merged.MergedFields = append(merged.MergedFields, prefix+name)
+	}
+
+	return merged
+}
+
+// createSummary creates a human-readable summary of the merge
+func (t *ResoMergeTool) createSummary(merged *MergedListing, prefix string) string {
+	var summary strings.Builder
+
+	summary.WriteString("RESO Raw Merge Results\n")
+	summary.WriteString("======================\n\n")
+
+	summary.WriteString(fmt.Sprintf("ListingKey: %s\n", merged.ListingKey))
+	if !merged.RawAvailable {
+		summary.WriteString("Raw Data: not available for this listing (standardized record returned as-is)\n")
+		return summary.String()
+	}
+
+	summary.WriteString(fmt.Sprintf("Raw Fields Merged: %d (prefix '%s')\n", len(merged.MergedFields), prefix))
+	if len(merged.MissingRaw) > 0 {
+		summary.WriteString(fmt.Sprintf("Requested Raw Fields Not Found: %s\n", strings.Join(merged.MissingRaw, ", ")))
+	}
+
+	return summary.String()
+}
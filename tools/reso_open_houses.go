@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+// openHouseFields are the OpenHouse fields returned for each event
+const openHouseFields = "OpenHouseKey,ListingKey,OpenHouseStartTime,OpenHouseEndTime,OpenHouseType,OpenHouseStatus,OpenHouseRemarks,AppointmentRequiredYN,Refreshments,VirtualURL"
+
+// openHousePropertyFields are the parent Property fields attached to each event
+const openHousePropertyFields = "ListingKey,ListingId,UnparsedAddress,City,StateOrProvince,PostalCode,StandardStatus,ListPrice,PropertyType,PropertySubType,BedroomsTotal,BathroomsTotalInteger,LivingArea"
+
+// defaultOpenHouseLimit is the number of open houses returned when no limit is given
+const defaultOpenHouseLimit = 25
+
+// maxOpenHouseLimit caps the limit argument
+const maxOpenHouseLimit = 200
+
+// maxOpenHouseScan caps the open houses read when filtering by location, since the
+// OpenHouse entity has no address fields and each event's listing must be looked up
+const maxOpenHouseScan = 1000
+
+// ResoOpenHousesTool implements the reso_open_houses MCP tool, which lists upcoming
+// open houses in a date range with their listing's address and price
+type ResoOpenHousesTool struct {
+	client *api.Client
+	config *config.Config
+}
+
+// openHouseSearch holds the parsed search arguments
+type openHouseSearch struct {
+	From            time.Time
+	To              time.Time
+	Location        *time.Location
+	City            string
+	PostalCode      string
+	Limit           int
+	IncludeProperty bool
+}
+
+// OpenHousesResult is the outcome of an open house search
+type OpenHousesResult struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	City       string           `json:"city,omitempty"`
+	PostalCode string           `json:"postal_code,omitempty"`
+	Filter     string           `json:"filter"`
+	Scanned    int              `json:"scanned"`
+	Incomplete bool             `json:"incomplete,omitempty"`
+	OpenHouses []OpenHouseEvent `json:"open_houses"`
+}
+
+// OpenHouseEvent is one open house with its listing
+type OpenHouseEvent struct {
+	OpenHouse map[string]interface{} `json:"open_house"`
+	Property  map[string]interface{} `json:"property,omitempty"`
+}
+
+// NewResoOpenHousesTool creates a new RESO open houses tool
+func NewResoOpenHousesTool(client *api.Client, cfg *config.Config) *ResoOpenHousesTool {
+	return &ResoOpenHousesTool{
+		client: client,
+		config: cfg,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoOpenHousesTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_open_houses",
+		Description: "Find open houses in a date range, optionally in a city or postal code, ordered by start time. Builds the OpenHouseStartTime filter with proper ISO timestamps, so no OData is needed. Defaults to the coming weekend (Saturday and Sunday, or the rest of the current weekend). Each open house comes with its listing's address, price, beds, baths and living area.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"city": map[string]interface{}{
+					"type":        "string",
+					"description": "Only open houses for listings in this city (case-insensitive), e.g. 'Seattle'.",
+				},
+				"postal_code": map[string]interface{}{
+					"type":        "string",
+					"description": "Only open houses for listings in this postal code, e.g. '98103'.",
+				},
+				"from": map[string]interface{}{
+					"type":        "string",
+					"description": "Start of the range: a date (2024-06-01, from the start of that day) or an ISO 8601 timestamp. Default: the coming weekend.",
+				},
+				"to": map[string]interface{}{
+					"type":        "string",
+					"description": "End of the range: a date (2024-06-02, through the end of that day) or an ISO 8601 timestamp. Default: 7 days after from, or the end of the coming weekend.",
+				},
+				"timezone": map[string]interface{}{
+					"type":        "string",
+					"description": "IANA time zone used for dates and the weekend default, e.g. 'America/Los_Angeles'. Default: the configured timezone (RESO_TIMEZONE), or the server's local time zone.",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of open houses to return. Default: %d.", defaultOpenHouseLimit),
+					"minimum":     1,
+					"maximum":     maxOpenHouseLimit,
+				},
+				"include_property": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Attach each open house's listing (address, price, beds, baths, living area). Always on when city or postal_code is given. Default: true.",
+					"default":     true,
+				},
+			},
+		},
+	}
+}
+
+// Execute executes the RESO open houses tool
+func (t *ResoOpenHousesTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	search, err := parseOpenHouseSearch(args, time.Now(), t.defaultLocation())
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	if err := t.client.CheckEntityAllowed("OpenHouse"); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+	if search.IncludeProperty {
+		if err := t.client.CheckEntityAllowed("Property"); err != nil {
+			return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+		}
+	}
+
+	result, err := t.search(search)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error querying open houses: %s", err.Error()))
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatOpenHouses(result, search.Location),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Open Houses:\n```json\n%s\n```", string(resultJSON)),
+			},
+		},
+	}
+}
+
+// search queries open houses in the range ordered by start time and attaches their
+// listings. With a location, open houses are read up to maxOpenHouseScan and kept
+// only when their listing matches it.
+func (t *ResoOpenHousesTool) search(search *openHouseSearch) (*OpenHousesResult, error) {
+	filter := openHouseFilter(search.From, search.To)
+	params := api.QueryParams{
+		Entity:      "OpenHouse",
+		Select:      openHouseFields,
+		Filter:      filter,
+		OrderBy:     "OpenHouseStartTime asc",
+		IgnoreNulls: true,
+	}
+
+	located := search.City != "" || search.PostalCode != ""
+	if located {
+		params.FetchAll = true
+		params.MaxRecords = maxOpenHouseScan
+	} else {
+		params.Top = search.Limit
+	}
+
+	response, err := t.client.Query(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OpenHousesResult{
+		From:       search.From.Format(time.RFC3339),
+		To:         search.To.Format(time.RFC3339),
+		City:       search.City,
+		PostalCode: search.PostalCode,
+		Filter:     filter,
+		Scanned:    len(response.Value),
+		Incomplete: located && response.MoreAvailable(),
+		OpenHouses: []OpenHouseEvent{},
+	}
+
+	var properties map[string]map[string]interface{}
+	if search.IncludeProperty {
+		properties, err = t.lookupProperties(response.Value, search)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, openHouse := range response.Value {
+		event := OpenHouseEvent{OpenHouse: openHouse}
+		if properties != nil {
+			event.Property = properties[keyString(openHouse["ListingKey"])]
+			if located && event.Property == nil {
+				continue
+			}
+		}
+		result.OpenHouses = append(result.OpenHouses, event)
+		if len(result.OpenHouses) == search.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// lookupProperties fetches the listings of the open houses, restricted to the search
+// location when one is given, keyed by ListingKey
+func (t *ResoOpenHousesTool) lookupProperties(openHouses []map[string]interface{}, search *openHouseSearch) (map[string]map[string]interface{}, error) {
+	lookup := &keyLookup{Field: "ListingKey"}
+	seen := make(map[string]bool)
+	for _, openHouse := range openHouses {
+		key, ok := openHouse["ListingKey"].(string)
+		if !ok || key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		lookup.Keys = append(lookup.Keys, key)
+		lookup.Literals = append(lookup.Literals, QuoteODataString(key))
+	}
+
+	properties := make(map[string]map[string]interface{}, len(lookup.Keys))
+	if len(lookup.Keys) == 0 {
+		return properties, nil
+	}
+
+	var locationFilter string
+	if search.City != "" {
+		locationFilter = combineFilters(locationFilter, fmt.Sprintf("City eq %s", QuoteODataString(search.City)))
+	}
+	if search.PostalCode != "" {
+		locationFilter = combineFilters(locationFilter, fmt.Sprintf("PostalCode eq %s", QuoteODataString(search.PostalCode)))
+	}
+
+	for i, chunkFilter := range lookup.chunkFilters() {
+		response, err := t.client.Query(api.QueryParams{
+			Entity:      "Property",
+			Select:      openHousePropertyFields,
+			Filter:      combineFilters(locationFilter, chunkFilter),
+			IgnoreNulls: true,
+			IgnoreCase:  true,
+			FetchAll:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing lookup %d: %w", i+1, err)
+		}
+		for _, record := range response.Value {
+			properties[keyString(record["ListingKey"])] = record
+		}
+	}
+	return properties, nil
+}
+
+// defaultLocation is the configured timezone, or the server's local time zone when
+// none is set
+func (t *ResoOpenHousesTool) defaultLocation() *time.Location {
+	if t.config.Timezone == "" {
+		return time.Local
+	}
+	return t.config.Location()
+}
+
+// parseOpenHouseSearch reads the search arguments, resolving the date range against
+// now in the requested time zone, or location when none is given
+func parseOpenHouseSearch(args map[string]interface{}, now time.Time, location *time.Location) (*openHouseSearch, error) {
+	search := &openHouseSearch{
+		Location:        location,
+		Limit:           defaultOpenHouseLimit,
+		IncludeProperty: true,
+	}
+
+	if name, ok := args["timezone"].(string); ok && strings.TrimSpace(name) != "" {
+		location, err := time.LoadLocation(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q (use an IANA name such as America/Chicago)", name)
+		}
+		search.Location = location
+	}
+	now = now.In(search.Location)
+
+	if city, ok := args["city"].(string); ok {
+		search.City = strings.TrimSpace(city)
+	}
+	if postalCode, ok := args["postal_code"].(string); ok {
+		search.PostalCode = strings.TrimSpace(postalCode)
+	}
+	if include, ok := args["include_property"].(bool); ok {
+		search.IncludeProperty = include
+	}
+	if search.City != "" || search.PostalCode != "" {
+		search.IncludeProperty = true
+	}
+
+	if v, err := numberArg(args, "limit"); err == nil {
+		search.Limit = int(v)
+	}
+	if search.Limit < 1 || search.Limit > maxOpenHouseLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxOpenHouseLimit)
+	}
+
+	fromArg, _ := args["from"].(string)
+	toArg, _ := args["to"].(string)
+	fromArg, toArg = strings.TrimSpace(fromArg), strings.TrimSpace(toArg)
+
+	switch {
+	case fromArg == "" && toArg == "":
+		search.From, search.To = upcomingWeekend(now)
+	case fromArg == "":
+		search.From = now
+	default:
+		from, _, err := parseOpenHouseTime(fromArg, search.Location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+		search.From = from
+	}
+
+	if toArg != "" {
+		to, dateOnly, err := parseOpenHouseTime(toArg, search.Location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+		// A date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		search.To = to
+	} else if fromArg != "" {
+		search.To = search.From.AddDate(0, 0, 7)
+	}
+
+	if !search.To.After(search.From) {
+		return nil, fmt.Errorf("to (%s) must be after from (%s)", search.To.Format(time.RFC3339), search.From.Format(time.RFC3339))
+	}
+	return search, nil
+}
+
+// parseOpenHouseTime parses a date (midnight in location) or an ISO 8601 timestamp,
+// reporting whether the value was a date
+func parseOpenHouseTime(value string, location *time.Location) (time.Time, bool, error) {
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.In(location), false, nil
+	}
+	if ts, err := time.ParseInLocation("2006-01-02T15:04:05", value, location); err == nil {
+		return ts, false, nil
+	}
+	if day, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return day, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not a date like 2024-06-01 or a timestamp like 2024-06-01T13:00:00-07:00", value)
+}
+
+// upcomingWeekend returns the coming Saturday 00:00 to Monday 00:00, or from now to
+// Monday 00:00 when now is already in a weekend
+func upcomingWeekend(now time.Time) (time.Time, time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch now.Weekday() {
+	case time.Saturday:
+		return now, midnight.AddDate(0, 0, 2)
+	case time.Sunday:
+		return now, midnight.AddDate(0, 0, 1)
+	}
+	saturday := midnight.AddDate(0, 0, int(time.Saturday-now.Weekday()))
+	return saturday, saturday.AddDate(0, 0, 2)
+}
+
+// openHouseFilter builds the OpenHouseStartTime range filter with UTC timestamps,
+// which OData expects unquoted, leaving out cancelled events
+func openHouseFilter(from, to time.Time) string {
+	return fmt.Sprintf("OpenHouseStartTime ge %s and OpenHouseStartTime lt %s and OpenHouseStatus ne 'Cancelled'",
+		from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+}
+
+// formatOpenHouses renders the search and a table of open houses in local time
+func formatOpenHouses(result *OpenHousesResult, location *time.Location) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Open Houses\n")
+	out.WriteString("================\n\n")
+
+	from, _ := time.Parse(time.RFC3339, result.From)
+	to, _ := time.Parse(time.RFC3339, result.To)
+	out.WriteString(fmt.Sprintf("Range: %s to %s\n", from.In(location).Format("Mon Jan 2 2006 3:04 PM MST"), to.In(location).Format("Mon Jan 2 2006 3:04 PM MST")))
+	if result.City != "" {
+		out.WriteString(fmt.Sprintf("City: %s\n", result.City))
+	}
+	if result.PostalCode != "" {
+		out.WriteString(fmt.Sprintf("Postal Code: %s\n", result.PostalCode))
+	}
+	out.WriteString(fmt.Sprintf("Filter: %s\n", result.Filter))
+	out.WriteString(fmt.Sprintf("Open Houses: %d\n", len(result.OpenHouses)))
+	if result.Incomplete {
+		out.WriteString(fmt.Sprintf("\nNote: only the first %d open houses in the range were checked against the location; narrow the date range to see the rest.\n", maxOpenHouseScan))
+	}
+
+	if len(result.OpenHouses) == 0 {
+		out.WriteString("\nNo open houses found. Try a wider date range or another location.\n")
+		return out.String()
+	}
+
+	out.WriteString("\n| # | When | Address | Price | Beds | Baths | Sqft | Type |\n")
+	out.WriteString("|---|------|---------|-------|------|-------|------|------|\n")
+	for i, event := range result.OpenHouses {
+		address := valueOr(event.OpenHouse["ListingKey"], "-")
+		var price, beds, baths, area interface{} = "-", "-", "-", "-"
+		if p := event.Property; p != nil {
+			if street, ok := p["UnparsedAddress"].(string); ok {
+				address = street
+				if city, ok := p["City"].(string); ok {
+					address = street + ", " + city
+				}
+			}
+			if v, ok := p["ListPrice"].(float64); ok {
+				price = formatCurrency(v)
+			}
+			beds, baths = valueOr(p["BedroomsTotal"], "-"), valueOr(p["BathroomsTotalInteger"], "-")
+			if v, ok := p["LivingArea"].(float64); ok {
+				area = groupDigits(v, 0)
+			}
+		}
+		out.WriteString(fmt.Sprintf("| %d | %s | %v | %v | %v | %v | %v | %v |\n",
+			i+1, openHouseWhen(event.OpenHouse, location), address, price, beds, baths, area, valueOr(event.OpenHouse["OpenHouseType"], "-")))
+	}
+	return out.String()
+}
+
+// openHouseWhen renders an open house's start and end time in location
+func openHouseWhen(openHouse map[string]interface{}, location *time.Location) string {
+	startValue, _ := openHouse["OpenHouseStartTime"].(string)
+	start, err := time.Parse(time.RFC3339, startValue)
+	if err != nil {
+		return fmt.Sprint(valueOr(openHouse["OpenHouseStartTime"], "-"))
+	}
+	when := start.In(location).Format("Mon Jan 2 3:04 PM")
+	if endValue, ok := openHouse["OpenHouseEndTime"].(string); ok {
+		if end, err := time.Parse(time.RFC3339, endValue); err == nil {
+			when += " - " + end.In(location).Format("3:04 PM")
+		}
+	}
+	return when
+}
@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// maxListingPhotos bounds how many Media records are fetched for one listing
+const maxListingPhotos = 500
+
+// ResoPhotosTool implements the reso_photos MCP tool, which returns the ordered
+// public photo URLs of a listing
+type ResoPhotosTool struct {
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+}
+
+// ListingPhotos is the ordered photo list returned for a listing. Media holds the
+// media_select fields of each returned photo, in the same order as URLs.
+type ListingPhotos struct {
+	ListingKey  string                   `json:"listing_key"`
+	PhotosCount int                      `json:"photos_count"`
+	ImageSize   string                   `json:"image_size,omitempty"`
+	MediaKind   string                   `json:"media_kind,omitempty"`
+	URLs        []string                 `json:"urls"`
+	Media       []map[string]interface{} `json:"media,omitempty"`
+	MediaFields []string                 `json:"media_fields,omitempty"`
+}
+
+// NewResoPhotosTool creates a new RESO photos tool
+func NewResoPhotosTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoPhotosTool {
+	return &ResoPhotosTool{
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoPhotosTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_photos",
+		Description: "Get the public photo URLs of one listing by ListingKey, in display order, plus the total PhotosCount. Private photos and non-photo media (videos, tours, documents) are excluded unless media_kind asks for another kind. Use this for galleries instead of expanding Media on a Property query.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"listing_key": map[string]interface{}{
+					"type":        "string",
+					"description": "ListingKey of the listing whose photos to return.",
+				},
+				"image_size": map[string]interface{}{
+					"type":        "string",
+					"description": imageSizeDescription,
+				},
+				"media_kind": map[string]interface{}{
+					"type":        "string",
+					"description": mediaKindDescription + " Default: photos (MediaCategory 'Photo').",
+					"enum":        mediaKinds,
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Return at most this many URLs (the first photos in display order). PhotosCount still reports the total. Default: all photos.",
+					"minimum":     1,
+				},
+				"media_select": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated Media fields to return for each photo, e.g. 'MediaURL,Order,ShortDescription,MediaCategory'. The records are added as 'media' next to the URLs. Fields are checked against the Media metadata. Default: URLs only.",
+				},
+			},
+			"required": []string{"listing_key"},
+		},
+	}
+}
+
+// Execute executes the RESO photos tool
+func (t *ResoPhotosTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	listingKey, _ := args["listing_key"].(string)
+	listingKey = strings.TrimSpace(listingKey)
+	if listingKey == "" {
+		return errorResult("Error parsing arguments: listing_key is required")
+	}
+
+	imageSize, err := parseImageSize(args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	kind, err := parseMediaKind(args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	limit := 0
+	switch v := args["limit"].(type) {
+	case float64:
+		limit = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			limit = parsed
+		}
+	}
+	if _, given := args["limit"]; given && limit <= 0 {
+		return errorResult("Error parsing arguments: limit must be a positive integer")
+	}
+
+	mediaSelect, _ := args["media_select"].(string)
+	mediaFields := splitFieldList(mediaSelect)
+	if _, given := args["media_select"]; given && len(mediaFields) == 0 {
+		return errorResult("Error parsing arguments: media_select must name at least one Media field")
+	}
+	if err := t.validateMediaFields(mediaFields); err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	// Fetch every public photo so PhotosCount is exact; listings rarely have more
+	// than a page of photos. A media kind is matched here rather than in the filter,
+	// since MLSes spell MediaType and MediaCategory differently.
+	params := api.QueryParams{
+		Entity:      "Media",
+		Select:      "MediaKey,MediaURL,Order",
+		Filter:      fmt.Sprintf("ResourceRecordKey eq %s and MediaCategory eq 'Photo' and Permission ne 'Private'", QuoteODataString(listingKey)),
+		OrderBy:     "Order asc",
+		IgnoreNulls: true,
+		FetchAll:    true,
+		MaxRecords:  maxListingPhotos,
+	}
+	if kind != "" {
+		params.Select = "MediaKey,MediaURL,Order,MediaType,MediaCategory"
+		params.Filter = fmt.Sprintf("ResourceRecordKey eq %s and Permission ne 'Private'", QuoteODataString(listingKey))
+	}
+	params.Select = mergeSelect(params.Select, mediaFields)
+	response, err := t.client.Query(params)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error fetching photos: %s", err.Error()))
+	}
+
+	photos := buildListingPhotos(listingKey, filterMediaKind(response.Value, kind), imageSize, limit, mediaFields)
+	photos.MediaKind = kind
+
+	photosJSON, err := json.MarshalIndent(photos, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatListingPhotos(photos),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Photos:\n```json\n%s\n```", string(photosJSON)),
+			},
+		},
+	}
+}
+
+// validateMediaFields confirms each media_select field exists on Media when metadata
+// is loaded
+func (t *ResoPhotosTool) validateMediaFields(fields []string) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	if _, exists := t.metadataParser.GetEntityInfo("Media"); !exists {
+		return nil
+	}
+
+	for _, field := range fields {
+		if t.metadataParser.HasField("Media", field) {
+			continue
+		}
+		hint := ""
+		if suggestions := t.metadataParser.SuggestFields("Media", field, 3); len(suggestions) > 0 {
+			hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+		}
+		return fmt.Errorf("media_select: %s is not a field of Media%s", field, hint)
+	}
+	return nil
+}
+
+// mergeSelect appends the fields missing from a comma-separated $select list
+func mergeSelect(selectList string, fields []string) string {
+	selected := splitFieldList(selectList)
+	seen := make(map[string]bool, len(selected)+len(fields))
+	for _, field := range selected {
+		seen[field] = true
+	}
+	for _, field := range fields {
+		if !seen[field] {
+			seen[field] = true
+			selected = append(selected, field)
+		}
+	}
+	return strings.Join(selected, ",")
+}
+
+// buildListingPhotos collects the sized URLs of the ordered Media records, keeping at
+// most limit URLs when limit is positive. With fields, each kept photo also gets a
+// record holding just those fields.
+func buildListingPhotos(listingKey string, records []map[string]interface{}, imageSize string, limit int, fields []string) *ListingPhotos {
+	photos := &ListingPhotos{
+		ListingKey:  listingKey,
+		ImageSize:   imageSize,
+		URLs:        []string{},
+		MediaFields: fields,
+	}
+
+	for _, record := range records {
+		mediaURL, ok := record["MediaURL"].(string)
+		if !ok || mediaURL == "" {
+			continue
+		}
+		photos.PhotosCount++
+		if limit > 0 && len(photos.URLs) >= limit {
+			continue
+		}
+		if imageSize != "" {
+			mediaURL = sizedImageURL(mediaURL, imageSize)
+		}
+		photos.URLs = append(photos.URLs, mediaURL)
+		if len(fields) > 0 {
+			photos.Media = append(photos.Media, selectMediaFields(record, fields, mediaURL))
+		}
+	}
+
+	return photos
+}
+
+// selectMediaFields copies the chosen fields of a Media record, using the sized
+// mediaURL for MediaURL. Fields the record leaves empty are omitted.
+func selectMediaFields(record map[string]interface{}, fields []string, mediaURL string) map[string]interface{} {
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if field == "MediaURL" {
+			selected[field] = mediaURL
+		} else if value, ok := record[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+// formatListingPhotos renders the human-readable summary of a photo list
+func formatListingPhotos(photos *ListingPhotos) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Listing Photos\n")
+	out.WriteString("===================\n\n")
+
+	out.WriteString(fmt.Sprintf("Listing Key: %s\n", photos.ListingKey))
+	out.WriteString(fmt.Sprintf("Photos Count: %d\n", photos.PhotosCount))
+	if len(photos.URLs) < photos.PhotosCount {
+		out.WriteString(fmt.Sprintf("Returned: first %d\n", len(photos.URLs)))
+	}
+	if photos.ImageSize != "" {
+		out.WriteString(fmt.Sprintf("Image Size: %s\n", photos.ImageSize))
+	}
+	if photos.MediaKind != "" {
+		out.WriteString(fmt.Sprintf("Media Kind: %s\n", photos.MediaKind))
+	}
+	if len(photos.MediaFields) > 0 {
+		out.WriteString(fmt.Sprintf("Media Fields: %s\n", strings.Join(photos.MediaFields, ", ")))
+	}
+
+	if photos.PhotosCount == 0 {
+		if photos.MediaKind != "" {
+			out.WriteString(fmt.Sprintf("\nNo public %s media found for this listing.\n", photos.MediaKind))
+			return out.String()
+		}
+		out.WriteString("\nNo public photos found for this listing.\n")
+		return out.String()
+	}
+
+	out.WriteString("\n")
+	for i, mediaURL := range photos.URLs {
+		out.WriteString(fmt.Sprintf("%d. %s\n", i+1, mediaURL))
+	}
+	return out.String()
+}
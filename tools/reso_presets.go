@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// presetNamePattern restricts preset names to simple identifiers such as weekly_new_listings
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ResoPresetsTool implements the reso_presets MCP tool, which lists, shows and runs
+// named reso_query presets stored in the presets_file
+type ResoPresetsTool struct {
+	client    *api.Client
+	config    *config.Config
+	queryTool *ResoQueryTool
+}
+
+// QueryPreset is a named, reusable set of reso_query arguments
+type QueryPreset struct {
+	Description string                 `json:"description,omitempty"`
+	Query       map[string]interface{} `json:"query"`
+}
+
+// PresetsFile represents the JSON file referenced by presets_file
+type PresetsFile struct {
+	Presets map[string]*QueryPreset `json:"presets"`
+}
+
+// PresetSummary describes one preset in the list operation
+type PresetSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Entity      string `json:"entity"`
+	Filter      string `json:"filter,omitempty"`
+}
+
+// NewResoPresetsTool creates a new RESO presets tool
+func NewResoPresetsTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoPresetsTool {
+	return &ResoPresetsTool{
+		client:    client,
+		config:    cfg,
+		queryTool: NewResoQueryToolWithMetadata(client, cfg, parser),
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoPresetsTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_presets",
+		Description: "List, show and run named query presets saved by the server operator (e.g. 'weekly_new_listings'). A preset stores reso_query arguments such as entity, filter, select and orderby, so common searches can be run by name. 'run' executes the preset through reso_query, with any 'overrides' replacing the preset's arguments of the same name.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"operation": map[string]interface{}{
+					"type":        "string",
+					"description": "'list' shows every preset, 'get' shows one preset's arguments and 'run' executes it",
+					"enum":        []string{"list", "get", "run"},
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Preset name, required for 'get' and 'run'",
+				},
+				"overrides": map[string]interface{}{
+					"type":        "object",
+					"description": "reso_query arguments that replace the preset's values for this run only, e.g. {\"top\": 50} or {\"filter\": \"City eq 'Austin'\"}",
+				},
+			},
+			"required": []string{"operation"},
+		},
+	}
+}
+
+// Execute executes the RESO presets tool
+func (t *ResoPresetsTool) Execute(args map[string]interface{}) MCPToolResult {
+	presets, err := t.LoadPresets()
+	if err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	operation, _ := args["operation"].(string)
+	if operation == "list" {
+		return t.list(presets)
+	}
+
+	name, _ := args["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errorResult(fmt.Sprintf("Error parsing arguments: name is required for the %s operation", operation))
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return errorResult(fmt.Sprintf("Unknown preset %q (available: %s)", name, strings.Join(presetNames(presets), ", ")))
+	}
+
+	switch operation {
+	case "get":
+		return t.get(name, preset)
+	case "run":
+		overrides, _ := args["overrides"].(map[string]interface{})
+		return t.run(name, preset, overrides)
+	default:
+		return errorResult(fmt.Sprintf("Error parsing arguments: unknown operation %q (use list, get or run)", operation))
+	}
+}
+
+// LoadPresets reads and validates the presets_file. Every preset must have a valid
+// name and query arguments that reso_query accepts, so a broken file is reported as a
+// whole rather than when one preset happens to run.
+func (t *ResoPresetsTool) LoadPresets() (map[string]*QueryPreset, error) {
+	if t.config.PresetsFile == "" {
+		return nil, fmt.Errorf("no presets file configured (set RESO_PRESETS_FILE)")
+	}
+
+	data, err := os.ReadFile(t.config.PresetsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets file: %w", err)
+	}
+
+	var file PresetsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse presets file: %w", err)
+	}
+
+	definition := t.queryTool.GetToolDefinition()
+	var problems []string
+	for _, name := range presetNames(file.Presets) {
+		preset := file.Presets[name]
+		if !presetNamePattern.MatchString(name) {
+			problems = append(problems, fmt.Sprintf("%q: name may only contain letters, digits, '_' and '-'", name))
+			continue
+		}
+		if preset == nil || preset.Query == nil {
+			problems = append(problems, fmt.Sprintf("%s: missing query", name))
+			continue
+		}
+		if err := ValidateArguments(definition.InputSchema, preset.Query, definition.SelfChecked...); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err.Error()))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid presets in %s: %s", t.config.PresetsFile, strings.Join(problems, "; "))
+	}
+	return file.Presets, nil
+}
+
+// list renders every preset's name, description, entity and filter
+func (t *ResoPresetsTool) list(presets map[string]*QueryPreset) MCPToolResult {
+	summaries := make([]PresetSummary, 0, len(presets))
+	var out strings.Builder
+	out.WriteString("RESO Query Presets\n")
+	out.WriteString("==================\n\n")
+	if len(presets) == 0 {
+		out.WriteString("No presets are defined.\n")
+	}
+	for _, name := range presetNames(presets) {
+		preset := presets[name]
+		summary := PresetSummary{Name: name, Description: preset.Description}
+		summary.Entity, _ = preset.Query["entity"].(string)
+		summary.Filter, _ = preset.Query["filter"].(string)
+		summaries = append(summaries, summary)
+
+		out.WriteString(fmt.Sprintf("- %s (%s)", name, summary.Entity))
+		if summary.Description != "" {
+			out.WriteString(fmt.Sprintf(": %s", summary.Description))
+		}
+		out.WriteString("\n")
+	}
+
+	listJSON, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: out.String(),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Presets:\n```json\n%s\n```", string(listJSON)),
+			},
+		},
+	}
+}
+
+// get renders one preset's stored reso_query arguments
+func (t *ResoPresetsTool) get(name string, preset *QueryPreset) MCPToolResult {
+	presetJSON, err := json.MarshalIndent(preset, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Preset: %s\n", name))
+	if preset.Description != "" {
+		out.WriteString(fmt.Sprintf("Description: %s\n", preset.Description))
+	}
+	for _, key := range sortedKeys(preset.Query) {
+		out.WriteString(fmt.Sprintf("- %s: %v\n", key, preset.Query[key]))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: out.String(),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Preset Definition:\n```json\n%s\n```", string(presetJSON)),
+			},
+		},
+	}
+}
+
+// run merges the overrides over the preset's arguments and executes them through
+// reso_query, so presets get the same validation, limits and formatting as a direct query
+func (t *ResoPresetsTool) run(name string, preset *QueryPreset, overrides map[string]interface{}) MCPToolResult {
+	merged := make(map[string]interface{}, len(preset.Query)+len(overrides))
+	for key, value := range preset.Query {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	definition := t.queryTool.GetToolDefinition()
+	if err := ValidateArguments(definition.InputSchema, merged, definition.SelfChecked...); err != nil {
+		return errorResult(fmt.Sprintf("Invalid overrides for preset %s: %s", name, err.Error()))
+	}
+
+	result := t.queryTool.Execute(merged)
+	header := fmt.Sprintf("Preset: %s", name)
+	if len(overrides) > 0 {
+		header += fmt.Sprintf(" (overriding %s)", strings.Join(sortedKeys(overrides), ", "))
+	}
+	if len(result.Content) > 0 {
+		result.Content[0].Text = header + "\n\n" + result.Content[0].Text
+	}
+	return result
+}
+
+// presetNames returns the preset names in alphabetical order
+func presetNames(presets map[string]*QueryPreset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
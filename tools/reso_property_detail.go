@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+// propertyDetailFields are the Property fields returned in a listing detail view
+const propertyDetailFields = "ListingKey,ListingId,StandardStatus,ListPrice,ClosePrice,CloseDate,UnparsedAddress,City,StateOrProvince,PostalCode,PropertyType,PropertySubType,BedroomsTotal,BathroomsTotalInteger,LivingArea,LotSizeSquareFeet,YearBuilt,PublicRemarks,ListAgentFullName,ListOfficeName,Latitude,Longitude,OnMarketDate,PhotosCount,VirtualTourURLUnbranded,ModificationTimestamp"
+
+// propertyDetailExpand pulls public media, upcoming open houses, days on market and
+// rooms alongside the listing in a single request
+const propertyDetailExpand = "Media($filter=Permission ne 'Private';$select=MediaKey,MediaURL,MediaCategory,MediaType,Order,ShortDescription,Permission;$orderby=Order asc)," +
+	"OpenHouse($filter=OpenHouseStartTime gt now();$select=OpenHouseKey,OpenHouseStartTime,OpenHouseEndTime,OpenHouseType,OpenHouseStatus,OpenHouseRemarks,VirtualURL;$orderby=OpenHouseStartTime asc)," +
+	"Dom($select=DaysOnMarket,CumulativeDaysOnMarket)," +
+	"PropertyRooms($select=RoomType,RoomLevel,RoomDimensions,RoomArea,RoomAreaUnits,RoomDescription)"
+
+// propertyDetailNavigation are the expanded properties moved out of the listing record
+var propertyDetailNavigation = []string{"Media", "OpenHouse", "Dom", "PropertyRooms"}
+
+// ResoPropertyDetailTool implements the reso_property_detail MCP tool, which
+// assembles a listing with its media, open houses, days on market and rooms
+type ResoPropertyDetailTool struct {
+	client *api.Client
+	config *config.Config
+}
+
+// PropertyDetail is the curated view of a single listing
+type PropertyDetail struct {
+	ListingKey string                   `json:"listing_key"`
+	Listing    map[string]interface{}   `json:"listing"`
+	MediaKind  string                   `json:"media_kind,omitempty"`
+	Media      []map[string]interface{} `json:"media"`
+	OpenHouses []map[string]interface{} `json:"open_houses"`
+	Dom        map[string]interface{}   `json:"dom,omitempty"`
+	Rooms      []map[string]interface{} `json:"rooms"`
+	Warnings   []string                 `json:"warnings,omitempty"`
+}
+
+// NewResoPropertyDetailTool creates a new RESO property detail tool
+func NewResoPropertyDetailTool(client *api.Client, cfg *config.Config) *ResoPropertyDetailTool {
+	return &ResoPropertyDetailTool{
+		client: client,
+		config: cfg,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoPropertyDetailTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_property_detail",
+		Description: "Get a complete view of one listing by ListingKey in a single call: key Property fields, public Media ordered for display (private media is always excluded), upcoming OpenHouse events, days on market (Dom) and PropertyRooms. Use this for listing detail pages instead of hand-building expand clauses.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"listing_key": map[string]interface{}{
+					"type":        "string",
+					"description": "ListingKey of the listing to fetch.",
+				},
+				"image_size": map[string]interface{}{
+					"type":        "string",
+					"description": imageSizeDescription,
+				},
+				"select": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated Property fields to return instead of the default detail set.",
+				},
+				"media_kind": map[string]interface{}{
+					"type":        "string",
+					"description": mediaKindDescription + " Default: all public media.",
+					"enum":        mediaKinds,
+				},
+			},
+			"required": []string{"listing_key"},
+		},
+	}
+}
+
+// Execute executes the RESO property detail tool
+func (t *ResoPropertyDetailTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	listingKey, _ := args["listing_key"].(string)
+	listingKey = strings.TrimSpace(listingKey)
+	if listingKey == "" {
+		return errorResult("Error parsing arguments: listing_key is required")
+	}
+
+	imageSize, err := parseImageSize(args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	kind, err := parseMediaKind(args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	selectFields := propertyDetailFields
+	if s, ok := args["select"].(string); ok && strings.TrimSpace(s) != "" {
+		selectFields = ensureSelected(strings.TrimSpace(s), "ListingKey")
+	}
+
+	expand := allowedExpand(t.client, "Property", propertyDetailExpand)
+	response, err := t.client.Query(api.QueryParams{
+		Entity: "Property",
+		Select: selectFields,
+		Filter: fmt.Sprintf("ListingKey eq %s", QuoteODataString(listingKey)),
+		Expand: expand,
+		Top:    1,
+	})
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error fetching listing: %s", err.Error()))
+	}
+	if len(response.Value) == 0 {
+		return errorResult(fmt.Sprintf("No listing found with ListingKey '%s'", listingKey))
+	}
+
+	detail := buildPropertyDetail(listingKey, response.Value[0], imageSize)
+	detail.Warnings = expandErrors(response.Value, expand)
+	detail.MediaKind = kind
+	detail.Media = filterMediaKind(detail.Media, kind)
+
+	detailJSON, err := json.MarshalIndent(detail, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: formatPropertyDetail(detail),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Property Detail:\n```json\n%s\n```", string(detailJSON)),
+			},
+		},
+	}
+}
+
+// buildPropertyDetail splits the expanded record into the curated detail view,
+// dropping any private media the server returned and sizing media URLs
+func buildPropertyDetail(listingKey string, record map[string]interface{}, imageSize string) *PropertyDetail {
+	if imageSize != "" {
+		record = sizeMediaRecord(record, imageSize)
+	}
+
+	detail := &PropertyDetail{
+		ListingKey: listingKey,
+		Listing:    make(map[string]interface{}, len(record)),
+		Media:      []map[string]interface{}{},
+		OpenHouses: navigationRecords(record["OpenHouse"]),
+		Rooms:      navigationRecords(record["PropertyRooms"]),
+	}
+
+	for field, value := range record {
+		detail.Listing[field] = value
+	}
+	for _, nav := range propertyDetailNavigation {
+		delete(detail.Listing, nav)
+	}
+
+	for _, media := range navigationRecords(record["Media"]) {
+		if permission, _ := media["Permission"].(string); strings.EqualFold(permission, "Private") {
+			continue
+		}
+		detail.Media = append(detail.Media, media)
+	}
+
+	if dom := navigationRecords(record["Dom"]); len(dom) > 0 {
+		detail.Dom = dom[0]
+	}
+
+	return detail
+}
+
+// navigationRecords converts an expanded collection into a slice of records
+func navigationRecords(value interface{}) []map[string]interface{} {
+	records := []map[string]interface{}{}
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		if record, ok := item.(map[string]interface{}); ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// formatPropertyDetail renders a short human-readable overview of the listing
+func formatPropertyDetail(detail *PropertyDetail) string {
+	var out strings.Builder
+	listing := detail.Listing
+
+	out.WriteString("RESO Property Detail\n")
+	out.WriteString("====================\n\n")
+
+	out.WriteString(fmt.Sprintf("Listing Key: %s\n", detail.ListingKey))
+	if address, ok := listing["UnparsedAddress"]; ok {
+		out.WriteString(fmt.Sprintf("Address: %v, %v, %v %v\n", valueOr(address, "-"), valueOr(listing["City"], "-"), valueOr(listing["StateOrProvince"], "-"), valueOr(listing["PostalCode"], "")))
+	}
+	if status, ok := listing["StandardStatus"]; ok {
+		out.WriteString(fmt.Sprintf("Status: %v\n", valueOr(status, "-")))
+	}
+	if price, ok := toFloat(listing["ListPrice"]); ok {
+		out.WriteString(fmt.Sprintf("List Price: $%.0f\n", price))
+	}
+	if _, ok := listing["BedroomsTotal"]; ok {
+		out.WriteString(fmt.Sprintf("Beds/Baths: %v / %v\n", valueOr(listing["BedroomsTotal"], "-"), valueOr(listing["BathroomsTotalInteger"], "-")))
+	}
+	if area, ok := toFloat(listing["LivingArea"]); ok {
+		out.WriteString(fmt.Sprintf("Living Area: %.0f sq ft\n", area))
+	}
+	if detail.Dom != nil {
+		out.WriteString(fmt.Sprintf("Days on Market: %v (cumulative %v)\n", valueOr(detail.Dom["DaysOnMarket"], "-"), valueOr(detail.Dom["CumulativeDaysOnMarket"], "-")))
+	}
+
+	switch {
+	case detail.MediaKind != "":
+		out.WriteString(fmt.Sprintf("\nPublic Media (%s): %d\n", detail.MediaKind, len(detail.Media)))
+	case len(detail.Media) > 0:
+		out.WriteString(fmt.Sprintf("\nPublic Media: %d (%s)\n", len(detail.Media), formatMediaKindCounts(detail.Media)))
+	default:
+		out.WriteString("\nPublic Media: 0\n")
+	}
+	out.WriteString(fmt.Sprintf("Rooms: %d\n", len(detail.Rooms)))
+	out.WriteString(fmt.Sprintf("Upcoming Open Houses: %d\n", len(detail.OpenHouses)))
+	for _, openHouse := range detail.OpenHouses {
+		out.WriteString(fmt.Sprintf("- %v to %v", valueOr(openHouse["OpenHouseStartTime"], "-"), valueOr(openHouse["OpenHouseEndTime"], "-")))
+		if openHouseType, ok := openHouse["OpenHouseType"].(string); ok && openHouseType != "" {
+			out.WriteString(fmt.Sprintf(" (%s)", openHouseType))
+		}
+		out.WriteString("\n")
+	}
+
+	if len(detail.Warnings) > 0 {
+		out.WriteString("\nWarnings:\n- " + strings.Join(detail.Warnings, "\n- ") + "\n")
+	}
+
+	return out.String()
+}
@@ -1,12 +1,15 @@
 package tools
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rennietech/constellation1-mcp-server/api"
 	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
 )
 
 // MCPTool represents an MCP tool
@@ -14,18 +17,41 @@ type MCPTool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// SelfChecked names arguments whose enum and bounds the tool enforces itself, with
+	// its own errors or corrections; argument validation only checks their type
+	SelfChecked []string `json:"-"`
 }
 
 // MCPToolResult represents the result of an MCP tool execution
 type MCPToolResult struct {
-	Content []MCPContent `json:"content"`
-	IsError bool         `json:"isError,omitempty"`
+	Content           []MCPContent `json:"content"`
+	StructuredContent interface{}  `json:"structuredContent,omitempty"`
+	IsError           bool         `json:"isError,omitempty"`
 }
 
 // MCPContent represents content in an MCP tool result
 type MCPContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string               `json:"type"`
+	Text     string               `json:"text,omitempty"`
+	Resource *MCPEmbeddedResource `json:"resource,omitempty"`
+}
+
+// MCPEmbeddedResource represents a resource embedded in a tool result
+type MCPEmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// errorResult builds a single-message error tool result
+func errorResult(message string) MCPToolResult {
+	return MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: message,
+		}},
+		IsError: true,
+	}
 }
 
 // ResoQueryTool implements the reso_query MCP tool for querying RESO standard real estate data
@@ -63,15 +89,22 @@ type MCPContent struct {
 // MediaCategory Values: Photo, Video, BrandedVideo, UnbrandedVideo, BrandedVirtualTour, UnbrandedVirtualTour, FloorPlan, Document
 // Permission Values: Public (MediaURL available), Private (MediaURL not available)
 type ResoQueryTool struct {
-	client *api.Client
-	config *config.Config
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
 }
 
 // NewResoQueryTool creates a new RESO query tool
 func NewResoQueryTool(client *api.Client, cfg *config.Config) *ResoQueryTool {
+	return NewResoQueryToolWithMetadata(client, cfg, nil)
+}
+
+// NewResoQueryToolWithMetadata creates a query tool that validates field names against parsed metadata
+func NewResoQueryToolWithMetadata(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoQueryTool {
 	return &ResoQueryTool{
-		client: client,
-		config: cfg,
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
 	}
 }
 
@@ -82,37 +115,51 @@ func (t *ResoQueryTool) GetToolDefinition() MCPTool {
 		Description: "Query the RESO (Real Estate Standards Organization) API for comprehensive real estate data. This tool provides access to MLS (Multiple Listing Service) data including property listings, agent information, office details, media files, and market analytics. Perfect for real estate research, market analysis, property searches, and lead generation. Supports advanced filtering, sorting, and field selection with standardized RESO field names for consistent data access across different MLS systems.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
-			"properties": map[string]interface{}{
+			"properties": withRangeProperties(map[string]interface{}{
 				"entity": map[string]interface{}{
 					"type":        "string",
-					"description": "RESO Entity to query. Choose based on your data needs:\n\n• **Property** - Primary real estate listings with comprehensive property details (address, price, features, status, agent info, etc.). Use for: searching homes, analyzing market data, getting listing details. Key fields: ListingKey, StandardStatus, ListPrice, PropertyType, PropertySubType, StreetNumber, City, StateOrProvince, PostalCode, BedroomsTotal, BathroomsTotal, LivingArea, YearBuilt, ListAgentFullName, PublicRemarks.\n\n• **Member** - MLS agents/members with contact information and credentials. Use for: finding agent details, contact information, professional designations. Key fields: MemberMlsId, MemberFullName, MemberEmail, MemberDirectPhone, OfficeKey, MemberDesignation.\n\n• **Office** - Real estate offices/brokerages. Use for: finding office information, brokerage details. Key fields: OfficeMlsId, OfficeName, OfficePhone, OfficeEmail, OfficeAddress1, OfficeCity.\n\n• **Media** - Photos, videos, virtual tours, and documents associated with listings. Use for: getting listing media, photos, virtual tours. Key fields: MediaKey, ResourceRecordKey (links to ListingKey), MediaType, MediaCategory, MediaURL, MediaStatus.\n\n• **OpenHouse** - Scheduled open house events. Use for: finding open houses, event scheduling. Key fields: OpenHouseKey, ListingKey, OpenHouseStartTime, OpenHouseEndTime, OpenHouseRemarks.\n\n• **Dom** - Days on Market tracking data. Use for: market timing analysis, DOM calculations. Key fields: ListingId, DaysOnMarket, CumulativeDaysOnMarket.\n\n• **PropertyUnitTypes** - Unit type details for multi-unit properties (apartments, condos). Use for: rental properties, multi-family analysis. Key fields: ListingKey, UnitTypeDescription, UnitTypeBedsTotal, UnitTypeBathsTotal, UnitTypeActualRent.\n\n• **PropertyRooms** - Detailed room-by-room information. Use for: detailed property layouts, room specifications. Key fields: ListingKey, RoomType, RoomDimensions, RoomFeatures, RoomLevel.\n\n• **RawMlsProperty** - Raw MLS data fields (original unprocessed data). Use for: accessing MLS-specific fields not in standardized Property entity.",
-					"enum": []string{
-						"Property", "Member", "Office", "Media", "OpenHouse",
-						"Dom", "PropertyUnitTypes", "PropertyRooms", "RawMlsProperty",
-					},
+					"description": "RESO Entity to query. Choose based on your data needs:\n\n• **Property** - Primary real estate listings with comprehensive property details (address, price, features, status, agent info, etc.). Use for: searching homes, analyzing market data, getting listing details. Key fields: ListingKey, StandardStatus, ListPrice, PropertyType, PropertySubType, StreetNumber, City, StateOrProvince, PostalCode, BedroomsTotal, BathroomsTotal, LivingArea, YearBuilt, ListAgentFullName, PublicRemarks.\n\n• **Member** - MLS agents/members with contact information and credentials. Use for: finding agent details, contact information, professional designations. Key fields: MemberMlsId, MemberFullName, MemberEmail, MemberDirectPhone, OfficeKey, MemberDesignation.\n\n• **Office** - Real estate offices/brokerages. Use for: finding office information, brokerage details. Key fields: OfficeMlsId, OfficeName, OfficePhone, OfficeEmail, OfficeAddress1, OfficeCity.\n\n• **Media** - Photos, videos, virtual tours, and documents associated with listings. Use for: getting listing media, photos, virtual tours. Key fields: MediaKey, ResourceRecordKey (links to ListingKey), MediaType, MediaCategory, MediaURL, MediaStatus.\n\n• **OpenHouse** - Scheduled open house events. Use for: finding open houses, event scheduling. Key fields: OpenHouseKey, ListingKey, OpenHouseStartTime, OpenHouseEndTime, OpenHouseRemarks.\n\n• **Dom** - Days on Market tracking data. Use for: market timing analysis, DOM calculations. Key fields: ListingId, DaysOnMarket, CumulativeDaysOnMarket.\n\n• **PropertyUnitTypes** - Unit type details for multi-unit properties (apartments, condos). Use for: rental properties, multi-family analysis. Key fields: ListingKey, UnitTypeDescription, UnitTypeBedsTotal, UnitTypeBathsTotal, UnitTypeActualRent.\n\n• **PropertyRooms** - Detailed room-by-room information. Use for: detailed property layouts, room specifications. Key fields: ListingKey, RoomType, RoomDimensions, RoomFeatures, RoomLevel.\n\n• **RawMlsProperty** - Raw MLS data fields (original unprocessed data). Use for: accessing MLS-specific fields not in standardized Property entity." + t.defaultEntityNote(),
+					"enum":        t.entityNames(),
 				},
 				"select": map[string]interface{}{
 					"type":        "string",
-					"description": "Comma-separated list of fields to return. Leave empty to get all available fields. For Property entity, common fields include:\n• **Identifiers**: ListingKey, ListingId, MlsStatus\n• **Address**: StreetNumber, StreetName, City, StateOrProvince, PostalCode, UnparsedAddress\n• **Pricing**: ListPrice, ClosePrice, OriginalListPrice, PreviousListPrice\n• **Property Details**: PropertyType, PropertySubType, BedroomsTotal, BathroomsTotal, LivingArea, YearBuilt, LotSizeSquareFeet\n• **Status & Dates**: StandardStatus, OnMarketTimestamp, ModificationTimestamp, DaysOnMarket\n• **Agent Info**: ListAgentFullName, ListAgentEmail, ListAgentDirectPhone, ListOfficeName\n• **Features**: PublicRemarks, Appliances, Heating, Cooling, ParkingFeatures, ExteriorFeatures\n• **Location**: Latitude, Longitude, MLSAreaMajor, MLSAreaMinor, SchoolDistrict\nExample: 'ListingKey,StandardStatus,ListPrice,BedroomsTotal,City,PublicRemarks'",
+					"description": "Comma-separated list of fields to return. Leave empty to get all available fields. For Property entity, common fields include:\n• **Identifiers**: ListingKey, ListingId, MlsStatus\n• **Address**: StreetNumber, StreetName, City, StateOrProvince, PostalCode, UnparsedAddress\n• **Pricing**: ListPrice, ClosePrice, OriginalListPrice, PreviousListPrice\n• **Property Details**: PropertyType, PropertySubType, BedroomsTotal, BathroomsTotal, LivingArea, YearBuilt, LotSizeSquareFeet\n• **Status & Dates**: StandardStatus, OnMarketTimestamp, ModificationTimestamp, DaysOnMarket\n• **Agent Info**: ListAgentFullName, ListAgentEmail, ListAgentDirectPhone, ListOfficeName\n• **Features**: PublicRemarks, Appliances, Heating, Cooling, ParkingFeatures, ExteriorFeatures\n• **Location**: Latitude, Longitude, MLSAreaMajor, MLSAreaMinor, SchoolDistrict\nExample: 'ListingKey,StandardStatus,ListPrice,BedroomsTotal,City,PublicRemarks'. Friendly aliases such as beds, price or zip are translated here and in filter and orderby (see reso_help('aliases')).",
 				},
 				"filter": map[string]interface{}{
 					"type":        "string",
-					"description": "OData filter expression for querying data. Supports comparison operators (eq, ne, gt, ge, lt, le), collection operators (has, in), and logical operators (and, or, not). Common Property filters:\n\n**Status Filters**:\n• Active listings: \"StandardStatus eq 'Active'\"\n• Recently sold: \"StandardStatus eq 'Closed' and CloseDate ge 2024-01-01\"\n• Under contract: \"StandardStatus eq 'Pending'\"\n\n**Price Filters**:\n• Price range: \"ListPrice ge 200000 and ListPrice le 500000\"\n• Luxury properties: \"ListPrice gt 1000000\"\n\n**Property Features**:\n• Bedrooms: \"BedroomsTotal ge 3\"\n• Bathrooms: \"BathroomsTotal ge 2\"\n• Square footage: \"LivingArea gt 2000\"\n• Year built: \"YearBuilt ge 2000\"\n\n**Location Filters**:\n• By city: \"City eq 'Seattle'\"\n• By state: \"StateOrProvince eq 'WA'\"\n• By zip: \"PostalCode eq '98101'\"\n• By area: \"MLSAreaMajor eq 'Downtown'\"\n\n**Property Type**:\n• Single family: \"PropertySubType eq 'SingleFamilyResidence'\"\n• Condos: \"PropertySubType eq 'Condominium'\"\n• Multi-family: \"PropertyType eq 'ResidentialIncome'\"\n\n**Complex Examples**:\n• \"StandardStatus eq 'Active' and PropertySubType eq 'Condominium' and ListPrice le 400000 and City eq 'Bellevue'\"\n• \"StandardStatus eq 'Closed' and CloseDate ge 2024-01-01 and PropertyType eq 'Residential'\"\n\nNote: Use single quotes for string values, proper date formats (YYYY-MM-DD), and combine with 'and'/'or' operators.",
+					"description": "OData filter expression for querying data. Supports comparison operators (eq, ne, gt, ge, lt, le), collection operators (has, in), and logical operators (and, or, not). Common Property filters:\n\n**Status Filters**:\n• Active listings: \"StandardStatus eq 'Active'\"\n• Recently sold: \"StandardStatus eq 'Closed' and CloseDate ge 2024-01-01\"\n• Under contract: \"StandardStatus eq 'Pending'\"\n\n**Price Filters**:\n• Price range: \"ListPrice ge 200000 and ListPrice le 500000\"\n• Luxury properties: \"ListPrice gt 1000000\"\n\n**Property Features**:\n• Bedrooms: \"BedroomsTotal ge 3\"\n• Bathrooms: \"BathroomsTotal ge 2\"\n• Square footage: \"LivingArea gt 2000\"\n• Year built: \"YearBuilt ge 2000\"\n\n**Location Filters**:\n• By city: \"City eq 'Seattle'\"\n• By state: \"StateOrProvince eq 'WA'\"\n• By zip: \"PostalCode eq '98101'\"\n• By area: \"MLSAreaMajor eq 'Downtown'\"\n\n**Property Type**:\n• Single family: \"PropertySubType eq 'SingleFamilyResidence'\"\n• Condos: \"PropertySubType eq 'Condominium'\"\n• Multi-family: \"PropertyType eq 'ResidentialIncome'\"\n\n**Complex Examples**:\n• \"StandardStatus eq 'Active' and PropertySubType eq 'Condominium' and ListPrice le 400000 and City eq 'Bellevue'\"\n• \"StandardStatus eq 'Closed' and CloseDate ge 2024-01-01 and PropertyType eq 'Residential'\"\n\nNote: Use single quotes for string values, proper date formats (YYYY-MM-DD), and combine with 'and'/'or' operators." + operatorNote(t.config.AllowedOperators),
 				},
-				"top": map[string]interface{}{
-					"type":        "integer",
-					"description": "Maximum number of records to return in this request. Use smaller values (10-50) for quick searches, larger values (100-1000) for comprehensive data analysis. Default: 10, Maximum: 1000. For large datasets, use pagination with 'skip' parameter.",
-					"minimum":     1,
-					"maximum":     1000,
+				"filters": map[string]interface{}{
+					"type":        "array",
+					"description": "Structured alternative to 'filter' that is compiled into a correctly quoted OData expression. Each item is a condition {field, op, value}, a nested group {logic, filters} or a negation {not: <condition or group>}; \"not\": true on a condition or group negates it too. Operators: eq, ne, gt, ge, lt, le, has, in (array value; on enum fields such as StandardStatus every value must be a valid enum member), contains, startswith, endswith. Strings are quoted, numbers and booleans are not, and YYYY-MM-DD dates or ISO timestamps are emitted as date literals. Ignored when 'filter' is also given.\n\nExample: [{\"field\": \"StandardStatus\", \"op\": \"eq\", \"value\": \"Active\"}, {\"logic\": \"or\", \"filters\": [{\"field\": \"City\", \"op\": \"eq\", \"value\": \"Seattle\"}, {\"field\": \"City\", \"op\": \"eq\", \"value\": \"Bellevue\"}]}]\n\nSeveral statuses: [{\"field\": \"StandardStatus\", \"op\": \"in\", \"value\": [\"Active\", \"Pending\"]}]\n\nExcluding types: [{\"not\": {\"field\": \"PropertySubType\", \"op\": \"eq\", \"value\": \"Condominium\"}}, {\"not\": {\"field\": \"PropertySubType\", \"op\": \"eq\", \"value\": \"Townhouse\"}}]",
+					"items": map[string]interface{}{
+						"type": "object",
+					},
 				},
+				"logic": map[string]interface{}{
+					"type":        "string",
+					"description": "How top-level 'filters' entries are combined. Default: and.",
+					"enum":        []string{"and", "or"},
+					"default":     "and",
+				},
+				"not": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Negate the whole compiled 'filters' expression, emitted as not (...). Default: false.",
+					"default":     false,
+				},
+				"search": map[string]interface{}{
+					"type":        "string",
+					"description": "Free-text search across the entity's text fields (e.g. PublicRemarks), sent as OData $search and combined with any filter. Words are matched individually; use \"double quotes\" for an exact phrase and AND, OR, NOT between terms. Availability depends on the provider: MLSs without $search support reject the query, in which case use a filter such as contains(PublicRemarks,'pool') instead.\n\nExample: 'waterfront \"open floor plan\" NOT condo'",
+				},
+				"top": t.topSchema(),
 				"skip": map[string]interface{}{
 					"type":        "integer",
-					"description": "Number of records to skip for pagination. Used with 'top' to implement paging through large result sets. Skip limits vary by entity: Property (1M), Office/Member (500K), Media/Rooms (50K). Example: skip=0&top=100 for first page, skip=100&top=100 for second page.",
+					"description": "Number of records to skip for pagination. Used with 'top' to implement paging through large result sets. Skip limits vary by entity: Property (1M), Office/Member (500K), Media/Rooms (50K). Example: skip=0&top=100 for first page, skip=100&top=100 for second page. Requests past the limit are rejected with a suggested filter cursor (e.g. 'ModificationTimestamp gt ...') for paging deeper.",
 					"minimum":     0,
 				},
 				"orderby": map[string]interface{}{
 					"type":        "string",
-					"description": "Sort order for results. Format: 'FieldName [asc|desc]'. Multiple fields supported with comma separation. Common patterns:\n• **Price sorting**: 'ListPrice desc' (high to low), 'ListPrice asc' (low to high)\n• **Date sorting**: 'ModificationTimestamp desc' (newest first), 'OnMarketTimestamp desc'\n• **Location sorting**: 'City asc, ListPrice desc'\n• **Size sorting**: 'LivingArea desc, BedroomsTotal desc'\nDefault direction is ascending if not specified. Examples: 'ListPrice desc', 'City asc, ModificationTimestamp desc'",
+					"description": "Sort order for results. Format: 'FieldName [asc|desc]'. Multiple fields supported with comma separation. Common patterns:\n• **Price sorting**: 'ListPrice desc' (high to low), 'ListPrice asc' (low to high)\n• **Date sorting**: 'ModificationTimestamp desc' (newest first), 'OnMarketTimestamp desc'\n• **Location sorting**: 'City asc, ListPrice desc'\n• **Size sorting**: 'LivingArea desc, BedroomsTotal desc'\nDefault direction is ascending if not specified. When omitted, results are sorted by 'ModificationTimestamp desc' and the entity key so pages do not overlap. Examples: 'ListPrice desc', 'City asc, ModificationTimestamp desc'",
 				},
 				"expand": map[string]interface{}{
 					"type":        "string",
@@ -128,9 +175,106 @@ func (t *ResoQueryTool) GetToolDefinition() MCPTool {
 					"description": "Enable case-insensitive text matching for string comparisons in filters. Useful when searching for cities, agent names, or other text fields where case might vary. Example: with ignorecase=true, \"City eq 'seattle'\" will match 'Seattle', 'SEATTLE', etc. Default: false.",
 					"default":     false,
 				},
-			},
-			"required": []string{"entity"},
+				"near": map[string]interface{}{
+					"type":        "object",
+					"description": "Radius search around a point. Computes a Latitude/Longitude bounding box and appends it to the filter, then (unless exact=false) drops records outside the true great-circle radius. Distances are reported in the summary. Only works on entities that expose Latitude/Longitude (Property).\n\nExample: {\"lat\": 47.6062, \"lon\": -122.3321, \"radius_miles\": 2}",
+					"properties": map[string]interface{}{
+						"lat": map[string]interface{}{
+							"type":        "number",
+							"description": "Latitude of the search center.",
+						},
+						"lon": map[string]interface{}{
+							"type":        "number",
+							"description": "Longitude of the search center.",
+						},
+						"radius_miles": map[string]interface{}{
+							"type":        "number",
+							"description": "Search radius in miles.",
+						},
+						"exact": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Drop records in the bounding box corners that fall outside the true radius. Default: true.",
+							"default":     true,
+						},
+					},
+					"required": []string{"lat", "lon", "radius_miles"},
+				},
+				"image_size": map[string]interface{}{
+					"type":        "string",
+					"description": imageSizeDescription + " Applies to MediaURL fields in the results, whether top-level (Media entity) or inside expanded Media.",
+				},
+				"keys": map[string]interface{}{
+					"type":        "array",
+					"description": fmt.Sprintf("Fetch the records whose key_field matches any of these values (up to %d), e.g. ListingKeys from a saved search. Compiled to an OData 'in (...)' filter and combined with any filter given; long lists are split into several requests and merged in the order given. Every matching record is returned regardless of top, and keys with no match are listed in the summary.", maxLookupKeys),
+					"items": map[string]interface{}{
+						"type": []string{"string", "number"},
+					},
+				},
+				"explain": map[string]interface{}{
+					"type":        "string",
+					"description": "Describe the query in plain English: the entity, what the filter means (e.g. 'status is Active, city is Seattle and list price is between $200,000 and $500,000'), the sort, the expansions and the limits. 'only' returns the explanation without running the query; 'with_results' runs it and adds the explanation as an extra content block.",
+					"enum":        explainModes,
+				},
+				"flatten": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Flatten expanded entities into table-friendly rows. Fields of a single-valued expand are hoisted into the parent with the navigation property as prefix (e.g. Office_OfficeName). One expanded collection, such as Media, is joined: each child becomes its own row repeating the parent fields (Media_MediaURL, ...), and parents without children keep one row. Requires expand; fails if more than one expanded collection is returned. Default: false (nested).",
+					"default":     false,
+				},
+				"key_field": map[string]interface{}{
+					"type":        "string",
+					"description": "Field matched by 'keys'. Default: ListingKey. Use e.g. 'ListingId', 'MemberKey' or 'ResourceRecordKey' (Media) as appropriate for the entity.",
+				},
+				"delta_link": map[string]interface{}{
+					"type":        "string",
+					"description": "Resume change tracking from an @odata.deltaLink returned by an earlier query (reported as 'Delta Link Available' and in the pagination block). Returns only the records added, changed or removed since then; removed records carry '@removed'. The link carries the original query, so select, filter, top, skip, orderby, expand, keys and near cannot be combined with it. Only providers that support delta queries return delta links.",
+				},
+				"fetch_all": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Automatically follow @odata.nextLink and concatenate every page of results into a single response. Use for large result sweeps that would otherwise exceed the entity skip limits. Combine with 'max_records' to cap the total. Default: false.",
+					"default":     false,
+				},
+				"max_records": map[string]interface{}{
+					"type":        "integer",
+					"description": "Safety cap on the total number of records collected when fetch_all is true. Default: 5000.",
+					"minimum":     1,
+				},
+				"check_types": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Check each Property, Member, Office or Media record (and Media expanded on a Property) against the typed structs of the api package, and warn about values of the wrong type, e.g. a price sent as a string. Default: false.",
+					"default":     false,
+				},
+				"structured_output": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return the full response as structured JSON (an embedded application/json resource plus structuredContent) instead of a fenced JSON text block, so agents can consume records directly without re-parsing. Default: false.",
+					"default":     false,
+				},
+				"auto_select": map[string]interface{}{
+					"type":        "boolean",
+					"description": fmt.Sprintf("When select is empty, request only the entity's common fields (listing key, status, price, address, size, agent, ...) instead of every field, and list them in the summary. Set to false to get the full field set. Ignored when select or apply is given. Default: %t.", t.config.AutoSelect),
+					"default":     t.config.AutoSelect,
+				},
+				"humanize": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Make the summary easier to read: show the first records as a table of key fields (address, status, price, beds, baths, area, ...) with prices as currency ($1,250,000) and areas in square feet (2,340 sq ft), plus the range of each price and area field. The JSON response is unchanged. Default: false.",
+					"default":     false,
+				},
+				"humanize_rows": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Number of records in the humanize table. Default: %d.", defaultHumanizeRows),
+					"minimum":     1,
+					"maximum":     maxHumanizeRows,
+				},
+				"skip_validation": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Skip the pre-flight check of field names in select, filter and orderby against the entity metadata. Use for RawMlsProperty or other entities whose metadata may not list every field. Default: false.",
+					"default":     false,
+				},
+			}),
+			"required": t.requiredArguments(),
 		},
+		// The policy error names the allowed entities, and top above the maximum is
+		// clamped with a note rather than rejected
+		SelfChecked: []string{"entity", "top"},
 	}
 }
 
@@ -147,6 +291,11 @@ func (t *ResoQueryTool) Execute(args map[string]interface{}) MCPToolResult {
 		}
 	}
 
+	// Fall back to the configured default entity, then translate friendly field
+	// aliases (beds, price, zip) to RESO field names
+	args = t.withDefaultEntity(args)
+	args, aliased := applyFieldAliases(args, mergeFieldAliases(t.config.FieldAliases), t.metadataParser)
+
 	// Parse arguments
 	params, err := t.parseArguments(args)
 	if err != nil {
@@ -159,17 +308,284 @@ func (t *ResoQueryTool) Execute(args map[string]interface{}) MCPToolResult {
 		}
 	}
 
-	// Execute query
-	response, err := t.client.Query(*params)
+	// Refuse entities excluded by the allowed_entities policy before any other work
+	if err := checkEntityPolicy(t.client, t.metadataParser, params.Entity, params.Expand); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+	if err := checkFilterOperators(params.Filter, params.Expand, t.config.AllowedOperators); err != nil {
+		return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+	}
+
+	// Escape apostrophes and control characters in string literals (City eq 'O'Brien'),
+	// rewrite YN-style booleans (PoolPrivateYN eq 'Y'), then quote bare string values
+	// (City eq Seattle) before validation sees them as fields
+	var autoCorrected []string
+	if params.Filter != "" {
+		var escaped, booleans, quoted []string
+		params.Filter, escaped = repairFilterLiterals(params.Filter)
+		params.Filter, booleans = normalizeBooleanFilter(params.Filter, t.booleanField(params.Entity))
+		params.Filter, quoted = autoQuoteFilter(params.Filter, t.bareStringQuotable(params.Entity))
+		autoCorrected = append(append(escaped, booleans...), quoted...)
+	}
+
+	// Optional: request the entity's common fields instead of every field
+	autoSelected := t.autoSelect(params, args)
+
+	// The filter as given, before near adds its bounding box
+	userFilter := params.Filter
+
+	// Optional: radius search around a point
+	near, err := parseNear(args)
 	if err != nil {
 		return MCPToolResult{
 			Content: []MCPContent{{
 				Type: "text",
-				Text: fmt.Sprintf("Error executing query: %s", err.Error()),
+				Text: fmt.Sprintf("Error parsing arguments: %s", err.Error()),
 			}},
 			IsError: true,
 		}
 	}
+	if near != nil {
+		if !t.supportsCoordinates(params.Entity) {
+			return MCPToolResult{
+				Content: []MCPContent{{
+					Type: "text",
+					Text: fmt.Sprintf("Error parsing arguments: near is only supported on entities that expose Latitude/Longitude (e.g. Property), not %s", params.Entity),
+				}},
+				IsError: true,
+			}
+		}
+		params.Filter = combineFilters(params.Filter, near.BoundingBoxFilter())
+		params.Select = ensureSelected(params.Select, "Latitude", "Longitude")
+	}
+
+	// Optional: resize MediaURL values in the results
+	imageSize, err := parseImageSize(args)
+	if err != nil {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error parsing arguments: %s", err.Error()),
+			}},
+			IsError: true,
+		}
+	}
+
+	// Optional: look up records by a list of keys
+	lookup, err := parseKeys(args)
+	if err != nil {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error parsing arguments: %s", err.Error()),
+			}},
+			IsError: true,
+		}
+	}
+
+	// Optional: plain-English description of the query, with or without running it
+	explain, _ := args["explain"].(string)
+	if explain != "" && explain != "only" && explain != "with_results" {
+		return errorResult(fmt.Sprintf("Error parsing arguments: explain must be one of %s, got %q", strings.Join(explainModes, ", "), explain))
+	}
+
+	// Optional: one flat row per expanded child instead of nested collections
+	flatten, _ := args["flatten"].(bool)
+	if flatten && params.Expand == "" {
+		return errorResult("Error parsing arguments: flatten requires expand")
+	}
+
+	// Sort by the entity key when no orderby is given, so pages do not overlap
+	autoOrdered := t.autoOrderBy(params, lookup == nil && params.DeltaLink == "")
+
+	// Optional: readable summary table of the first records
+	humanizeRows, err := parseHumanize(args)
+	if err != nil {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error parsing arguments: %s", err.Error()),
+			}},
+			IsError: true,
+		}
+	}
+
+	// Apply the configured default and maximum top; fetch_all and key lookups page
+	// through every result, so they keep the server's default page size
+	topNote := t.applyTopLimits(params, !params.FetchAll && lookup == nil && params.DeltaLink == "")
+
+	// Cap expanded collections that set no $top of their own
+	var expandCapped []string
+	params.Expand, expandCapped, err = capExpandTop(params.Expand, params.Entity, t.config.ExpandTop, t.metadataParser)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	// Validate field names against metadata before making any API calls
+	if skipValidation, _ := args["skip_validation"].(bool); !skipValidation {
+		validationParams := *params
+		if lookup != nil {
+			validationParams.Filter = combineFilters(params.Filter, lookup.inFilter(lookup.Literals[:1]))
+		}
+		if err := t.validateFields(&validationParams); err != nil {
+			return MCPToolResult{
+				Content: []MCPContent{{
+					Type: "text",
+					Text: fmt.Sprintf("Validation error: %s", err.Error()),
+				}},
+				IsError: true,
+			}
+		}
+	}
+
+	// Collect enum value warnings (reported in the summary, not as errors)
+	enumWarnings := t.checkEnumValues(params)
+
+	var explanation string
+	if explain != "" {
+		explanation = explainQuery(params, userFilter, near, lookup, t.metadataParser)
+	}
+	if explain == "only" {
+		return t.explainOnly(params, explanation, enumWarnings)
+	}
+
+	// Execute query, one request per key chunk for key lookups
+	var response *api.APIResponse
+	if lookup != nil {
+		response, err = t.queryKeys(*params, lookup)
+	} else {
+		response, err = t.client.Query(*params)
+	}
+	if err != nil {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error executing query: %s%s", err.Error(), nestedExpandHint(params.Expand, err)),
+			}},
+			IsError: true,
+		}
+	}
+
+	// Paging is computed from the server page, before any client-side filtering
+	pagination := buildPagination(response)
+
+	// Providers may answer 200 with errors embedded in expanded collections
+	expandWarnings := expandErrors(response.Value, params.Expand)
+
+	// Optional: records whose values do not fit the typed structs of the api package
+	if checkTypes, _ := args["check_types"].(bool); checkTypes {
+		expandWarnings = append(expandWarnings, typeWarnings(params.Entity, response.Value)...)
+	}
+
+	// Drop bounding-box corner cases and measure distances
+	var distances []recordDistance
+	if near != nil {
+		response.Value, distances = near.Apply(response.Value)
+		response.Count = len(response.Value)
+	}
+
+	// Size media URLs on copies so cached records keep the original URLs
+	response.Value = sizeMediaURLs(response.Value, imageSize)
+
+	// Join expanded entities into flat rows on copies, for the same reason
+	var flattened *flattenResult
+	if flatten {
+		response.Value, flattened, err = flattenExpanded(response.Value, params.Expand)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Error flattening results: %s", err.Error()))
+		}
+	}
+
+	// Keep the payload within the configured size so it fits an LLM context
+	truncation := truncateResponse(response, t.config.MaxResponseBytes)
+	if truncation != nil {
+		pagination.applyTruncation(truncation.Kept, near == nil && lookup == nil && flattened == nil && !params.FetchAll && params.DeltaLink == "")
+	}
+
+	// Create summary
+	summary := t.createSummary(response, humanizeRows)
+	if near != nil {
+		summary += formatDistances(near, distances)
+	}
+	if lookup != nil {
+		summary += formatKeyLookup(lookup, response.Value)
+	}
+	if flattened != nil {
+		summary += flattened.format()
+	}
+	if topNote != "" {
+		summary += "\nNote: " + topNote + "\n"
+	}
+	if autoOrdered {
+		summary += fmt.Sprintf("\nDefault Order: no orderby was given, so results are sorted by %s to keep paging stable. Pass an explicit orderby to sort differently.\n", params.OrderBy)
+	}
+	if len(autoSelected) > 0 {
+		summary += fmt.Sprintf("\nAuto Select: no select was given, so only the %d common %s fields were requested: %s. Pass auto_select=false (or an explicit select) for every field.\n",
+			len(autoSelected), params.Entity, strings.Join(autoSelected, ", "))
+	}
+	if truncation != nil {
+		summary += truncation.format(pagination.NextSkip)
+	}
+	if len(expandCapped) > 0 {
+		summary += fmt.Sprintf("\nExpand Cap: added $top=%d to %s, which set no $top. Give a $top inside the expand to return more.\n", t.config.ExpandTop, strings.Join(expandCapped, ", "))
+	}
+	if len(aliased) > 0 {
+		summary += fmt.Sprintf("\nField Aliases: translated %s. See reso_help('aliases') for the full list.\n", strings.Join(aliased, ", "))
+	}
+	if len(autoCorrected) > 0 {
+		summary += fmt.Sprintf("\nFilter Auto-Corrected: sent %s. Quote string values with single quotes, double apostrophes inside them ('O''Brien') and compare boolean fields with true or false to avoid this.\n", strings.Join(autoCorrected, ", "))
+	}
+	if warnings := append(enumWarnings, expandWarnings...); len(warnings) > 0 {
+		summary += "\nWarnings:\n- " + strings.Join(warnings, "\n- ") + "\n"
+	}
+
+	paginationJSON, err := json.MarshalIndent(pagination, "", "  ")
+	if err != nil {
+		return MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error formatting response: %s", err.Error()),
+			}},
+			IsError: true,
+		}
+	}
+	paginationContent := MCPContent{
+		Type: "text",
+		Text: fmt.Sprintf("Pagination:\n```json\n%s\n```", string(paginationJSON)),
+	}
+
+	// Return the parsed response as structured JSON when requested
+	if structured, _ := args["structured_output"].(bool); structured {
+		compactJSON, err := json.Marshal(response)
+		if err != nil {
+			return MCPToolResult{
+				Content: []MCPContent{{
+					Type: "text",
+					Text: fmt.Sprintf("Error formatting response: %s", err.Error()),
+				}},
+				IsError: true,
+			}
+		}
+
+		return withExplanation(MCPToolResult{
+			Content: []MCPContent{
+				{
+					Type: "text",
+					Text: summary,
+				},
+				paginationContent,
+				{
+					Type: "resource",
+					Resource: &MCPEmbeddedResource{
+						URI:      fmt.Sprintf("reso://query/%s", response.RequestParams.Entity),
+						MimeType: "application/json",
+						Text:     string(compactJSON),
+					},
+				},
+			},
+			StructuredContent: response,
+		}, explanation)
+	}
 
 	// Format response
 	responseJSON, err := response.ToJSON()
@@ -183,21 +599,114 @@ func (t *ResoQueryTool) Execute(args map[string]interface{}) MCPToolResult {
 		}
 	}
 
-	// Create summary
-	summary := t.createSummary(response)
-
-	return MCPToolResult{
+	return withExplanation(MCPToolResult{
 		Content: []MCPContent{
 			{
 				Type: "text",
 				Text: summary,
 			},
+			paginationContent,
 			{
 				Type: "text",
 				Text: fmt.Sprintf("Full Response:\n```json\n%s\n```", responseJSON),
 			},
 		},
+	}, explanation)
+}
+
+// explainOnly returns the query explanation and the parameters that would be sent,
+// without running the query
+func (t *ResoQueryTool) explainOnly(params *api.QueryParams, explanation string, warnings []string) MCPToolResult {
+	text := explanation + "\nThe query was not run. Call reso_query again without explain=only (or with explain=with_results) to get the records.\n"
+	if len(warnings) > 0 {
+		text += "\nWarnings:\n- " + strings.Join(warnings, "\n- ") + "\n"
+	}
+
+	paramsJSON, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
 	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Query Parameters:\n```json\n%s\n```", string(paramsJSON)),
+			},
+		},
+	}
+}
+
+// withExplanation adds the query explanation as its own content block after the
+// summary, leaving the result unchanged when there is none
+func withExplanation(result MCPToolResult, explanation string) MCPToolResult {
+	if explanation == "" {
+		return result
+	}
+	block := MCPContent{Type: "text", Text: explanation}
+	result.Content = append(result.Content[:1], append([]MCPContent{block}, result.Content[1:]...)...)
+	return result
+}
+
+// topSchema describes the top argument, declaring a maximum only when max_top caps it
+func (t *ResoQueryTool) topSchema() map[string]interface{} {
+	limit := "no maximum"
+	if t.config.MaxTop > 0 {
+		limit = fmt.Sprintf("Maximum: %d (larger values are clamped)", t.config.MaxTop)
+	}
+	schema := map[string]interface{}{
+		"type":        "integer",
+		"description": fmt.Sprintf("Maximum number of records to return in this request. Use smaller values (10-50) for quick searches, larger values (100-1000) for comprehensive data analysis. Default: %d, %s. For large datasets, use pagination with 'skip' parameter.", t.config.DefaultTop, limit),
+		"minimum":     1,
+	}
+	if t.config.MaxTop > 0 {
+		schema["maximum"] = t.config.MaxTop
+	}
+	return schema
+}
+
+// requiredArguments lists the required arguments; entity is optional once a default
+// entity is configured
+func (t *ResoQueryTool) requiredArguments() []string {
+	if t.config.DefaultEntity != "" {
+		return []string{}
+	}
+	return []string{"entity"}
+}
+
+// defaultEntityNote tells the model which entity an omitted entity falls back to
+func (t *ResoQueryTool) defaultEntityNote() string {
+	if t.config.DefaultEntity == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nDefault: %s. This server queries %s when entity is omitted.", t.config.DefaultEntity, t.config.DefaultEntity)
+}
+
+// withDefaultEntity returns args with the configured default entity filled in when
+// entity is omitted or empty, leaving args unchanged otherwise
+func (t *ResoQueryTool) withDefaultEntity(args map[string]interface{}) map[string]interface{} {
+	if entity, _ := args["entity"].(string); strings.TrimSpace(entity) != "" || t.config.DefaultEntity == "" {
+		return args
+	}
+	defaulted := make(map[string]interface{}, len(args)+1)
+	for key, value := range args {
+		defaulted[key] = value
+	}
+	defaulted["entity"] = t.config.DefaultEntity
+	return defaulted
+}
+
+// entityNames lists the entities advertised in the schema, honoring the
+// allowed_entities policy
+func (t *ResoQueryTool) entityNames() []string {
+	if t.client == nil {
+		return api.GetSupportedEntityNames()
+	}
+	return t.client.AllowedEntities()
 }
 
 // parseArguments parses the tool arguments into QueryParams
@@ -206,7 +715,8 @@ func (t *ResoQueryTool) parseArguments(args map[string]interface{}) (*api.QueryP
 		IgnoreNulls: true, // Default to true
 	}
 
-	// Required: entity
+	// Required: entity, unless a default entity is configured
+	args = t.withDefaultEntity(args)
 	if entity, ok := args["entity"].(string); ok {
 		params.Entity = entity
 	} else {
@@ -223,6 +733,41 @@ func (t *ResoQueryTool) parseArguments(args map[string]interface{}) (*api.QueryP
 		params.Filter = strings.TrimSpace(filter)
 	}
 
+	// Optional: structured filters (the raw filter wins when both are given)
+	if filters, ok := args["filters"].([]interface{}); ok && params.Filter == "" {
+		logic, _ := args["logic"].(string)
+		compiled, err := buildFilter(filters, logic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filters: %w", err)
+		}
+		if skipValidation, _ := args["skip_validation"].(bool); !skipValidation {
+			if err := t.checkInEnums(params.Entity, filters); err != nil {
+				return nil, fmt.Errorf("invalid filters: %w", err)
+			}
+		}
+		if negate, _ := args["not"].(bool); negate {
+			compiled = negateFilter(compiled)
+		}
+		params.Filter = compiled
+	}
+
+	// Optional: price, bedroom, size and similar range shorthands, ANDed with the filter
+	ranges, err := buildRangeFilter(args)
+	if err != nil {
+		return nil, err
+	}
+	if ranges != "" {
+		if params.Entity != "Property" {
+			return nil, fmt.Errorf("range arguments such as price_min only apply to Property, not %s; use filter instead", params.Entity)
+		}
+		params.Filter = combineFilters(params.Filter, ranges)
+	}
+
+	// Optional: free-text search
+	if search, ok := args["search"].(string); ok {
+		params.Search = strings.TrimSpace(stripControlChars(search))
+	}
+
 	// Optional: top
 	if top, ok := args["top"]; ok {
 		switch v := top.(type) {
@@ -255,10 +800,29 @@ func (t *ResoQueryTool) parseArguments(args map[string]interface{}) (*api.QueryP
 	if orderby, ok := args["orderby"].(string); ok {
 		params.OrderBy = strings.TrimSpace(orderby)
 	}
+	if params.OrderBy != "" {
+		fields, err := parseOrderBy(params.OrderBy)
+		if err != nil {
+			return nil, err
+		}
+		if skipValidation, _ := args["skip_validation"].(bool); !skipValidation {
+			if err := t.validateOrderByFields(params.Entity, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	// Optional: expand
 	if expand, ok := args["expand"].(string); ok {
 		params.Expand = strings.TrimSpace(expand)
+		if params.Expand != "" {
+			if _, err := parseExpand(params.Expand); err != nil {
+				return nil, err
+			}
+			if err := checkExpandDepth(params.Expand, t.config.MaxExpandDepth); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Optional: ignorenulls
@@ -271,11 +835,384 @@ func (t *ResoQueryTool) parseArguments(args map[string]interface{}) (*api.QueryP
 		params.IgnoreCase = ignorecase
 	}
 
+	// Optional: fetch_all
+	if fetchAll, ok := args["fetch_all"].(bool); ok {
+		params.FetchAll = fetchAll
+	}
+
+	// Optional: max_records
+	if maxRecords, ok := args["max_records"]; ok {
+		switch v := maxRecords.(type) {
+		case float64:
+			params.MaxRecords = int(v)
+		case int:
+			params.MaxRecords = v
+		case string:
+			if maxInt, err := strconv.Atoi(v); err == nil {
+				params.MaxRecords = maxInt
+			}
+		}
+	}
+
+	// Optional: delta_link, which replaces the query options with those it carries
+	if deltaLink, ok := args["delta_link"].(string); ok && strings.TrimSpace(deltaLink) != "" {
+		params.DeltaLink = strings.TrimSpace(deltaLink)
+		var conflicts []string
+		for _, name := range []string{"select", "filter", "filters", "search", "top", "skip", "orderby", "expand", "keys", "near"} {
+			if _, given := args[name]; given {
+				conflicts = append(conflicts, name)
+			}
+		}
+		if ranges != "" {
+			conflicts = append(conflicts, "range arguments")
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("delta_link already carries the query, so it cannot be combined with %s", strings.Join(conflicts, ", "))
+		}
+	}
+
 	return params, nil
 }
 
-// createSummary creates a human-readable summary of the response
-func (t *ResoQueryTool) createSummary(response *api.APIResponse) string {
+// applyTopLimits sets the configured default top when none was requested (if
+// useDefault) and clamps top to the configured maximum, describing any clamp
+func (t *ResoQueryTool) applyTopLimits(params *api.QueryParams, useDefault bool) string {
+	if params.Top <= 0 && useDefault && t.config.DefaultTop > 0 {
+		params.Top = t.config.DefaultTop
+	}
+	if maxTop := t.config.MaxTop; maxTop > 0 && params.Top > maxTop {
+		requested := params.Top
+		params.Top = maxTop
+		return fmt.Sprintf("top %d exceeds the maximum of %d and was clamped to %d; use skip or fetch_all to page through more records", requested, maxTop, maxTop)
+	}
+	return ""
+}
+
+// autoSelect sets the entity's common fields as the select list when the query gives
+// none and auto_select is on, returning the fields applied. The auto_select argument
+// overrides the configured default.
+func (t *ResoQueryTool) autoSelect(params *api.QueryParams, args map[string]interface{}) []string {
+	enabled := t.config.AutoSelect
+	if requested, ok := args["auto_select"].(bool); ok {
+		enabled = requested
+	}
+	if !enabled || params.Select != "" || params.Apply != "" || params.DeltaLink != "" || t.metadataParser == nil {
+		return nil
+	}
+
+	fields := t.metadataParser.GetCommonFields(params.Entity)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// Keep the entity key so records can be fetched again in full
+	selectList := strings.Join(fields, ",")
+	if entity, ok := t.metadataParser.Entities[params.Entity]; ok {
+		selectList = ensureSelected(selectList, entity.KeyFields...)
+	}
+	params.Select = selectList
+	return splitFieldList(selectList)
+}
+
+// autoOrderBy sorts a query without orderby by ModificationTimestamp (when the entity
+// has it) and then its key fields, since the server's own order may change between
+// page requests. It needs the key from the metadata, and is skipped for $apply and
+// $search queries, key lookups and when disable_auto_orderby is set. It reports whether
+// the default was applied.
+func (t *ResoQueryTool) autoOrderBy(params *api.QueryParams, enabled bool) bool {
+	if !enabled || t.config.DisableAutoOrder || params.OrderBy != "" || params.Apply != "" || params.Search != "" || t.metadataParser == nil {
+		return false
+	}
+	entity, ok := t.metadataParser.GetEntityInfo(params.Entity)
+	if !ok || len(entity.KeyFields) == 0 {
+		return false
+	}
+
+	var clauses []string
+	if t.metadataParser.HasField(params.Entity, syncCursorField) {
+		clauses = append(clauses, syncCursorField+" desc")
+	}
+	for _, key := range entity.KeyFields {
+		clauses = append(clauses, key+" asc")
+	}
+	params.OrderBy = strings.Join(clauses, ", ")
+	return true
+}
+
+// supportsCoordinates reports whether an entity exposes Latitude/Longitude for radius searches
+func (t *ResoQueryTool) supportsCoordinates(entity string) bool {
+	if t.metadataParser != nil {
+		if _, exists := t.metadataParser.GetEntityInfo(entity); exists {
+			return t.metadataParser.HasField(entity, "Latitude") && t.metadataParser.HasField(entity, "Longitude")
+		}
+	}
+	return entity == "Property"
+}
+
+// validateOrderByFields confirms each orderby field exists on the entity when metadata is loaded
+func (t *ResoQueryTool) validateOrderByFields(entity string, fields []string) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	if _, exists := t.metadataParser.GetEntityInfo(entity); !exists {
+		return nil
+	}
+
+	for _, field := range fields {
+		root := strings.SplitN(field, "/", 2)[0]
+		if t.metadataParser.HasField(entity, root) || t.metadataParser.HasNavigationProperty(entity, root) {
+			continue
+		}
+
+		hint := ""
+		if suggestions := t.metadataParser.SuggestFields(entity, root, 3); len(suggestions) > 0 {
+			hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+		}
+		return fmt.Errorf("invalid orderby clause: %s is not a field of %s%s", field, entity, hint)
+	}
+	return nil
+}
+
+// validateFields checks select, filter and orderby field names against the entity metadata
+func (t *ResoQueryTool) validateFields(params *api.QueryParams) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	if _, exists := t.metadataParser.GetEntityInfo(params.Entity); !exists {
+		return nil
+	}
+
+	var referenced []string
+	referenced = append(referenced, splitFieldList(params.Select)...)
+	referenced = append(referenced, extractFilterFields(params.Filter)...)
+	referenced = append(referenced, extractOrderByFields(params.OrderBy)...)
+
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, field := range referenced {
+		if seen[field] || t.metadataParser.HasField(params.Entity, field) ||
+			t.metadataParser.HasNavigationProperty(params.Entity, field) {
+			continue
+		}
+		seen[field] = true
+
+		line := fmt.Sprintf("- %s", field)
+		if suggestions := t.metadataParser.SuggestFields(params.Entity, field, 3); len(suggestions) > 0 {
+			line += fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+		}
+		unknown = append(unknown, line)
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown field(s) for entity %s:\n%s\nSet skip_validation=true to send the query anyway", params.Entity, strings.Join(unknown, "\n"))
+	}
+
+	if params.Expand != "" {
+		if err := t.validateExpand(params.Entity, params.Expand); err != nil {
+			return fmt.Errorf("%s\nSet skip_validation=true to send the query anyway", err.Error())
+		}
+	}
+	return nil
+}
+
+// validateExpand checks each expand segment names a navigation property of entity and
+// that its nested $select, $filter and $orderby fields exist on the target entity
+func (t *ResoQueryTool) validateExpand(entity, expand string) error {
+	segments, err := parseExpand(expand)
+	if err != nil {
+		return err
+	}
+
+	entityInfo, exists := t.metadataParser.GetEntityInfo(entity)
+	if !exists {
+		return nil
+	}
+
+	for _, segment := range segments {
+		nav, exists := entityInfo.NavigationProperties[segment.Name]
+		if !exists {
+			hint := ""
+			if suggestions := t.metadataParser.SuggestNavigationProperties(entity, segment.Name, 3); len(suggestions) > 0 {
+				hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+			}
+			return fmt.Errorf("invalid expand segment %q: %s cannot be expanded from %s%s", segment.Raw, segment.Name, entity, hint)
+		}
+		if _, exists := t.metadataParser.GetEntityInfo(nav.TargetType); !exists {
+			continue
+		}
+
+		var referenced []string
+		referenced = append(referenced, splitFieldList(segment.Options["$select"])...)
+		referenced = append(referenced, extractFilterFields(segment.Options["$filter"])...)
+		referenced = append(referenced, extractOrderByFields(segment.Options["$orderby"])...)
+		for _, field := range referenced {
+			if t.metadataParser.HasField(nav.TargetType, field) || t.metadataParser.HasNavigationProperty(nav.TargetType, field) {
+				continue
+			}
+			hint := ""
+			if suggestions := t.metadataParser.SuggestFields(nav.TargetType, field, 3); len(suggestions) > 0 {
+				hint = fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+			}
+			return fmt.Errorf("invalid expand segment %q: %s is not a field of %s%s", segment.Raw, field, nav.TargetType, hint)
+		}
+
+		if nested := segment.Options["$expand"]; nested != "" {
+			if err := t.validateExpand(nav.TargetType, nested); err != nil {
+				parent := segment.Name
+				if nav.TargetType != segment.Name {
+					parent += " (" + nav.TargetType + ")"
+				}
+				return fmt.Errorf("in the nested expand of %s: %w", parent, err)
+			}
+		}
+	}
+	return nil
+}
+
+// bareStringQuotable decides whether autoQuoteFilter may quote a bare word compared
+// against field. With metadata the field must hold strings or enum values and the word
+// must not be another field or navigation property. Without it, camel-case words such
+// as OriginalListPrice are assumed to be field names and left alone.
+func (t *ResoQueryTool) bareStringQuotable(entity string) func(field, value string) bool {
+	return func(field, value string) bool {
+		if t.metadataParser != nil {
+			if entityInfo, exists := t.metadataParser.GetEntityInfo(entity); exists {
+				prop, exists := entityInfo.Properties[field]
+				if !exists || (prop.EnumType == "" && strings.TrimSuffix(strings.TrimPrefix(prop.Type, "Collection("), ")") != "Edm.String") {
+					return false
+				}
+				return !t.metadataParser.HasField(entity, value) && !t.metadataParser.HasNavigationProperty(entity, value)
+			}
+		}
+		for _, r := range value[1:] {
+			if r >= 'A' && r <= 'Z' {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// booleanField reports whether a field of entity holds booleans: Edm.Boolean fields
+// when metadata describes the entity, otherwise fields named like PoolPrivateYN
+func (t *ResoQueryTool) booleanField(entity string) func(field string) bool {
+	return func(field string) bool {
+		if t.metadataParser != nil {
+			if entityInfo, exists := t.metadataParser.GetEntityInfo(entity); exists {
+				prop, exists := entityInfo.Properties[field]
+				return exists && prop.Type == "Edm.Boolean"
+			}
+		}
+		return strings.HasSuffix(field, "YN")
+	}
+}
+
+// checkEnumValues flags filter comparisons against enum-typed fields whose value is not a valid member
+func (t *ResoQueryTool) checkEnumValues(params *api.QueryParams) []string {
+	if t.metadataParser == nil {
+		return nil
+	}
+	entity, exists := t.metadataParser.GetEntityInfo(params.Entity)
+	if !exists {
+		return nil
+	}
+
+	var warnings []string
+	for _, cmp := range extractComparisons(params.Filter) {
+		prop, exists := entity.Properties[cmp.Field]
+		if !exists || prop.EnumType == "" {
+			continue
+		}
+		if _, known := t.metadataParser.GetEnumInfo(prop.EnumType); !known {
+			continue
+		}
+		if t.metadataParser.IsEnumMember(prop.EnumType, cmp.Value) {
+			continue
+		}
+
+		warnings = append(warnings, t.invalidEnumValue(prop.EnumType, cmp.Field, cmp.Value))
+	}
+
+	return warnings
+}
+
+// invalidEnumValue describes a value that is not a member of a field's enum, with
+// close matches and the valid values
+func (t *ResoQueryTool) invalidEnumValue(enumType, field, value string) string {
+	message := fmt.Sprintf("'%s' is not a valid %s value for %s", value, enumType, field)
+	if suggestions := t.metadataParser.SuggestEnumMembers(enumType, value, 3); len(suggestions) > 0 {
+		message += fmt.Sprintf(" (did you mean: %s?)", strings.Join(suggestions, ", "))
+	}
+
+	members := t.metadataParser.GetEnumMemberNames(enumType)
+	if len(members) <= 25 {
+		message += fmt.Sprintf(". Valid values: %s", strings.Join(members, ", "))
+	} else {
+		message += fmt.Sprintf(". %d valid values; use reso_help topic 'enums' to list them", len(members))
+	}
+	return message
+}
+
+// checkInEnums rejects the first value of a structured 'in' condition on an enum field
+// that is not a member of the enum, so a typo cannot silently narrow the results.
+// Nested groups and negations are checked too.
+func (t *ResoQueryTool) checkInEnums(entityName string, filters []interface{}) error {
+	if t.metadataParser == nil {
+		return nil
+	}
+	entity, exists := t.metadataParser.GetEntityInfo(entityName)
+	if !exists {
+		return nil
+	}
+
+	for _, entry := range filters {
+		item, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if not, ok := item["not"].(map[string]interface{}); ok {
+			if err := t.checkInEnums(entityName, []interface{}{not}); err != nil {
+				return err
+			}
+			continue
+		}
+		if nested, ok := item["filters"].([]interface{}); ok {
+			if err := t.checkInEnums(entityName, nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		op, _ := item["op"].(string)
+		field, _ := item["field"].(string)
+		values, _ := item["value"].([]interface{})
+		if !strings.EqualFold(strings.TrimSpace(op), "in") {
+			continue
+		}
+		prop, exists := entity.Properties[strings.TrimSpace(field)]
+		if !exists || prop.EnumType == "" {
+			continue
+		}
+		if _, known := t.metadataParser.GetEnumInfo(prop.EnumType); !known {
+			continue
+		}
+		for _, value := range values {
+			text, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s is a %s enum, so its 'in' values must be strings, got %v", prop.Name, prop.EnumType, value)
+			}
+			if !t.metadataParser.IsEnumMember(prop.EnumType, text) {
+				return fmt.Errorf("%s", t.invalidEnumValue(prop.EnumType, prop.Name, text))
+			}
+		}
+	}
+	return nil
+}
+
+// createSummary creates a human-readable summary of the response. When humanizeRows is
+// positive, the first records are tabulated with formatted prices and areas instead of
+// listing the sample record's field names.
+func (t *ResoQueryTool) createSummary(response *api.APIResponse, humanizeRows int) string {
 	var summary strings.Builder
 
 	summary.WriteString(fmt.Sprintf("RESO API Query Results\n"))
@@ -283,9 +1220,20 @@ func (t *ResoQueryTool) createSummary(response *api.APIResponse) string {
 
 	summary.WriteString(fmt.Sprintf("Entity: %s\n", response.RequestParams.Entity))
 	summary.WriteString(fmt.Sprintf("Records Returned: %d\n", response.Count))
-	summary.WriteString(fmt.Sprintf("Total Records Available: %d\n", response.TotalCount))
-	summary.WriteString(fmt.Sprintf("Request Time: %s\n", response.RequestTime.Format("2006-01-02 15:04:05 UTC")))
-	summary.WriteString(fmt.Sprintf("Response Time: %s\n\n", response.ResponseTime))
+	if len(response.Group) > 0 {
+		summary.WriteString(fmt.Sprintf("Groups Returned: %d\n", len(response.Group)))
+	}
+	if response.TotalCountExact {
+		summary.WriteString(fmt.Sprintf("Total Records Available: %d (exact count)\n", response.TotalCount))
+	} else {
+		summary.WriteString(fmt.Sprintf("Total Records Available: unknown, at least %d (the server reported no count)\n", response.TotalCount))
+	}
+	summary.WriteString(fmt.Sprintf("Request Time: %s\n", response.RequestTime.In(t.config.Location()).Format("2006-01-02 15:04:05 MST")))
+	summary.WriteString(fmt.Sprintf("Response Time: %s\n", response.ResponseTime))
+	if response.FromCache {
+		summary.WriteString(fmt.Sprintf("Served From Cache: yes (%s old)\n", response.CacheAge.Round(time.Second)))
+	}
+	summary.WriteString("\n")
 
 	// Query parameters
 	if response.RequestParams.Select != "" {
@@ -294,6 +1242,9 @@ func (t *ResoQueryTool) createSummary(response *api.APIResponse) string {
 	if response.RequestParams.Filter != "" {
 		summary.WriteString(fmt.Sprintf("Filter: %s\n", response.RequestParams.Filter))
 	}
+	if response.RequestParams.Search != "" {
+		summary.WriteString(fmt.Sprintf("Search: %s\n", response.RequestParams.Search))
+	}
 	if response.RequestParams.Top > 0 {
 		summary.WriteString(fmt.Sprintf("Top: %d\n", response.RequestParams.Top))
 	}
@@ -306,13 +1257,35 @@ func (t *ResoQueryTool) createSummary(response *api.APIResponse) string {
 	summary.WriteString(fmt.Sprintf("Ignore Nulls: %t\n", response.RequestParams.IgnoreNulls))
 	summary.WriteString(fmt.Sprintf("Ignore Case: %t\n", response.RequestParams.IgnoreCase))
 
+	if len(response.RedactedFields) > 0 {
+		summary.WriteString(fmt.Sprintf("PII Redaction: masked values in %s\n", strings.Join(response.RedactedFields, ", ")))
+	}
+
 	// Pagination info
+	if response.RequestParams.FetchAll {
+		summary.WriteString(fmt.Sprintf("\nPages Fetched: %d\n", response.PagesFetched))
+		if response.MoreAvailable() {
+			summary.WriteString("Record cap reached before all pages were fetched (raise max_records to collect more)\n")
+		}
+	}
 	if response.NextLink != "" {
 		summary.WriteString(fmt.Sprintf("\nNext Page Available: %s\n", response.NextLink))
 	}
+	if response.RequestParams.DeltaLink != "" {
+		summary.WriteString(fmt.Sprintf("\nChanges Since Delta Link: %d records, %d of them removed\n", len(response.Value), countRemoved(response.Value)))
+	}
+	if response.DeltaLink != "" {
+		summary.WriteString(fmt.Sprintf("\nDelta Link Available: %s\n(store it and pass it as delta_link later to fetch only the records changed since this query)\n", response.DeltaLink))
+	}
+
+	// Grouped $apply results carry their rows in Group rather than Value
+	summary.WriteString(formatGroupTable(response.RequestParams.Apply, response.Group, t.config.Location()))
 
 	// Sample data preview
-	if len(response.Value) > 0 {
+	if humanizeRows > 0 {
+		summary.WriteString(formatRecordTable(response.Value, humanizeRows, t.config.Location()))
+		summary.WriteString(formatValueRanges(response.Value))
+	} else if len(response.Value) > 0 {
 		summary.WriteString(fmt.Sprintf("\nSample Record Fields:\n"))
 		sampleRecord := response.Value[0]
 		fieldCount := 0
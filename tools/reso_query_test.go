@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// newTestQueryTool returns a reso_query tool backed by the bundled metadata
+func newTestQueryTool(t *testing.T) *ResoQueryTool {
+	t.Helper()
+	parser := metadata.NewMetadataParser()
+	if err := parser.ParseFromFile("../constellation1_metadata.xml"); err != nil {
+		t.Fatalf("loading metadata: %v", err)
+	}
+	return NewResoQueryToolWithMetadata(nil, config.DefaultConfig(), parser)
+}
+
+func TestInFilterEnumValues(t *testing.T) {
+	tool := newTestQueryTool(t)
+	tests := []struct {
+		name    string
+		filters []interface{}
+		wantErr string
+	}{
+		{
+			name: "all values valid",
+			filters: []interface{}{
+				map[string]interface{}{"field": "StandardStatus", "op": "in", "value": []interface{}{"Active", "Pending"}},
+			},
+		},
+		{
+			name: "mix of valid and invalid values",
+			filters: []interface{}{
+				map[string]interface{}{"field": "StandardStatus", "op": "in", "value": []interface{}{"Active", "Pendng", "Closed"}},
+			},
+			wantErr: "'Pendng' is not a valid StandardStatus value",
+		},
+		{
+			name: "invalid value in a negated group",
+			filters: []interface{}{
+				map[string]interface{}{"not": map[string]interface{}{
+					"logic": "or",
+					"filters": []interface{}{
+						map[string]interface{}{"field": "StandardStatus", "op": "in", "value": []interface{}{"Closed", "Sold"}},
+					},
+				}},
+			},
+			wantErr: "'Sold' is not a valid StandardStatus value",
+		},
+		{
+			name: "non-enum field is not checked",
+			filters: []interface{}{
+				map[string]interface{}{"field": "City", "op": "in", "value": []interface{}{"Seattle", "Anywhere"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := tool.parseArguments(map[string]interface{}{"entity": "Property", "filters": tt.filters})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.Contains(params.Filter, " in (") {
+					t.Errorf("filter = %q, want an OData in (...) expression", params.Filter)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to name the invalid member: %q", err, tt.wantErr)
+			}
+		})
+	}
+}
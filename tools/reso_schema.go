@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// ResoSchemaTool implements the reso_schema MCP tool, returning the parsed metadata as JSON
+type ResoSchemaTool struct {
+	metadataParser *metadata.MetadataParser
+}
+
+// NewResoSchemaTool creates a new RESO schema tool
+func NewResoSchemaTool(parser *metadata.MetadataParser) *ResoSchemaTool {
+	return &ResoSchemaTool{
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoSchemaTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_schema",
+		Description: "Return the RESO schema as machine-readable JSON, generated from the API metadata. Includes every entity's fields (name, type, nullable, isCollection, enumType) and expandable navigation properties, and optionally enum types with their members. Use this instead of reso_help when an agent needs the schema as data rather than Markdown.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Limit the output to a single entity (e.g. 'Property'). Leave empty for all entities.",
+				},
+				"include_enums": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include enum types and their members. When an entity is given, only enums referenced by that entity are included. Default: false.",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// Execute executes the RESO schema tool
+func (t *ResoSchemaTool) Execute(args map[string]interface{}) MCPToolResult {
+	if t.metadataParser == nil {
+		return errorResult("Error: metadata is not loaded. Use reso_help topic 'metadata' for details on how metadata is located.")
+	}
+
+	entity, _ := args["entity"].(string)
+	includeEnums, _ := args["include_enums"].(bool)
+
+	schema, err := t.metadataParser.ExportSchema(strings.TrimSpace(entity), includeEnums)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error: %s", err.Error()))
+	}
+
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting schema: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: string(schemaJSON),
+		}},
+		StructuredContent: schema,
+	}
+}
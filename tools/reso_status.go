@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/auth"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+	"github.com/rennietech/constellation1-mcp-server/metrics"
+)
+
+// ResoStatusTool implements the reso_status MCP tool, which reports credential,
+// authentication, API and metadata health in one place
+type ResoStatusTool struct {
+	client         *api.Client
+	oauthClient    *auth.OAuthClient
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+}
+
+// StatusReport represents the result of a status check
+type StatusReport struct {
+	CredentialsConfigured bool              `json:"credentials_configured"`
+	Profile               string            `json:"profile,omitempty"`
+	BaseURL               string            `json:"base_url"`
+	MetadataURL           string            `json:"metadata_url"`
+	AuthURL               string            `json:"auth_url"`
+	GrantType             string            `json:"grant_type"`
+	AuthOK                bool              `json:"auth_ok"`
+	AuthError             string            `json:"auth_error,omitempty"`
+	TokenExpiry           string            `json:"token_expiry,omitempty"`
+	APIOK                 bool              `json:"api_ok"`
+	APIError              string            `json:"api_error,omitempty"`
+	APILatencyMs          int64             `json:"api_latency_ms,omitempty"`
+	MetadataLoaded        bool              `json:"metadata_loaded"`
+	EntityCount           int               `json:"entity_count,omitempty"`
+	EnumCount             int               `json:"enum_count,omitempty"`
+	Metrics               *metrics.Snapshot `json:"metrics"`
+}
+
+// NewResoStatusTool creates a new RESO status tool
+func NewResoStatusTool(client *api.Client, oauthClient *auth.OAuthClient, cfg *config.Config, parser *metadata.MetadataParser) *ResoStatusTool {
+	return &ResoStatusTool{
+		client:         client,
+		oauthClient:    oauthClient,
+		config:         cfg,
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoStatusTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_status",
+		Description: "Check server health before running queries: reports whether credentials are configured, whether OAuth authentication succeeds (and when the token expires), whether a trivial API query succeeds, the base URL in use, whether metadata is loaded with entity/enum counts, and counters of queries, API requests, cache hits and token requests since the server started. Use this first when queries fail unexpectedly.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}
+}
+
+// Execute executes the RESO status tool
+func (t *ResoStatusTool) Execute(args map[string]interface{}) MCPToolResult {
+	report := &StatusReport{
+		CredentialsConfigured: t.config.ValidateCredentials() == nil,
+		Profile:               t.config.Profile,
+		BaseURL:               t.client.BaseURL(),
+		MetadataURL:           t.client.MetadataURL(),
+		AuthURL:               t.config.AuthURL,
+		GrantType:             t.oauthClient.GrantType(),
+	}
+
+	// Authentication, then a trivial query, only when credentials are present
+	if report.CredentialsConfigured {
+		if _, err := t.oauthClient.GetToken(); err != nil {
+			report.AuthError = err.Error()
+		} else {
+			report.AuthOK = true
+			if expiry := t.oauthClient.TokenExpiry(); !expiry.IsZero() {
+				report.TokenExpiry = expiry.UTC().Format(time.RFC3339)
+			}
+
+			start := time.Now()
+			if err := t.client.TestConnection(); err != nil {
+				report.APIError = err.Error()
+			} else {
+				report.APIOK = true
+			}
+			report.APILatencyMs = time.Since(start).Milliseconds()
+		}
+	}
+
+	// Metadata
+	if t.metadataParser != nil {
+		report.MetadataLoaded = true
+		report.EntityCount = len(t.metadataParser.GetEntityNames())
+
+		enums := make(map[*metadata.EnumInfo]bool)
+		for _, name := range t.metadataParser.GetEnumNames() {
+			if enumInfo, ok := t.metadataParser.GetEnumInfo(name); ok {
+				enums[enumInfo] = true
+			}
+		}
+		report.EnumCount = len(enums)
+	}
+
+	// Counters are read after the checks above, so they include them
+	report.Metrics = metrics.Take()
+
+	return MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: formatStatus(report),
+		}},
+		StructuredContent: report,
+	}
+}
+
+// formatStatus renders the status report as a checklist
+func formatStatus(report *StatusReport) string {
+	var out strings.Builder
+
+	out.WriteString("RESO Server Status\n")
+	out.WriteString("==================\n\n")
+
+	check := func(ok bool) string {
+		if ok {
+			return "✅"
+		}
+		return "❌"
+	}
+
+	out.WriteString(fmt.Sprintf("%s Credentials configured", check(report.CredentialsConfigured)))
+	if report.Profile != "" {
+		out.WriteString(fmt.Sprintf(" (profile: %s)", report.Profile))
+	}
+	out.WriteString("\n")
+	if !report.CredentialsConfigured {
+		switch report.GrantType {
+		case auth.GrantPassword:
+			out.WriteString("   Set client_id, username and password in MCP settings or RESO_CLIENT_ID/RESO_OAUTH_USERNAME/RESO_OAUTH_PASSWORD\n")
+		case auth.GrantRefreshToken:
+			out.WriteString("   Set client_id and refresh_token in MCP settings or RESO_CLIENT_ID/RESO_OAUTH_REFRESH_TOKEN\n")
+		default:
+			out.WriteString("   Set client_id and client_secret in MCP settings, command-line flags or RESO_CLIENT_ID/RESO_CLIENT_SECRET\n")
+		}
+	}
+
+	switch {
+	case report.AuthOK:
+		out.WriteString(fmt.Sprintf("%s Authentication succeeded (token expires %s)\n", check(true), report.TokenExpiry))
+	case report.AuthError != "":
+		out.WriteString(fmt.Sprintf("%s Authentication failed: %s\n", check(false), report.AuthError))
+	default:
+		out.WriteString("⏭️ Authentication not attempted\n")
+	}
+
+	switch {
+	case report.APIOK:
+		out.WriteString(fmt.Sprintf("%s API reachable (test query took %d ms)\n", check(true), report.APILatencyMs))
+	case report.APIError != "":
+		out.WriteString(fmt.Sprintf("%s API test query failed: %s\n", check(false), report.APIError))
+	default:
+		out.WriteString("⏭️ API test query not attempted\n")
+	}
+
+	if report.MetadataLoaded {
+		out.WriteString(fmt.Sprintf("%s Metadata loaded (%d entities, %d enum types)\n", check(true), report.EntityCount, report.EnumCount))
+	} else {
+		out.WriteString(fmt.Sprintf("%s Metadata not loaded (dynamic help and field validation disabled; see reso_help topic 'metadata')\n", check(false)))
+	}
+
+	out.WriteString(fmt.Sprintf("\nBase URL: %s\n", report.BaseURL))
+	out.WriteString(fmt.Sprintf("Metadata URL: %s\n", report.MetadataURL))
+	out.WriteString(fmt.Sprintf("Auth URL: %s (%s grant)\n", report.AuthURL, report.GrantType))
+
+	if m := report.Metrics; m != nil {
+		out.WriteString(fmt.Sprintf("\nActivity since start (%s):\n", (time.Duration(m.UptimeSeconds) * time.Second).String()))
+		out.WriteString(fmt.Sprintf("- Queries: %d (%d failed)\n", m.Queries, m.QueryErrors))
+		out.WriteString(fmt.Sprintf("- API requests: %d (%d failed)\n", m.APIRequests, m.APIErrors))
+		out.WriteString(fmt.Sprintf("- Cache: %d hits, %d misses (%.0f%% hit rate), %d evictions\n", m.CacheHits, m.CacheMisses, m.CacheHitRate*100, m.CacheEvictions))
+		out.WriteString(fmt.Sprintf("- Token requests: %d (%d in the background, %d failed)\n", m.TokenRequests, m.TokenRefreshes, m.TokenErrors))
+	}
+
+	return out.String()
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+	"github.com/rennietech/constellation1-mcp-server/config"
+	"github.com/rennietech/constellation1-mcp-server/metadata"
+)
+
+// syncCursorField is the field used to track incremental replication progress
+const syncCursorField = "ModificationTimestamp"
+
+// ResoSyncTool implements the reso_sync MCP tool, which pulls records changed
+// since a ModificationTimestamp cursor for incremental replication
+type ResoSyncTool struct {
+	client         *api.Client
+	config         *config.Config
+	metadataParser *metadata.MetadataParser
+}
+
+// SyncResult represents one incremental sync batch. The cursor is the timestamp and
+// key of the last record returned, so records sharing a timestamp are never skipped.
+type SyncResult struct {
+	Entity        string                   `json:"entity"`
+	KeyField      string                   `json:"key_field"`
+	Since         string                   `json:"since"`
+	SinceKey      interface{}              `json:"since_key,omitempty"`
+	NextSince     string                   `json:"next_since"`
+	NextSinceKey  interface{}              `json:"next_since_key,omitempty"`
+	Records       int                      `json:"records_returned"`
+	MoreAvailable bool                     `json:"more_available"`
+	Value         []map[string]interface{} `json:"value"`
+}
+
+// NewResoSyncTool creates a new RESO sync tool
+func NewResoSyncTool(client *api.Client, cfg *config.Config, parser *metadata.MetadataParser) *ResoSyncTool {
+	return &ResoSyncTool{
+		client:         client,
+		config:         cfg,
+		metadataParser: parser,
+	}
+}
+
+// GetToolDefinition returns the MCP tool definition
+func (t *ResoSyncTool) GetToolDefinition() MCPTool {
+	return MCPTool{
+		Name:        "reso_sync",
+		Description: "Incrementally replicate an entity: return every record changed after the given cursor, oldest change first and by key within a timestamp, following @odata.nextLink automatically. The result includes 'next_since' and 'next_since_key', the ModificationTimestamp and key of the last record returned, which should be persisted and passed as 'since' and 'since_key' on the next call. When 'more_available' is true, call again immediately with the new cursor.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"entity": map[string]interface{}{
+					"type":        "string",
+					"description": "Entity to replicate (e.g. 'Property', 'Member', 'Office'). Must expose ModificationTimestamp.",
+				},
+				"since": map[string]interface{}{
+					"type":        "string",
+					"description": "Cursor: ISO 8601 timestamp (e.g. '2024-06-01T00:00:00Z') or date ('2024-06-01'). Only records modified strictly after it are returned, unless since_key is given.",
+				},
+				"since_key": map[string]interface{}{
+					"type":        []string{"string", "number"},
+					"description": "Key of the last record already synced at the 'since' timestamp (the previous call's next_since_key). Records modified at exactly 'since' with a greater key are returned too.",
+				},
+				"key_field": map[string]interface{}{
+					"type":        "string",
+					"description": "Field that orders records sharing a timestamp (default: the entity's key from the metadata, or ListingKey)",
+				},
+				"select": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated list of fields to return. ModificationTimestamp and the key field are always included. Leave empty for all fields.",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Additional OData filter combined with the cursor condition (e.g. \"StandardStatus eq 'Active'\").",
+				},
+				"max_records": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum records to return in this batch. Default: %d.", api.DefaultMaxRecords),
+					"minimum":     1,
+				},
+			},
+			"required": []string{"entity", "since"},
+		},
+	}
+}
+
+// Execute executes the RESO sync tool
+func (t *ResoSyncTool) Execute(args map[string]interface{}) MCPToolResult {
+	// Validate credentials before proceeding
+	if err := t.config.ValidateCredentials(); err != nil {
+		return errorResult(fmt.Sprintf("Configuration error: %s", err.Error()))
+	}
+
+	entity, _ := args["entity"].(string)
+	entity = strings.TrimSpace(entity)
+	if entity == "" {
+		return errorResult("Error parsing arguments: entity is required")
+	}
+
+	sinceArg, _ := args["since"].(string)
+	since, err := parseSyncCursor(sinceArg)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+
+	if !t.hasCursorField(entity) {
+		return errorResult(fmt.Sprintf("Entity %s has no %s field and cannot be synced incrementally", entity, syncCursorField))
+	}
+
+	keyField, err := entityKeyField(t.metadataParser, entity, args)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+	}
+	sinceKey := args["since_key"]
+	if text, ok := sinceKey.(string); ok && strings.TrimSpace(text) == "" {
+		sinceKey = nil
+	}
+
+	maxRecords := api.DefaultMaxRecords
+	switch v := args["max_records"].(type) {
+	case float64:
+		maxRecords = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			maxRecords = parsed
+		}
+	}
+	if maxRecords <= 0 {
+		maxRecords = api.DefaultMaxRecords
+	}
+
+	cursor := since.Format(time.RFC3339Nano)
+	filter := fmt.Sprintf("%s gt %s", syncCursorField, cursor)
+	if sinceKey != nil {
+		literal, err := syncKeyLiteral(sinceKey)
+		if err != nil {
+			return errorResult(fmt.Sprintf("Error parsing arguments: %s", err.Error()))
+		}
+		filter = fmt.Sprintf("%s gt %s or (%s eq %s and %s gt %s)", syncCursorField, cursor, syncCursorField, cursor, keyField, literal)
+	}
+	if extra, ok := args["filter"].(string); ok {
+		if err := checkFilterOperators(extra, "", t.config.AllowedOperators); err != nil {
+			return errorResult(fmt.Sprintf("Policy error: %s", err.Error()))
+		}
+		filter = combineFilters(extra, filter)
+	}
+
+	params := api.QueryParams{
+		Entity:      entity,
+		Filter:      filter,
+		OrderBy:     syncCursorField + " asc, " + keyField + " asc",
+		IgnoreNulls: true,
+		FetchAll:    true,
+		MaxRecords:  maxRecords,
+	}
+	if sel, ok := args["select"].(string); ok {
+		params.Select = ensureSelected(strings.TrimSpace(sel), syncCursorField, keyField)
+	}
+
+	response, err := t.client.Query(params)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error executing sync query: %s", err.Error()))
+	}
+
+	result := &SyncResult{
+		Entity:        entity,
+		KeyField:      keyField,
+		Since:         cursor,
+		SinceKey:      sinceKey,
+		NextSince:     cursor,
+		NextSinceKey:  sinceKey,
+		Records:       len(response.Value),
+		MoreAvailable: response.MoreAvailable(),
+		Value:         response.Value,
+	}
+	if len(response.Value) > 0 {
+		last := response.Value[len(response.Value)-1]
+		if ts, ok := recordTimestamp(last); ok {
+			result.NextSince = ts.Format(time.RFC3339Nano)
+			result.NextSinceKey = last[keyField]
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error formatting response: %s", err.Error()))
+	}
+
+	return MCPToolResult{
+		Content: []MCPContent{
+			{
+				Type: "text",
+				Text: t.createSummary(result, response.PagesFetched),
+			},
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Sync Batch:\n```json\n%s\n```", string(resultJSON)),
+			},
+		},
+	}
+}
+
+// hasCursorField reports whether the entity exposes ModificationTimestamp; entities
+// unknown to the metadata are assumed to have it
+func (t *ResoSyncTool) hasCursorField(entity string) bool {
+	if t.metadataParser == nil {
+		return true
+	}
+	if _, exists := t.metadataParser.GetEntityInfo(entity); !exists {
+		return true
+	}
+	return t.metadataParser.HasField(entity, syncCursorField)
+}
+
+// parseSyncCursor parses an ISO 8601 timestamp or date into a UTC time
+func parseSyncCursor(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("since is required")
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.UTC(), nil
+	}
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day, nil
+	}
+	return time.Time{}, fmt.Errorf("since must be an ISO 8601 timestamp like 2024-06-01T00:00:00Z, got %q", value)
+}
+
+// recordTimestamp returns a record's ModificationTimestamp
+func recordTimestamp(record map[string]interface{}) (time.Time, bool) {
+	value, ok := record[syncCursorField].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts.UTC(), true
+}
+
+// syncKeyLiteral formats a since_key value as an OData literal
+func syncKeyLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return QuoteODataString(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("since_key must be a string or number")
+	}
+}
+
+// createSummary creates a human-readable summary of the sync batch
+func (t *ResoSyncTool) createSummary(result *SyncResult, pages int) string {
+	var summary strings.Builder
+
+	summary.WriteString("RESO Sync Results\n")
+	summary.WriteString("=================\n\n")
+
+	summary.WriteString(fmt.Sprintf("Entity: %s\n", result.Entity))
+	summary.WriteString(fmt.Sprintf("Since: %s\n", result.Since))
+	summary.WriteString(fmt.Sprintf("Records Returned: %d\n", result.Records))
+	summary.WriteString(fmt.Sprintf("Pages Fetched: %d\n", pages))
+	summary.WriteString(fmt.Sprintf("Next Cursor (next_since): %s\n", result.NextSince))
+	if result.NextSinceKey != nil {
+		summary.WriteString(fmt.Sprintf("Next Key (next_since_key): %v\n", result.NextSinceKey))
+	}
+
+	if result.MoreAvailable {
+		summary.WriteString("\nMore changes are available: call reso_sync again with since set to next_since and since_key set to next_since_key.\n")
+	} else {
+		summary.WriteString("\nUp to date: no further changes after next_since.\n")
+	}
+
+	return summary.String()
+}
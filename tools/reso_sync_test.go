@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestSyncCursorBreaksTimestampTies pages through records that all share one
+// ModificationTimestamp, which a timestamp-only cursor could never get past
+func TestSyncCursorBreaksTimestampTies(t *testing.T) {
+	const ts = "2024-06-01T12:00:00Z"
+	var filters []string
+	client, cfg := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got, want := query.Get("$orderby"), "ModificationTimestamp asc, ListingKey asc"; got != want {
+			t.Errorf("$orderby = %q, want %q", got, want)
+		}
+		filters = append(filters, query.Get("$filter"))
+		if len(filters) == 1 {
+			fmt.Fprintf(w, `{"value":[{"ListingKey":"K1","ModificationTimestamp":%[1]q},{"ListingKey":"K2","ModificationTimestamp":%[1]q},{"ListingKey":"K3","ModificationTimestamp":%[1]q}]}`, ts)
+			return
+		}
+		fmt.Fprintf(w, `{"value":[{"ListingKey":"K3","ModificationTimestamp":%q}]}`, ts)
+	})
+	tool := NewResoSyncTool(client, cfg, nil)
+
+	var first SyncResult
+	toolResultJSON(t, tool.Execute(map[string]interface{}{
+		"entity":      "Property",
+		"since":       "2024-06-01T00:00:00Z",
+		"max_records": float64(2),
+	}), &first)
+	if first.Records != 2 || !first.MoreAvailable {
+		t.Fatalf("first batch: %d records, more_available %t; want 2 and true", first.Records, first.MoreAvailable)
+	}
+	if first.NextSince != ts || first.NextSinceKey != "K2" {
+		t.Fatalf("first cursor = %s/%v, want %s/K2", first.NextSince, first.NextSinceKey, ts)
+	}
+
+	var second SyncResult
+	toolResultJSON(t, tool.Execute(map[string]interface{}{
+		"entity":      "Property",
+		"since":       first.NextSince,
+		"since_key":   first.NextSinceKey,
+		"max_records": float64(2),
+	}), &second)
+	if second.Records != 1 || second.MoreAvailable || second.NextSinceKey != "K3" {
+		t.Errorf("second batch: %d records, more_available %t, next key %v; want 1, false, K3", second.Records, second.MoreAvailable, second.NextSinceKey)
+	}
+
+	want := []string{
+		"ModificationTimestamp gt 2024-06-01T00:00:00Z",
+		"ModificationTimestamp gt 2024-06-01T12:00:00Z or (ModificationTimestamp eq 2024-06-01T12:00:00Z and ListingKey gt 'K2')",
+	}
+	for i, filter := range filters {
+		if filter != want[i] {
+			t.Errorf("request %d $filter = %q, want %q", i+1, filter, want[i])
+		}
+	}
+}
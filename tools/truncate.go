@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+)
+
+// responseTruncation records how a response was cut down to fit the size limit
+type responseTruncation struct {
+	Kept     int
+	Total    int
+	Bytes    int
+	MaxBytes int
+}
+
+// truncateResponse drops trailing records until the indented JSON response fits in
+// maxBytes, returning nil when nothing was dropped. Count and TotalCount are left as
+// the server reported them.
+func truncateResponse(response *api.APIResponse, maxBytes int) *responseTruncation {
+	if maxBytes <= 0 || len(response.Value) == 0 {
+		return nil
+	}
+
+	// Measure the envelope once, then each record as it appears nested in "value"
+	records := response.Value
+	response.Value = nil
+	envelope, err := json.MarshalIndent(response, "", "  ")
+	response.Value = records
+	if err != nil {
+		return nil
+	}
+
+	size := len(envelope)
+	kept := len(records)
+	for i, record := range records {
+		encoded, err := json.MarshalIndent(record, "    ", "  ")
+		if err != nil {
+			return nil
+		}
+		// Indentation, separating comma and newline
+		size += len(encoded) + 6
+		if size > maxBytes && kept == len(records) {
+			kept = i
+		}
+	}
+	if kept == len(records) {
+		return nil
+	}
+
+	response.Value = records[:kept]
+	return &responseTruncation{
+		Kept:     kept,
+		Total:    len(records),
+		Bytes:    size,
+		MaxBytes: maxBytes,
+	}
+}
+
+// format explains the truncation and how to get the remaining records; nextSkip is
+// nil when the dropped records cannot be reached with skip
+func (r *responseTruncation) format(nextSkip *int) string {
+	section := fmt.Sprintf("\nTruncated: the response was about %s, over the %s limit, so only the first %d of %d records are included.\n",
+		formatBytes(r.Bytes), formatBytes(r.MaxBytes), r.Kept, r.Total)
+	section += "Narrow select to the fields you need, lower top, or drop large expands such as Media"
+	if nextSkip != nil {
+		section += fmt.Sprintf("; continue with skip=%d for the next records", *nextSkip)
+	}
+	return section + ".\n"
+}
+
+// formatBytes renders a byte count in B, KB or MB
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
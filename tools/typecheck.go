@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/rennietech/constellation1-mcp-server/api"
+)
+
+// maxTypeWarnings caps the type mismatches reported for one response
+const maxTypeWarnings = 5
+
+// typeWarnings decodes each record into the api package's struct for entity and
+// describes the records that do not fit, naming the record by its key when present
+func typeWarnings(entity string, records []map[string]interface{}) []string {
+	if !api.HasTypedEntity(entity) {
+		return []string{fmt.Sprintf("check_types has no typed struct for %s (Property, Member, Office and Media are covered)", entity)}
+	}
+
+	var warnings []string
+	mismatched := 0
+	for i, record := range records {
+		err := api.CheckRecordTypes(entity, record)
+		if err == nil {
+			continue
+		}
+		mismatched++
+		if len(warnings) < maxTypeWarnings {
+			label := fmt.Sprintf("record %d", i+1)
+			if key, ok := record[entity+"Key"].(string); ok && key != "" {
+				label = fmt.Sprintf("%s %s", entity+"Key", key)
+			} else if key, ok := record["ListingKey"].(string); ok && key != "" {
+				label = "ListingKey " + key
+			}
+			warnings = append(warnings, fmt.Sprintf("Type mismatch in %s: %s", label, err.Error()))
+		}
+	}
+	if mismatched > len(warnings) {
+		warnings = append(warnings, fmt.Sprintf("%d more records have type mismatches", mismatched-len(warnings)))
+	}
+	return warnings
+}
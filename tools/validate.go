@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateArguments checks tool call arguments against the tool's InputSchema:
+// required properties, declared types, enum values, numeric bounds, array lengths
+// and unknown properties, recursing into nested objects and arrays. Numbers given as
+// numeric strings are accepted, as the tools parse them; a null value counts as
+// absent. Top-level arguments named in selfChecked only have their type checked,
+// since the tool enforces their enum and bounds itself. Every problem found is
+// reported, in property order.
+func ValidateArguments(schema map[string]interface{}, args map[string]interface{}, selfChecked ...string) error {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	if len(selfChecked) > 0 {
+		schema = typeOnlyProperties(schema, selfChecked)
+	}
+
+	var problems []string
+	validateValue(schema, args, "", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// typeOnlyProperties returns a copy of schema in which the named properties keep
+// only their type and description
+func typeOnlyProperties(schema map[string]interface{}, names []string) map[string]interface{} {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return schema
+	}
+
+	relaxed := make(map[string]interface{}, len(properties))
+	for name, property := range properties {
+		relaxed[name] = property
+	}
+	for _, name := range names {
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			relaxed[name] = map[string]interface{}{
+				"type":        property["type"],
+				"description": property["description"],
+			}
+		}
+	}
+
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	copied["properties"] = relaxed
+	return copied
+}
+
+// validateValue appends a problem for each way value breaks schema; path names the
+// value in messages and is empty for the arguments object itself
+func validateValue(schema map[string]interface{}, value interface{}, path string, problems *[]string) {
+	types := schemaTypes(schema["type"])
+	if len(types) > 0 && !matchesAnyType(value, types) {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s, got %s", describePath(path), strings.Join(types, " or "), describeValue(value)))
+		return
+	}
+
+	if allowed := schemaEnum(schema["enum"]); len(allowed) > 0 && !inEnum(value, allowed) {
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %s, got %s", describePath(path), strings.Join(allowed, ", "), describeValue(value)))
+		return
+	}
+
+	if number, ok := numericValue(value); ok {
+		if minimum, ok := numericValue(schema["minimum"]); ok && number < minimum {
+			*problems = append(*problems, fmt.Sprintf("%s must be at least %s, got %s", describePath(path), formatNumber(minimum), formatNumber(number)))
+		}
+		if maximum, ok := numericValue(schema["maximum"]); ok && number > maximum {
+			*problems = append(*problems, fmt.Sprintf("%s must be at most %s, got %s", describePath(path), formatNumber(maximum), formatNumber(number)))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(schema, v, path, problems)
+	case []interface{}:
+		if minItems, ok := numericValue(schema["minItems"]); ok && float64(len(v)) < minItems {
+			*problems = append(*problems, fmt.Sprintf("%s must have at least %s items, got %d", describePath(path), formatNumber(minItems), len(v)))
+		}
+		if maxItems, ok := numericValue(schema["maxItems"]); ok && float64(len(v)) > maxItems {
+			*problems = append(*problems, fmt.Sprintf("%s must have at most %s items, got %d", describePath(path), formatNumber(maxItems), len(v)))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+// validateObject checks required and declared properties; an object schema without
+// properties accepts any members
+func validateObject(schema map[string]interface{}, object map[string]interface{}, path string, problems *[]string) {
+	for _, name := range schemaStrings(schema["required"]) {
+		if object[name] == nil {
+			*problems = append(*problems, fmt.Sprintf("%s is required", describePath(joinPath(path, name))))
+		}
+	}
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+		if value == nil {
+			continue
+		}
+		property, declared := properties[name].(map[string]interface{})
+		if !declared {
+			*problems = append(*problems, fmt.Sprintf("unknown property %s (expected one of %s)", describePath(joinPath(path, name)), strings.Join(sortedKeys(properties), ", ")))
+			continue
+		}
+		validateValue(property, value, joinPath(path, name), problems)
+	}
+}
+
+// matchesAnyType reports whether value is an instance of one of the JSON types
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether value is an instance of a JSON Schema type
+func matchesType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := numericValue(value)
+		return ok
+	case "integer":
+		number, ok := numericValue(value)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// numericValue converts a JSON number, Go integer or numeric string to a float64
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil && !math.IsNaN(number) && !math.IsInf(number, 0)
+	}
+	return 0, false
+}
+
+// inEnum reports whether value equals one of the allowed values
+func inEnum(value interface{}, allowed []string) bool {
+	text := fmt.Sprint(value)
+	for _, a := range allowed {
+		if text == a {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypes reads a "type" keyword, which may be a single type or a list
+func schemaTypes(value interface{}) []string {
+	if t, ok := value.(string); ok {
+		return []string{t}
+	}
+	return schemaStrings(value)
+}
+
+// schemaEnum reads an "enum" keyword as strings
+func schemaEnum(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		allowed := make([]string, len(v))
+		for i, a := range v {
+			allowed[i] = fmt.Sprint(a)
+		}
+		return allowed
+	}
+	return nil
+}
+
+// schemaStrings reads a keyword holding a list of strings
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, s := range v {
+			if text, ok := s.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// sortedKeys returns the property names of a schema in order
+func sortedKeys(properties map[string]interface{}) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinPath appends a property name to a value path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// describePath names a value in a validation message
+func describePath(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return "'" + path + "'"
+}
+
+// describeValue renders the JSON type and value of an argument for a message
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case float64, int:
+		number, _ := numericValue(v)
+		return "number " + formatNumber(number)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// formatNumber renders a number without a trailing ".0" for whole values
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
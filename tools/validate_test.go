@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rennietech/constellation1-mcp-server/config"
+)
+
+func TestValidateArgumentsSelfChecked(t *testing.T) {
+	cfg := config.DefaultConfig()
+	definition := NewResoQueryTool(nil, cfg).GetToolDefinition()
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr string
+	}{
+		{"entity outside the enum is left to the tool", map[string]interface{}{"entity": "Nope"}, ""},
+		{"top above the maximum is left to the tool", map[string]interface{}{"entity": "Property", "top": 5000.0}, ""},
+		{"self-checked type is still enforced", map[string]interface{}{"entity": "Property", "top": "many"}, "'top' must be integer"},
+		{"null undeclared property counts as absent", map[string]interface{}{"entity": "Property", "bogus": nil}, ""},
+		{"undeclared property is rejected", map[string]interface{}{"entity": "Property", "bogus": 1.0}, "unknown property 'bogus'"},
+		{"other enums are still enforced", map[string]interface{}{"entity": "Property", "explain": "loudly"}, "'explain' must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(definition.InputSchema, tt.args, definition.SelfChecked...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArgumentsWithoutSelfChecked(t *testing.T) {
+	definition := NewResoQueryTool(nil, config.DefaultConfig()).GetToolDefinition()
+	err := ValidateArguments(definition.InputSchema, map[string]interface{}{"entity": "Nope"})
+	if err == nil || !strings.Contains(err.Error(), "'entity' must be one of") {
+		t.Errorf("err = %v, want the enum enforced when entity is not self-checked", err)
+	}
+}
+
+func TestTopSchemaMaximum(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.MaxTop = 0
+	definition := NewResoQueryTool(nil, cfg).GetToolDefinition()
+	top := definition.InputSchema["properties"].(map[string]interface{})["top"].(map[string]interface{})
+	if _, ok := top["maximum"]; ok {
+		t.Errorf("top schema declares maximum %v with max_top disabled", top["maximum"])
+	}
+	if err := ValidateArguments(definition.InputSchema, map[string]interface{}{"entity": "Property", "top": 50.0}); err != nil {
+		t.Errorf("top 50 rejected with max_top disabled: %v", err)
+	}
+
+	cfg.MaxTop = 100
+	top = NewResoQueryTool(nil, cfg).GetToolDefinition().InputSchema["properties"].(map[string]interface{})["top"].(map[string]interface{})
+	if top["maximum"] != 100 {
+		t.Errorf("top maximum = %v, want 100", top["maximum"])
+	}
+}
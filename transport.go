@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Transport names accepted by the -transport flag
+const (
+	// transportLine is newline-delimited JSON, one message per line
+	transportLine = "line"
+	// transportFramed is LSP-style framing with Content-Length headers
+	transportFramed = "framed"
+)
+
+// maxFramedMessageSize bounds the Content-Length accepted from the client
+const maxFramedMessageSize = 64 << 20
+
+// messageReader reads one JSON-RPC message at a time from the client
+type messageReader interface {
+	ReadMessage() ([]byte, error)
+}
+
+// newMessageReader returns a reader for the named transport
+func newMessageReader(transport string, r io.Reader) (messageReader, error) {
+	switch transport {
+	case transportLine:
+		return &lineReader{reader: bufio.NewReader(r)}, nil
+	case transportFramed:
+		return &framedReader{reader: bufio.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q (expected %q or %q)", transport, transportLine, transportFramed)
+	}
+}
+
+// lineReader reads newline-delimited messages, skipping blank lines
+type lineReader struct {
+	reader *bufio.Reader
+}
+
+// ReadMessage returns the next non-blank line, or io.EOF when input ends
+func (r *lineReader) ReadMessage() ([]byte, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// framedReader reads messages preceded by Content-Length headers and a blank line
+type framedReader struct {
+	reader *bufio.Reader
+}
+
+// ReadMessage parses one header block and returns the body it describes
+func (r *framedReader) ReadMessage() ([]byte, error) {
+	headers, err := textproto.NewReader(r.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(headers) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message headers: %w", err)
+	}
+
+	rawLength := strings.TrimSpace(headers.Get("Content-Length"))
+	if rawLength == "" {
+		return nil, fmt.Errorf("message is missing a Content-Length header")
+	}
+	length, err := strconv.Atoi(rawLength)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", rawLength)
+	}
+	if length > maxFramedMessageSize {
+		return nil, fmt.Errorf("Content-Length %d exceeds the %d byte limit", length, maxFramedMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read %d byte message body: %w", length, err)
+	}
+	return body, nil
+}
+
+// writeFrame writes one encoded message in the named transport's format
+func writeFrame(w io.Writer, transport string, payload []byte) error {
+	if transport == transportFramed {
+		_, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(payload), payload)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s\n", payload)
+	return err
+}